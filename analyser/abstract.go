@@ -0,0 +1,262 @@
+package analyser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"rahu/parser/ast"
+)
+
+var abstractDecorators = map[string]bool{
+	"abstractmethod": true, "abc.abstractmethod": true,
+	"abstractproperty": true, "abc.abstractproperty": true,
+	"abstractclassmethod": true, "abc.abstractclassmethod": true,
+	"abstractstaticmethod": true, "abc.abstractstaticmethod": true,
+}
+
+var overrideDecorators = map[string]bool{
+	"override": true, "typing.override": true, "typing_extensions.override": true,
+}
+
+var abcMetaclasses = map[string]bool{"ABCMeta": true, "abc.ABCMeta": true}
+
+// constructorMethods may change their signature freely in subclasses.
+var constructorMethods = map[string]bool{
+	"__init__": true, "__new__": true, "__init_subclass__": true, "__post_init__": true,
+}
+
+func hasDecoratorIn(tree *ast.AST, stmt ast.NodeID, names map[string]bool) bool {
+	for _, decorator := range tree.Decorators(stmt) {
+		if names[exprDottedName(tree, tree.DecoratorExpr(decorator))] {
+			return true
+		}
+	}
+	return false
+}
+
+// declaresABCMeta reports whether a class base list passes metaclass=ABCMeta.
+func (r *Resolver) declaresABCMeta(bases ast.NodeID) bool {
+	tree := r.tree
+	for arg := tree.Node(bases).FirstChild; arg != ast.NoNode; arg = tree.Node(arg).NextSibling {
+		if tree.Node(arg).Kind != ast.NodeKeywordArg {
+			continue
+		}
+		if name, _ := tree.NameText(tree.ChildAt(arg, 0)); name != "metaclass" {
+			continue
+		}
+		metaclass := tree.ChildAt(arg, 1)
+		if abcMetaclasses[exprDottedName(tree, metaclass)] || r.qualifiedName(metaclass) == "abc.ABCMeta" {
+			return true
+		}
+	}
+	return false
+}
+
+// isABCBase reports whether a base expression names abc.ABC without
+// resolving to a class, as when the abc module cannot be loaded. The class
+// is then marked as an ABC rather than given an unknown base.
+func (r *Resolver) isABCBase(expr ast.NodeID) bool {
+	if r.qualifiedName(expr) != "abc.ABC" {
+		return false
+	}
+	sym := r.calleeSymbol(expr)
+	return sym == nil || sym.Kind != SymClass
+}
+
+// qualifiedName returns the dotted name expr refers to, following the
+// module-level import that binds its first name: after `from abc import
+// ABC as Base`, Base is abc.ABC, and after `import abc as a`, a.ABC is too.
+func (r *Resolver) qualifiedName(expr ast.NodeID) string {
+	return qualifiedName(r.tree, r.target, expr)
+}
+
+func qualifiedName(tree *ast.AST, target Target, expr ast.NodeID) string {
+	dotted := exprDottedName(tree, expr)
+	head, rest, dottedRest := strings.Cut(dotted, ".")
+	qualified := head
+	WalkActiveImports(tree, target, func(stmt ast.NodeID, _ bool) {
+		fromImport := tree.Node(stmt).Kind == ast.NodeFromImport
+		var module ast.NodeID
+		aliases := tree.Children(stmt)
+		if fromImport {
+			if tree.Node(stmt).Data != 0 {
+				return
+			}
+			module, aliases = tree.FromImportParts(stmt)
+		}
+		for _, alias := range aliases {
+			imported, asName := tree.AliasParts(alias)
+			bound := asName
+			if bound == ast.NoNode && !fromImport {
+				// `import a.b` binds a, which names itself.
+				continue
+			}
+			if bound == ast.NoNode {
+				bound = imported
+			}
+			if name, _ := tree.NameText(bound); name != head {
+				continue
+			}
+			qualified = exprDottedName(tree, imported)
+			if fromImport {
+				qualified = exprDottedName(tree, module) + "." + qualified
+			}
+		}
+	})
+	if dottedRest {
+		return qualified + "." + rest
+	}
+	return qualified
+}
+
+// isABC reports whether cls is abc.ABC or was marked as using ABCMeta.
+func isABC(cls *Symbol) bool {
+	return cls != nil && (cls.ABC || cls.Name == "ABC")
+}
+
+// abstractMembers returns the @abstractmethod definitions that cls inherits
+// or declares without a concrete override further down its MRO.
+func abstractMembers(cls *Symbol) []*Symbol {
+	seen := make(map[string]bool)
+	var out []*Symbol
+	for _, c := range MRO(cls) {
+		scope := c.Inner
+		if scope == nil {
+			scope = c.Members
+		}
+		if scope == nil {
+			continue
+		}
+		defs := make([]*Symbol, 0, len(scope.Symbols))
+		for _, sym := range scope.Symbols {
+			defs = append(defs, sym)
+		}
+		sort.Slice(defs, func(i, j int) bool { return defs[i].Span.Start < defs[j].Span.Start })
+		for _, sym := range defs {
+			if seen[sym.Name] {
+				continue
+			}
+			seen[sym.Name] = true
+			if sym.Abstract {
+				out = append(out, sym)
+			}
+		}
+	}
+	return out
+}
+
+// checkAbstractInstantiation reports calling an ABC that still has abstract
+// members. Calls through cls or type(self) are left alone since they usually
+// run on a concrete subclass.
+func (r *Resolver) checkAbstractInstantiation(call ast.NodeID) {
+	callee := r.tree.Nodes[call].FirstChild
+	var cls *Symbol
+	switch r.tree.Node(callee).Kind {
+	case ast.NodeName:
+		cls = r.Resolved[callee]
+	case ast.NodeAttribute:
+		cls = r.ResolvedAttr[callee]
+	}
+	if cls == nil || cls.Kind != SymClass || !isABC(cls) {
+		return
+	}
+	members := abstractMembers(cls)
+	if len(members) == 0 {
+		return
+	}
+	names := make([]string, len(members))
+	for i, member := range members {
+		names[i] = member.Name
+	}
+	r.error(r.tree.RangeOf(callee), fmt.Sprintf("cannot instantiate abstract class %s with abstract methods: %s", cls.Name, strings.Join(names, ", ")))
+}
+
+// checkMethodOverride validates a method defined in the body of
+// r.currentClass against the base class method it overrides: @override
+// methods must override something, and the override must accept every
+// positional call the base method accepts.
+func (r *Resolver) checkMethodOverride(stmt ast.NodeID, fn *Symbol) {
+	cls := r.currentClass
+	mro := MRO(cls)
+	for _, c := range mro {
+		if c.DynamicMembers {
+			return
+		}
+	}
+
+	var base, owner *Symbol
+	for _, c := range mro[1:] {
+		if !DefinesMember(c, fn.Name) {
+			continue
+		}
+		owner = c
+		base, _ = LookupMemberOnType(ClassType(c), fn.Name)
+		break
+	}
+
+	nameID, _, _ := r.tree.FunctionParts(stmt)
+	if base == nil {
+		if hasDecoratorIn(r.tree, stmt, overrideDecorators) {
+			r.error(r.tree.RangeOf(nameID), "method marked @override does not override a base class member: "+fn.Name)
+		}
+		return
+	}
+
+	if constructorMethods[fn.Name] || base.Kind != SymFunction || base.Method != fn.Method ||
+		fn.Method == MethodProperty || fn.Decorated || base.Decorated ||
+		len(fn.Overloads) > 0 || len(base.Overloads) > 0 {
+		return
+	}
+	minArgs, maxArgs, ok := positionalArity(fn)
+	baseMin, baseMax, baseOK := positionalArity(base)
+	if !ok || !baseOK || baseMax < 0 {
+		return
+	}
+	label := owner.Name + "." + fn.Name
+	switch {
+	case maxArgs >= 0 && maxArgs < baseMax:
+		r.error(r.tree.RangeOf(nameID), fmt.Sprintf("incompatible override of %s: accepts %s, base accepts %d", label, positionalArguments(maxArgs), baseMax))
+	case minArgs > baseMin:
+		r.error(r.tree.RangeOf(nameID), fmt.Sprintf("incompatible override of %s: requires %s, base requires %d", label, positionalArguments(minArgs), baseMin))
+	}
+}
+
+// positionalArguments renders a count of positional arguments, singular
+// when there is exactly one.
+func positionalArguments(n int) string {
+	if n == 1 {
+		return "1 positional argument"
+	}
+	return fmt.Sprintf("%d positional arguments", n)
+}
+
+// positionalArity counts the positional arguments a method accepts after its
+// implicit first parameter; max is -1 when it takes *args.
+func positionalArity(fn *Symbol) (min, max int, ok bool) {
+	if !hasSignature(fn) {
+		return 0, 0, false
+	}
+	params := OrderedParams(fn)
+	if fn.Method != MethodStaticMethod {
+		if len(params) == 0 || params[0].IsVarArg || params[0].IsKwArg {
+			return 0, 0, false
+		}
+		params = params[1:]
+	}
+	for _, param := range params {
+		switch {
+		case param.IsVarArg:
+			max = -1
+		case param.IsKwArg, param.IsKwOnly:
+		default:
+			if max >= 0 {
+				max++
+			}
+			if param.DefaultValue == "" && max >= 0 {
+				min++
+			}
+		}
+	}
+	return min, max, true
+}
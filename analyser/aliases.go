@@ -0,0 +1,168 @@
+package analyser
+
+import (
+	"rahu/parser/ast"
+)
+
+var typeAliasAnnotations = map[string]bool{
+	"TypeAlias": true, "typing.TypeAlias": true, "typing_extensions.TypeAlias": true,
+}
+
+var newTypeConstructors = map[string]bool{
+	"NewType": true, "typing.NewType": true, "typing_extensions.NewType": true,
+}
+
+// typeForms are the generics whose subscription is always a type
+// expression, so `X = Optional[int]` declares an alias rather than a value.
+var typeForms = map[string]bool{
+	"Optional": true, "Union": true, "Callable": true, "Literal": true, "Annotated": true,
+	"List": true, "Dict": true, "Set": true, "FrozenSet": true, "Tuple": true, "Type": true,
+	"list": true, "dict": true, "set": true, "frozenset": true, "tuple": true, "type": true,
+	"Iterable": true, "Iterator": true, "Sequence": true, "Mapping": true, "MutableMapping": true,
+	"MutableSequence": true, "Generator": true, "AsyncIterator": true, "Awaitable": true,
+}
+
+// isNewTypeDeclaration reports whether value is a NewType("Name", base) call.
+func isNewTypeDeclaration(tree *ast.AST, value ast.NodeID) bool {
+	return tree.Node(value).Kind == ast.NodeCall && newTypeConstructors[calleeDottedName(tree, value)]
+}
+
+// isTypeAliasAnnotation reports an explicit `X: TypeAlias = ...` annotation.
+func isTypeAliasAnnotation(tree *ast.AST, annotation ast.NodeID) bool {
+	return typeAliasAnnotations[exprDottedName(tree, annotation)]
+}
+
+// isImplicitTypeAlias reports whether an unannotated assignment of value
+// declares a type alias: value must be a subscripted generic or a union of
+// types, as in `JSON = dict[str, "JSON"]` or `MaybeInt = int | None`. Plain
+// names are left alone since `Alias = Cls` already reads as the class.
+func (r *Resolver) isImplicitTypeAlias(value ast.NodeID) bool {
+	if r.inFunction {
+		return false
+	}
+	switch r.tree.Node(value).Kind {
+	case ast.NodeSubScript:
+		base := r.tree.ChildAt(value, 0)
+		if typeForms[lastDottedSegment(exprDottedName(r.tree, base))] {
+			return true
+		}
+		sym := r.lookupTypeName(base)
+		return sym != nil && sym.Kind == SymClass && sym.Enum == nil
+	case ast.NodeBinOp:
+		return ast.Operator(r.tree.Node(value).Data) == ast.BitOr &&
+			r.isTypeOperand(r.tree.ChildAt(value, 0)) && r.isTypeOperand(r.tree.ChildAt(value, 1))
+	}
+	return false
+}
+
+func (r *Resolver) isTypeOperand(expr ast.NodeID) bool {
+	switch r.tree.Node(expr).Kind {
+	case ast.NodeNone:
+		return true
+	case ast.NodeName:
+		sym := r.lookupTypeName(expr)
+		return sym != nil && (sym.Kind == SymClass || sym.Kind == SymType || sym.TypeAlias != nil)
+	default:
+		return r.isImplicitTypeAlias(expr)
+	}
+}
+
+// lookupTypeName finds the symbol a bare name refers to without resolving
+// it, so a rejected alias candidate is still visited only once.
+func (r *Resolver) lookupTypeName(expr ast.NodeID) *Symbol {
+	name, ok := r.tree.NameText(expr)
+	if !ok || r.tree.Node(expr).Kind != ast.NodeName {
+		return nil
+	}
+	sym, _ := r.current.Lookup(name)
+	return sym
+}
+
+// declareTypeAlias resolves value as the expansion of the alias sym. While
+// it is being resolved, references to sym inside value, such as the "JSON"
+// in `JSON = dict[str, "JSON"]`, get a placeholder that refers back to the
+// alias instead of a cyclic type.
+func (r *Resolver) declareTypeAlias(sym *Symbol, value ast.NodeID) {
+	placeholder := &Type{AliasRef: sym}
+	sym.TypeAlias = placeholder
+	sym.Inferred = nil
+	sym.InstanceOf = nil
+
+	expansion := r.resolveAnnotation(value)
+	for text, cached := range r.stringAnnotCache {
+		if cached == placeholder {
+			delete(r.stringAnnotCache, text)
+		}
+	}
+	if IsUnknownType(expansion) {
+		sym.TypeAlias = nil
+		return
+	}
+	sym.TypeAlias = expansion
+}
+
+// declareNewType gives the class synthesized for `Name = NewType("Name", base)`
+// its base, so it behaves like a distinct subclass of base.
+func (r *Resolver) declareNewType(sym *Symbol, value ast.NodeID) {
+	sym.Bases = nil
+	name := r.tree.Node(r.tree.Node(value).FirstChild).NextSibling
+	if name == ast.NoNode {
+		return
+	}
+	base := r.tree.Node(name).NextSibling
+	var baseSym *Symbol
+	switch r.tree.Node(base).Kind {
+	case ast.NodeName:
+		baseSym = r.Resolved[base]
+	case ast.NodeAttribute:
+		baseSym = r.ResolvedAttr[base]
+	}
+	if baseSym != nil && (baseSym.Kind == SymClass || baseSym.Kind == SymType) {
+		sym.Bases = []*Symbol{baseSym}
+	}
+}
+
+// expandAlias replaces a recursive alias placeholder with the alias it
+// refers to once that alias has been resolved.
+func expandAlias(t *Type) *Type {
+	if t != nil && t.AliasRef != nil && t.Kind == TypeUnknown && t.AliasRef.TypeAlias != t {
+		return t.AliasRef.TypeAlias
+	}
+	return t
+}
+
+// annotationTypeOf returns the type an annotation naming sym stands for.
+func annotationTypeOf(sym *Symbol) *Type {
+	if sym.TypeAlias != nil {
+		return sym.TypeAlias
+	}
+	if sym.Kind == SymClass {
+		return InstanceType(sym)
+	}
+	t := SymbolType(sym)
+	if t != nil && t.Kind == TypeClass && t.Symbol != nil {
+		// A name bound to a class, as in `Alias = Cls`, names its instances.
+		return InstanceType(t.Symbol)
+	}
+	return t
+}
+
+// unionAnnotation joins the arms of X | Y, Optional[X] or Union[X, Y],
+// keeping whichever side resolved when the other did not.
+func unionAnnotation(left, right *Type) *Type {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	}
+	return UnionType(left, right)
+}
+
+// noneAnnotation is the type None stands for in an annotation.
+func noneAnnotation() *Type {
+	if noneSym := BuiltinSymbol("NoneType"); noneSym != nil {
+		return BuiltinType(noneSym)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package analyser
+
+import (
+	"rahu/parser/ast"
+)
+
+// AllEntry is a name listed in a module's __all__. Node is the string
+// literal listing it, or NoNode for a name taken from another module's
+// __all__.
+type AllEntry struct {
+	Name string
+	Node ast.NodeID
+}
+
+// AllEntries evaluates the module-level __all__ of tree through the forms
+// modules build it up with: assigning a list or tuple of strings, `+=`,
+// .extend(), .append() and .remove(), concatenation, and `mod.__all__` of an
+// imported module, whose names moduleAll returns for the local name mod.
+// moduleAll may be nil. Operands of any other form contribute no names. ok
+// reports whether the module assigns __all__ at all. Only the branches of
+// if statements that can run on target are evaluated.
+func AllEntries(tree *ast.AST, target Target, moduleAll func(local string) []string) (entries []AllEntry, ok bool) {
+	if tree == nil || tree.Root == ast.NoNode {
+		return nil, false
+	}
+	e := &allEvaluator{tree: tree, target: target, moduleAll: moduleAll}
+	e.block(tree.Root)
+	return e.entries, e.assigned
+}
+
+// AllNames returns the names AllEntries lists, or nil when the module has
+// no __all__ or lists nothing.
+func AllNames(tree *ast.AST, target Target, moduleAll func(local string) []string) []string {
+	entries, _ := AllEntries(tree, target, moduleAll)
+	if len(entries) == 0 {
+		return nil
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name
+	}
+	return names
+}
+
+type allEvaluator struct {
+	tree      *ast.AST
+	target    Target
+	moduleAll func(string) []string
+	entries   []AllEntry
+	assigned  bool
+}
+
+// block evaluates the statements of a module-level block, entering the
+// branches of if statements that can run on the target interpreter.
+func (e *allEvaluator) block(block ast.NodeID) {
+	tree := e.tree
+	for stmt := tree.Node(block).FirstChild; stmt != ast.NoNode; stmt = tree.Node(stmt).NextSibling {
+		switch tree.Node(stmt).Kind {
+		case ast.NodeAssign:
+			value := tree.Node(stmt).FirstChild
+			for target := tree.Node(value).NextSibling; target != ast.NoNode; target = tree.Node(target).NextSibling {
+				if isAllName(tree, target) {
+					e.entries, e.assigned = e.eval(value), true
+				}
+			}
+		case ast.NodeAnnAssign:
+			target, _, value := tree.AnnAssignParts(stmt)
+			if value != ast.NoNode && isAllName(tree, target) {
+				e.entries, e.assigned = e.eval(value), true
+			}
+		case ast.NodeAugAssign:
+			target := tree.Node(stmt).FirstChild
+			if tree.Node(stmt).Data == uint32(ast.AugAdd) && isAllName(tree, target) {
+				e.entries = append(e.entries, e.eval(tree.Node(target).NextSibling)...)
+			}
+		case ast.NodeExprStmt:
+			e.call(tree.Node(stmt).FirstChild)
+		case ast.NodeIf:
+			_, body, orelse, bodyLive, orelseLive := e.target.ifBranches(tree, stmt)
+			if bodyLive && body != ast.NoNode {
+				e.block(body)
+			}
+			if orelseLive && orelse != ast.NoNode {
+				e.block(orelse)
+			}
+		}
+	}
+}
+
+// call applies __all__.extend(...), __all__.append(...) or
+// __all__.remove(...).
+func (e *allEvaluator) call(expr ast.NodeID) {
+	tree := e.tree
+	if tree.Node(expr).Kind != ast.NodeCall {
+		return
+	}
+	callee := tree.Node(expr).FirstChild
+	arg := tree.Node(callee).NextSibling
+	if tree.Node(callee).Kind != ast.NodeAttribute || arg == ast.NoNode {
+		return
+	}
+	base := tree.Node(callee).FirstChild
+	method, _ := tree.NameText(tree.Node(base).NextSibling)
+	if !isAllName(tree, base) {
+		return
+	}
+	switch method {
+	case "extend":
+		e.entries = append(e.entries, e.eval(arg)...)
+	case "append":
+		if name, ok := tree.StringText(arg); ok {
+			e.entries = append(e.entries, AllEntry{Name: name, Node: arg})
+		}
+	case "remove":
+		name, _ := tree.StringText(arg)
+		for i, entry := range e.entries {
+			if entry.Name == name {
+				e.entries = append(e.entries[:i:i], e.entries[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// eval returns the names an expression assigned to __all__ lists.
+func (e *allEvaluator) eval(expr ast.NodeID) []AllEntry {
+	tree := e.tree
+	switch tree.Node(expr).Kind {
+	case ast.NodeList, ast.NodeTuple:
+		var entries []AllEntry
+		for child := tree.Node(expr).FirstChild; child != ast.NoNode; child = tree.Node(child).NextSibling {
+			if name, ok := tree.StringText(child); ok {
+				entries = append(entries, AllEntry{Name: name, Node: child})
+			}
+		}
+		return entries
+	case ast.NodeBinOp:
+		if tree.Node(expr).Data != uint32(ast.Add) {
+			return nil
+		}
+		left := tree.Node(expr).FirstChild
+		return append(e.eval(left), e.eval(tree.Node(left).NextSibling)...)
+	case ast.NodeName:
+		if isAllName(tree, expr) {
+			return append([]AllEntry(nil), e.entries...)
+		}
+	case ast.NodeAttribute:
+		base := tree.Node(expr).FirstChild
+		local, ok := tree.NameText(base)
+		if !ok || e.moduleAll == nil || !isAllName(tree, tree.Node(base).NextSibling) {
+			return nil
+		}
+		var entries []AllEntry
+		for _, name := range e.moduleAll(local) {
+			entries = append(entries, AllEntry{Name: name})
+		}
+		return entries
+	}
+	return nil
+}
+
+func isAllName(tree *ast.AST, id ast.NodeID) bool {
+	name, ok := tree.NameText(id)
+	return ok && name == "__all__"
+}
+
+// UndefinedAllDiagnostics warns about names listed in __all__ that the
+// module never defines. isSubmodule reports the submodules of a package,
+// which `from package import *` imports without the package defining them;
+// it may be nil. Modules with a star import or a module __getattr__ are left
+// alone, as any name may be defined.
+func (r *Resolver) UndefinedAllDiagnostics(isSubmodule func(name string) bool) []SemanticError {
+	entries, ok := AllEntries(r.tree, r.target, nil)
+	if !ok || r.global.Symbols["__getattr__"] != nil || hasStarImport(r.tree, r.target) {
+		return nil
+	}
+	candidates := visibleNames(r.global)
+	var out []SemanticError
+	for _, entry := range entries {
+		if entry.Node == ast.NoNode || r.global.Symbols[entry.Name] != nil {
+			continue
+		}
+		if isSubmodule != nil && isSubmodule(entry.Name) {
+			continue
+		}
+		err := SemanticError{
+			Span:     StringContentSpan(r.tree, entry.Node),
+			Msg:      "undefined name in __all__: " + entry.Name,
+			Severity: SeverityWarning,
+		}
+		if err.Span != r.tree.RangeOf(entry.Node) {
+			// Only the text between plain quotes can be swapped for a name.
+			err = SuggestName(err, entry.Name, candidates)
+		}
+		out = append(out, err)
+	}
+	return out
+}
+
+// StringContentSpan returns the span of a plain string literal without its
+// quotes, so that replacing it keeps them, or the whole literal when it has
+// prefixes, triple quotes or escapes.
+func StringContentSpan(tree *ast.AST, id ast.NodeID) ast.Range {
+	span := tree.RangeOf(id)
+	text, _ := tree.StringText(id)
+	if span.End-span.Start == uint32(len(text))+2 {
+		return ast.Range{Start: span.Start + 1, End: span.End - 1}
+	}
+	return span
+}
+
+func hasStarImport(tree *ast.AST, target Target) bool {
+	found := false
+	WalkActiveImports(tree, target, func(stmt ast.NodeID, _ bool) {
+		if tree.Node(stmt).Kind != ast.NodeFromImport {
+			return
+		}
+		_, aliases := tree.FromImportParts(stmt)
+		for _, alias := range aliases {
+			target, _ := tree.AliasParts(alias)
+			if name, _ := tree.NameText(target); name == "*" {
+				found = true
+			}
+		}
+	})
+	return found
+}
@@ -1,6 +1,8 @@
 package analyser
 
 import (
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -2323,3 +2325,1813 @@ def test():
 		t.Logf("Warning: child_attr not resolved, but recorded on class")
 	}
 }
+
+func synthesizedInitParams(t *testing.T, cls *Symbol) []*Symbol {
+	t.Helper()
+	if cls == nil || cls.Members == nil {
+		t.Fatalf("missing promoted class %+v", cls)
+	}
+	init, ok := cls.Members.Lookup("__init__")
+	if !ok || init.Params == nil {
+		t.Fatalf("expected synthesized __init__ on %s, got %+v", cls.Name, init)
+	}
+	return init.Params[1:]
+}
+
+func paramNames(params []*Symbol) string {
+	names := make([]string, len(params))
+	for i, param := range params {
+		names[i] = param.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestResolveDataclassSynthesizesInit(t *testing.T) {
+	src := `from dataclasses import dataclass, field, InitVar, KW_ONLY
+from typing import ClassVar
+
+@dataclass
+class Base:
+    x: int
+    y: str = "a"
+
+@dataclass
+class Child(Base):
+    counter: ClassVar[int] = 0
+    z: list[int] = field(default_factory=list)
+    x: int = field(default=3)
+    hidden: int = field(init=False)
+    seed: InitVar[int] = 0
+    _: KW_ONLY
+    flag: bool = False
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	if _, errs := Resolve(tree, global); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+
+	params := synthesizedInitParams(t, global.Symbols["Child"])
+	if got := paramNames(params); got != "x,y,z,seed,flag" {
+		t.Fatalf("unexpected __init__ parameters: %s", got)
+	}
+	if params[0].DefaultValue != "3" {
+		t.Fatalf("expected redefined x to default to 3, got %q", params[0].DefaultValue)
+	}
+	if params[2].DefaultValue != "<factory>" {
+		t.Fatalf("expected factory default for z, got %q", params[2].DefaultValue)
+	}
+	if typ := params[2].Inferred; typ == nil || typ.Kind != TypeList {
+		t.Fatalf("expected list type for z, got %+v", typ)
+	}
+	if typ := params[3].Inferred; typ == nil || typ.Kind != TypeBuiltin || typ.Symbol.Name != "int" {
+		t.Fatalf("expected InitVar to unwrap to int, got %+v", typ)
+	}
+	if !params[4].IsKwOnly || params[0].IsKwOnly {
+		t.Fatalf("expected only flag to be keyword-only: %+v", params)
+	}
+
+	matchArgs, ok := global.Symbols["Child"].Members.Lookup("__match_args__")
+	if !ok || matchArgs.DefaultValue != "('x', 'y', 'z')" {
+		t.Fatalf("unexpected __match_args__: %+v", matchArgs)
+	}
+}
+
+func TestResolveDataclassOptions(t *testing.T) {
+	src := `import dataclasses
+import attr
+
+@dataclasses.dataclass(kw_only=True)
+class Options:
+    a: int
+    b: int = 1
+
+@dataclasses.dataclass(init=False)
+class Manual:
+    a: int
+
+@attr.s
+class Classic:
+    _x = attr.ib()
+    y: int = 0
+    z = attr.ib(default=2)
+
+from attrs import define, field as attr_field
+
+@define
+class Modern:
+    _a: int
+    b: int = attr_field(default=1)
+
+def frozen(cls):
+    return cls
+
+def field(default):
+    return default
+
+@frozen
+class Plain:
+    x: int
+
+@dataclasses.dataclass
+class Local:
+    x: int = field(3)
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	if _, errs := Resolve(tree, global); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+
+	params := synthesizedInitParams(t, global.Symbols["Options"])
+	if !params[0].IsKwOnly || !params[1].IsKwOnly {
+		t.Fatalf("expected kw_only=True to mark every field: %+v", params)
+	}
+	if _, ok := global.Symbols["Manual"].Members.Lookup("__init__"); ok {
+		t.Fatal("expected init=False to skip the synthesized __init__")
+	}
+	classic := synthesizedInitParams(t, global.Symbols["Classic"])
+	if got := paramNames(classic); got != "x,z" {
+		t.Fatalf("unexpected attr.s parameters: %s", got)
+	}
+	modern := synthesizedInitParams(t, global.Symbols["Modern"])
+	if got := paramNames(modern); got != "a,b" || modern[1].DefaultValue != "1" {
+		t.Fatalf("unexpected attrs.define parameters: %+v", modern)
+	}
+	if _, ok := global.Symbols["Plain"].Members.Lookup("__init__"); ok {
+		t.Fatal("expected a local decorator named frozen not to synthesize __init__")
+	}
+	local := synthesizedInitParams(t, global.Symbols["Local"])
+	if local[0].DefaultValue == "" {
+		t.Fatalf("expected a local function named field to be a plain default: %+v", local[0])
+	}
+}
+
+func TestResolveNamedTupleConstructors(t *testing.T) {
+	src := `from typing import NamedTuple
+from collections import namedtuple
+
+class Point(NamedTuple):
+    x: int
+    y: int = 0
+
+Pair = namedtuple("Pair", "left right")
+p = Pair(1, 2)
+q = p.left
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	resolver, _ := Resolve(tree, global)
+
+	if got := paramNames(synthesizedInitParams(t, global.Symbols["Point"])); got != "x,y" {
+		t.Fatalf("unexpected NamedTuple parameters: %s", got)
+	}
+
+	pairType := SymbolType(global.Symbols["Pair"])
+	if pairType == nil || pairType.Kind != TypeClass {
+		t.Fatalf("expected namedtuple() to produce a class, got %+v", pairType)
+	}
+	if got := paramNames(synthesizedInitParams(t, pairType.Symbol)); got != "left,right" {
+		t.Fatalf("unexpected namedtuple parameters: %s", got)
+	}
+	if typ := SymbolType(global.Symbols["p"]); typ == nil || typ.Kind != TypeInstance || typ.Symbol != pairType.Symbol {
+		t.Fatalf("expected Pair(...) to produce a Pair instance, got %+v", typ)
+	}
+	if sym := resolver.ResolvedAttr[mustAttributeNode(t, tree, "left")]; sym == nil || sym.Kind != SymField {
+		t.Fatalf("expected p.left to resolve to the namedtuple field, got %+v", sym)
+	}
+}
+
+func TestResolveDataclassTransformDecorator(t *testing.T) {
+	src := `from typing import dataclass_transform
+
+@dataclass_transform()
+def model(cls):
+    return cls
+
+@model
+class User:
+    name: str
+    age: int = 0
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	if _, errs := Resolve(tree, global); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+	if got := paramNames(synthesizedInitParams(t, global.Symbols["User"])); got != "name,age" {
+		t.Fatalf("unexpected dataclass_transform parameters: %s", got)
+	}
+}
+
+func TestResolvePropertyAndMethodKinds(t *testing.T) {
+	src := `class Temp:
+    def __init__(self):
+        self._c = 0
+
+    @property
+    def celsius(self) -> float:
+        return self._c
+
+    @celsius.setter
+    def celsius(self, value: float):
+        self._c = value
+
+    @classmethod
+    def make(cls):
+        return cls()
+
+    @staticmethod
+    def convert(value):
+        return value
+
+t = Temp()
+c = t.celsius
+descriptor = Temp.celsius
+m = Temp.make()
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	if _, errs := Resolve(tree, global); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+
+	temp := global.Symbols["Temp"]
+	celsius, ok := temp.Members.Lookup("celsius")
+	if !ok || celsius.Method != MethodProperty || len(celsius.Accessors) != 1 {
+		t.Fatalf("expected celsius property with one setter, got %+v", celsius)
+	}
+	if typ := SymbolType(global.Symbols["c"]); typ == nil || typ.Kind != TypeBuiltin || typ.Symbol.Name != "float" {
+		t.Fatalf("expected t.celsius to use the getter return type, got %+v", typ)
+	}
+	if typ := SymbolType(global.Symbols["descriptor"]); typ == nil || typ.Symbol == nil || typ.Symbol.Name != "property" {
+		t.Fatalf("expected Temp.celsius to be the property object, got %+v", typ)
+	}
+
+	make, _ := temp.Members.Lookup("make")
+	if make == nil || make.Method != MethodClassMethod {
+		t.Fatalf("expected make to be a classmethod, got %+v", make)
+	}
+	if typ := SymbolType(make.Inner.Symbols["cls"]); typ == nil || typ.Kind != TypeClass || typ.Symbol != temp {
+		t.Fatalf("expected cls to be typed as the class, got %+v", typ)
+	}
+
+	convert, _ := temp.Members.Lookup("convert")
+	if convert == nil || convert.Method != MethodStaticMethod {
+		t.Fatalf("expected convert to be a staticmethod, got %+v", convert)
+	}
+	if typ := SymbolType(convert.Inner.Symbols["value"]); typ != nil {
+		t.Fatalf("expected staticmethod parameter to stay untyped, got %+v", typ)
+	}
+}
+
+func TestResolveOverloadedCallReturnTypes(t *testing.T) {
+	src := `from typing import overload
+
+@overload
+def parse(value: int) -> int: ...
+@overload
+def parse(value: str, strict: bool = False) -> str: ...
+def parse(value, strict=False):
+    return value
+
+class Box:
+    @overload
+    def get(self) -> int: ...
+    @overload
+    def get(self, key: str) -> str: ...
+
+a = parse(1)
+b = parse("x")
+c = parse(value="y", strict=True)
+d = Box().get("k")
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	if _, errs := Resolve(tree, global); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+
+	parse := global.Symbols["parse"]
+	if parse == nil || len(parse.Overloads) != 2 || parse.Def == parse.Overloads[0].Def {
+		t.Fatalf("expected the implementation to collect two overloads, got %+v", parse)
+	}
+	want := map[string]string{"a": "int", "b": "str", "c": "str", "d": "str"}
+	for name, typeName := range want {
+		typ := SymbolType(global.Symbols[name])
+		if typ == nil || typ.Kind != TypeBuiltin || typ.Symbol.Name != typeName {
+			t.Fatalf("expected %s to be %s, got %+v", name, typeName, typ)
+		}
+	}
+}
+
+func TestResolveCallArgumentDiagnostics(t *testing.T) {
+	src := `def f(a, b=1, *, c, d=2): pass
+def g(x, /, y): pass
+def h(*args, **kwargs): pass
+
+class Box:
+    def __init__(self, size):
+        self.size = size
+
+    def put(self, item): pass
+
+f(1, c=3)
+f(1, 2, c=3, d=4)
+f(*[1], **{})
+g(1, 2)
+h(1, 2, x=3)
+Box(1).put(2)
+f(1, 2, 3, c=4)
+f(1, e=5, c=3)
+f(1, a=2, c=3)
+f(1)
+f(c=1)
+g(x=1, y=2)
+f(1, c=2, c=3)
+Box()
+Box(1).put()
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+
+	want := []string{
+		"too many positional arguments for f(): expected 2, got 3",
+		"unexpected keyword argument 'e' for f()",
+		"multiple values for argument 'a' in f()",
+		"missing required keyword-only argument 'c' for f()",
+		"missing required argument 'a' for f()",
+		"positional-only argument 'x' passed as keyword to g()",
+		"missing required argument 'x' for g()",
+		"keyword argument repeated: c",
+		"missing required argument 'size' for Box()",
+		"missing required argument 'item' for Box.put()",
+	}
+	got := make([]string, len(errs))
+	for i, err := range errs {
+		got[i] = err.Msg
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected call diagnostics:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestResolveUnknownAttributeWarnings(t *testing.T) {
+	src := `class Base:
+    def __init__(self):
+        self.count = 0
+
+class Child(Base):
+    __slots__ = ("label",)
+
+    def reset(self):
+        return self.cuont
+
+class Proxy:
+    def __getattr__(self, name):
+        return name
+
+class Dynamic:
+    __slots__ = make_slots()
+
+class Unknown(Missing):
+    pass
+
+c = Child()
+c.label
+c.coutn
+Child.rest
+Proxy().anything
+Dynamic().anything
+Unknown().anything
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+
+	var warnings []string
+	for _, err := range errs {
+		if err.Severity == SeverityWarning {
+			warnings = append(warnings, err.Msg)
+		}
+	}
+	want := []string{
+		"'Child' object has no attribute 'coutn'; did you mean 'count'?",
+		"class 'Child' has no attribute 'rest'; did you mean 'reset'?",
+		"'Child' object has no attribute 'cuont'; did you mean 'count'?",
+	}
+	sort.Strings(warnings)
+	sort.Strings(want)
+	if strings.Join(warnings, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected attribute warnings:\n%s", strings.Join(warnings, "\n"))
+	}
+}
+
+func TestClassAccessIncludesTypeAndMetaclassMembers(t *testing.T) {
+	src := `class Meta(type):
+    def hello(cls):
+        return cls
+
+class B(metaclass=Meta):
+    pass
+
+class C(B):
+    pass
+
+class D:
+    pass
+
+D.mro()
+B.hello()
+C.hello()
+D.nope
+B().hello
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+
+	var warnings []string
+	for _, err := range errs {
+		if strings.Contains(err.Msg, "has no attribute") {
+			warnings = append(warnings, err.Msg)
+		}
+	}
+	want := []string{
+		"'B' object has no attribute 'hello'",
+		"class 'D' has no attribute 'nope'",
+	}
+	sort.Strings(warnings)
+	if strings.Join(warnings, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected attribute warnings:\n%s", strings.Join(warnings, "\n"))
+	}
+}
+
+func TestShortNamesGetNoSuggestion(t *testing.T) {
+	src := `class A:
+    def __init__(self):
+        self.m = 1
+
+def f(p):
+    return A().z, r, x
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+
+	var got []string
+	for _, err := range errs {
+		if err.Suggestion != "" || strings.Contains(err.Msg, "did you mean") {
+			got = append(got, err.Msg)
+		}
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected no suggestions between one-letter names, got:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestResolveC3MethodResolutionOrder(t *testing.T) {
+	src := `class A:
+    def greet(self):
+        return 1
+
+class B(A):
+    pass
+
+class C(A):
+    def greet(self):
+        return "c"
+
+class D(B, C):
+    pass
+
+class E(A, B):
+    pass
+
+d = D()
+d.greet()
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+
+	d, _ := global.Lookup("D")
+	var order []string
+	for _, cls := range MRO(d) {
+		order = append(order, cls.Name)
+	}
+	if got := strings.Join(order, " "); got != "D B C A" {
+		t.Fatalf("expected MRO D B C A, got %s", got)
+	}
+
+	c, _ := global.Lookup("C")
+	greet, ok := LookupMemberOnType(InstanceType(d), "greet")
+	if !ok || greet != c.Inner.Symbols["greet"] {
+		t.Fatalf("expected D.greet to come from C, got %+v", greet)
+	}
+
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Msg)
+	}
+	want := "cannot create a consistent method resolution order (MRO) for bases A, B"
+	if len(msgs) != 1 || msgs[0] != want {
+		t.Fatalf("expected %q, got %v", want, msgs)
+	}
+}
+
+func TestResolveOperatorResultTypes(t *testing.T) {
+	src := `from typing import Self
+
+class Vec:
+    def __add__(self, other: "Vec") -> "Vec": ...
+    def __mul__(self, k: float) -> "Vec": ...
+    def __rmul__(self, k: float) -> "Vec": ...
+    def __neg__(self) -> "Vec": ...
+    def __lt__(self, other: "Vec") -> "Mask": ...
+
+class Mask:
+    pass
+
+class Path:
+    def __truediv__(self, key: str) -> Self: ...
+
+class LocalPath(Path):
+    pass
+
+price = 2.5
+qty = 3
+total = price * qty
+ratio = qty / 2
+count = qty + True
+label = "n" * qty
+v = Vec()
+summed = v + v
+scaled = 2 * v
+negated = -v
+mask = v < v
+flag = qty < 4
+nested = LocalPath() / "x"
+acc = 0
+acc += 0.5
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	if _, errs := Resolve(tree, global); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+
+	want := map[string]string{
+		"total":   "float",
+		"ratio":   "float",
+		"count":   "int",
+		"label":   "str",
+		"summed":  "Vec",
+		"scaled":  "Vec",
+		"negated": "Vec",
+		"mask":    "Mask",
+		"flag":    "bool",
+		"nested":  "LocalPath",
+	}
+	for name, typeName := range want {
+		typ := SymbolType(global.Symbols[name])
+		if IsUnknownType(typ) || typ.Symbol == nil || typ.Symbol.Name != typeName {
+			t.Fatalf("expected %s to be %s, got %+v", name, typeName, typ)
+		}
+	}
+
+	acc := SymbolType(global.Symbols["acc"])
+	if acc == nil || acc.Kind != TypeUnion || len(acc.Union) != 2 {
+		t.Fatalf("expected acc to be int | float, got %+v", acc)
+	}
+}
+
+func TestResolveIterationAndContextManagerTypes(t *testing.T) {
+	src := `from typing import Iterator, AsyncIterator
+
+class Row:
+    pass
+
+class Cursor:
+    def __iter__(self) -> "Cursor": ...
+    def __next__(self) -> Row: ...
+
+class Table:
+    def rows(self) -> Iterator[Row]: ...
+
+class Stream:
+    def __aiter__(self) -> AsyncIterator[Row]: ...
+
+class Session:
+    def __enter__(self) -> "Session": ...
+
+class Pool:
+    async def __aenter__(self) -> Session: ...
+
+for row in Cursor():
+    a = row
+
+for row2 in Table().rows():
+    b = row2
+
+d = {"k": 1}
+for k, v in d.items():
+    pass
+
+for key in d:
+    pass
+
+with Session() as s:
+    pass
+
+async def main():
+    async for item in Stream():
+        c = item
+    async with Pool() as conn:
+        e = conn
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	if _, errs := Resolve(tree, global); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+
+	main := global.Symbols["main"]
+	want := map[*Symbol]string{
+		global.Symbols["a"]:     "Row",
+		global.Symbols["b"]:     "Row",
+		global.Symbols["k"]:     "str",
+		global.Symbols["v"]:     "int",
+		global.Symbols["key"]:   "str",
+		global.Symbols["s"]:     "Session",
+		main.Inner.Symbols["c"]: "Row",
+		main.Inner.Symbols["e"]: "Session",
+	}
+	for sym, typeName := range want {
+		typ := SymbolType(sym)
+		if IsUnknownType(typ) || typ.Symbol == nil || typ.Symbol.Name != typeName {
+			t.Fatalf("expected %s to be %s, got %+v", sym.Name, typeName, typ)
+		}
+	}
+}
+
+func TestResolveUnpackingDistributesTupleItems(t *testing.T) {
+	src := `def pair() -> tuple[int, str]: ...
+def many() -> tuple[int, ...]: ...
+
+first, *middle, last = 1, "a", "b", 2.0
+(x, y), z = (1, "s"), 2.5
+p, q = pair()
+m, *n = many()
+for i, *rest in [(1, "a", "b")]:
+    pass
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	if _, errs := Resolve(tree, global); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+
+	want := map[string]string{
+		"first": "int",
+		"last":  "float",
+		"x":     "int",
+		"y":     "str",
+		"z":     "float",
+		"p":     "int",
+		"q":     "str",
+		"m":     "int",
+		"i":     "int",
+	}
+	for name, typeName := range want {
+		typ := SymbolType(global.Symbols[name])
+		if IsUnknownType(typ) || typ.Symbol == nil || typ.Symbol.Name != typeName {
+			t.Fatalf("expected %s to be %s, got %+v", name, typeName, typ)
+		}
+	}
+	for name, elemName := range map[string]string{"middle": "str", "n": "int", "rest": "str"} {
+		typ := SymbolType(global.Symbols[name])
+		if IsUnknownType(typ) || typ.Kind != TypeList || typ.Elem.Symbol == nil || typ.Elem.Symbol.Name != elemName {
+			t.Fatalf("expected %s to be list[%s], got %+v", name, elemName, typ)
+		}
+	}
+}
+
+func TestResolveUnpackingArityMismatch(t *testing.T) {
+	src := `def pair() -> tuple[int, str]: ...
+
+a, b = 1, 2, 3
+c, d, e, *f = pair()
+for g, h in [(1, 2, 3)]:
+    pass
+u, *v, *w = pair()
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+
+	want := []string{
+		"too many values to unpack (expected 2, got 3)",
+		"not enough values to unpack (expected at least 3, got 2)",
+		"too many values to unpack (expected 2, got 3)",
+		"multiple starred expressions in assignment",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %+v", len(want), errs)
+	}
+	for i, msg := range want {
+		if errs[i].Msg != msg {
+			t.Fatalf("error %d: expected %q, got %q", i, msg, errs[i].Msg)
+		}
+	}
+}
+
+func TestResolveDecoratorReturnTypes(t *testing.T) {
+	src := `from typing import Callable, Concatenate, ParamSpec, TypeVar
+from functools import partial
+
+P = ParamSpec("P")
+R = TypeVar("R")
+F = TypeVar("F")
+
+class Request:
+    pass
+
+def logged(fn: Callable[P, R]) -> Callable[P, R]: ...
+def with_request(fn: Callable[Concatenate[Request, P], R]) -> Callable[P, R]: ...
+def listify(fn: Callable[P, R]) -> Callable[P, list[R]]: ...
+def same(fn: F) -> F: ...
+def retry(fn: Callable[P, R], times: int) -> Callable[P, R]: ...
+def opaque(fn): ...
+
+@logged
+def add(a: int, b: int) -> int: ...
+
+@with_request
+def handle(req: Request, name: str) -> str: ...
+
+@listify
+def one() -> int: ...
+
+@same
+def ident(x: str) -> str: ...
+
+@partial(retry, times=3)
+def fetch(url: str) -> bytes: ...
+
+@opaque
+def hidden(x: int) -> int: ...
+
+a = add(1, 2)
+h = handle("n")
+o = one()
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	if _, errs := Resolve(tree, global); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+
+	params := func(name string) string {
+		var names []string
+		for _, param := range OrderedParams(global.Symbols[name]) {
+			names = append(names, param.Name)
+		}
+		return strings.Join(names, ",")
+	}
+	for name, want := range map[string]string{"add": "a,b", "handle": "name", "one": "", "ident": "x", "fetch": "url"} {
+		if got := params(name); got != want || global.Symbols[name].Decorated {
+			t.Fatalf("expected %s(%s) with a known signature, got (%s) decorated=%v", name, want, got, global.Symbols[name].Decorated)
+		}
+	}
+	if !global.Symbols["hidden"].Decorated {
+		t.Fatal("expected an unannotated decorator to keep the signature opaque")
+	}
+
+	for name, want := range map[string]string{"a": "int", "h": "str"} {
+		typ := SymbolType(global.Symbols[name])
+		if IsUnknownType(typ) || typ.Symbol == nil || typ.Symbol.Name != want {
+			t.Fatalf("expected %s to be %s, got %+v", name, want, typ)
+		}
+	}
+	if typ := SymbolType(global.Symbols["o"]); IsUnknownType(typ) || typ.Kind != TypeList || typ.Elem.Symbol == nil || typ.Elem.Symbol.Name != "int" {
+		t.Fatalf("expected o to be list[int], got %+v", typ)
+	}
+
+	src = "def add(a: int) -> int: ...\n\ndef logged(fn: Callable[P, R]) -> Callable[P, R]: ...\n\n@logged\ndef f(x): ...\n\nf(1, 2)\n"
+	src = "from typing import Callable, ParamSpec, TypeVar\nP = ParamSpec(\"P\")\nR = TypeVar(\"R\")\n" + src
+	tree = parser.New(src).Parse()
+	global, _ = BuildScopes(tree, src)
+	if _, errs := Resolve(tree, global); len(errs) != 1 || !strings.Contains(errs[0].Msg, "f()") {
+		t.Fatalf("expected the decorated signature to be checked at the call, got %+v", errs)
+	}
+}
+
+func TestResolvePartialCallSignature(t *testing.T) {
+	src := `import functools
+
+def add(a: int, b: int, c: int = 0) -> int: ...
+
+p = functools.partial(add, 1)
+q = functools.partial(add, b=2)
+x = p(2)
+p(2, 3, 4)
+q(1)
+q(1, 2)
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+
+	var got []string
+	for _, err := range errs {
+		got = append(got, err.Msg)
+	}
+	want := []string{
+		"too many positional arguments for p(): expected 2, got 3",
+		"too many positional arguments for q(): expected 1, got 2",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected errors:\n%s", strings.Join(got, "\n"))
+	}
+
+	signature := func(name string) string {
+		var params []string
+		for _, param := range SymbolType(global.Symbols[name]).Params {
+			if param.IsKwOnly {
+				params = append(params, "*"+param.Name)
+			} else {
+				params = append(params, param.Name)
+			}
+		}
+		return strings.Join(params, ",")
+	}
+	if got := signature("p"); got != "b,c" {
+		t.Fatalf("expected p to take (b, c), got (%s)", got)
+	}
+	if got := signature("q"); got != "a,*b,*c" {
+		t.Fatalf("expected q to take (a, *, b, c), got (%s)", got)
+	}
+	if typ := SymbolType(global.Symbols["x"]); IsUnknownType(typ) || typ.Symbol == nil || typ.Symbol.Name != "int" {
+		t.Fatalf("expected p(2) to be int, got %+v", typ)
+	}
+}
+
+func TestResolveProtocolStructuralCompatibility(t *testing.T) {
+	src := `from typing import Protocol, runtime_checkable
+
+class Runner(Protocol):
+    name: str
+    def run(self) -> int: ...
+
+@runtime_checkable
+class Closer(Protocol):
+    def close(self) -> None: ...
+
+class Job:
+    name: str = "job"
+    def run(self) -> int:
+        return 1
+    def close(self) -> None: ...
+
+class Broken:
+    def run(self) -> str:
+        return ""
+
+class Named(Runner):
+    pass
+
+def start(runner: Runner) -> int:
+    return runner.run()
+
+start(Job())
+start(Named())
+start(Broken())
+start(runner=Broken())
+r: Runner = Broken()
+
+def shut(x: Job):
+    if isinstance(x, Closer):
+        x.close()
+    if isinstance(x, Runner):
+        pass
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	resolver, errs := Resolve(tree, global)
+
+	want := []string{
+		"Broken does not satisfy protocol Runner: missing name; incompatible run",
+		"Broken does not satisfy protocol Runner: missing name; incompatible run",
+		"Broken does not satisfy protocol Runner: missing name; incompatible run",
+		"isinstance() requires a @runtime_checkable protocol: Runner",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %+v", len(want), errs)
+	}
+	for i, msg := range want {
+		if errs[i].Msg != msg {
+			t.Fatalf("error %d: expected %q, got %q", i, msg, errs[i].Msg)
+		}
+	}
+
+	runner, closer := global.Symbols["Runner"], global.Symbols["Closer"]
+	if !runner.Protocol || runner.RuntimeCheckable || !closer.RuntimeCheckable {
+		t.Fatalf("unexpected protocol flags: Runner=%v/%v Closer=%v", runner.Protocol, runner.RuntimeCheckable, closer.RuntimeCheckable)
+	}
+	if got := strings.Join(ProtocolMembers(runner), ","); got != "name,run" {
+		t.Fatalf("expected Runner members name,run, got %q", got)
+	}
+	if !ImplementsProtocol(global.Symbols["Job"], runner) || !ImplementsProtocol(global.Symbols["Named"], runner) {
+		t.Fatal("expected Job and Named to implement Runner")
+	}
+	if ImplementsProtocol(global.Symbols["Broken"], runner) || SatisfiesProtocol(InstanceType(global.Symbols["Broken"]), runner) {
+		t.Fatal("expected Broken not to satisfy Runner")
+	}
+
+	for id := range tree.Nodes {
+		node := ast.NodeID(id)
+		if tree.Node(node).Kind != ast.NodeAttribute {
+			continue
+		}
+		if name, _ := tree.NameText(tree.ChildAt(node, 1)); name != "close" {
+			continue
+		}
+		typ := resolver.ExprTypes[tree.ChildAt(node, 0)]
+		if IsUnknownType(typ) || typ.Kind != TypeInstance || typ.Symbol.Name != "Job" {
+			t.Fatalf("expected isinstance(x, Closer) to narrow x to Job, got %+v", typ)
+		}
+	}
+}
+
+func TestResolveEnumMembersAndExhaustiveness(t *testing.T) {
+	src := `class Enum: ...
+class Flag(Enum): ...
+def auto(): ...
+def assert_never(x): ...
+
+class Color(Enum):
+    RED = 1
+    GREEN = "g"
+    BLUE = auto()
+    CRIMSON = RED
+    _ignore_ = 5
+    def describe(self) -> str:
+        return ""
+
+class Perm(Flag):
+    R = auto()
+    W = auto()
+
+red = Color.RED
+red_value = Color.RED.value
+both = Perm.R | Perm.W
+for each in Color:
+    pass
+
+def chain(c: Color):
+    if c is Color.RED:
+        pass
+    elif c == Color.GREEN:
+        pass
+    else:
+        assert_never(c)
+
+def full(c: Color):
+    match c:
+        case Color.RED:
+            pass
+        case Color.GREEN | Color.BLUE:
+            pass
+        case _:
+            assert_never(c)
+
+def partial(c: Color):
+    match c:
+        case Color.RED as first:
+            pass
+        case other:
+            assert_never(other)
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+
+	want := []string{
+		"assert_never() reached with unhandled enum values: Color.BLUE",
+		"assert_never() reached with unhandled enum values: Color.GREEN, Color.BLUE",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %+v", len(want), errs)
+	}
+	for i, msg := range want {
+		if errs[i].Msg != msg {
+			t.Fatalf("error %d: expected %q, got %q", i, msg, errs[i].Msg)
+		}
+	}
+
+	color := global.Symbols["Color"]
+	if color.Enum == nil || color.Enum.Flag {
+		t.Fatalf("expected Color to be a plain enum, got %+v", color.Enum)
+	}
+	var names, values []string
+	for i, member := range color.Enum.Members {
+		names = append(names, member.Name)
+		values = append(values, color.Enum.Values[i].Symbol.Name)
+	}
+	if got := strings.Join(names, ","); got != "RED,GREEN,BLUE" {
+		t.Fatalf("expected members RED,GREEN,BLUE, got %q", got)
+	}
+	if got := strings.Join(values, ","); got != "int,str,int" {
+		t.Fatalf("expected values int,str,int, got %q", got)
+	}
+	red := color.Inner.Symbols["RED"]
+	if typ := color.Inner.Symbols["CRIMSON"].Inferred; typ == nil || typ.Literal != red {
+		t.Fatalf("expected CRIMSON to alias RED, got %+v", typ)
+	}
+	if !IsEnumMember(red) || IsEnumMember(color.Inner.Symbols["describe"]) {
+		t.Fatal("expected RED, but not describe, to be an enum member")
+	}
+	if typ := global.Symbols["red"].Inferred; typ == nil || typ.Symbol != color || typ.Literal != red {
+		t.Fatalf("expected red to be Literal[Color.RED], got %+v", typ)
+	}
+	if typ := global.Symbols["red_value"].Inferred; typ == nil || typ.Symbol.Name != "int" {
+		t.Fatalf("expected Color.RED.value to be int, got %+v", typ)
+	}
+	if typ := global.Symbols["each"].Inferred; typ == nil || typ.Symbol != color || typ.Literal != nil {
+		t.Fatalf("expected iterating Color to yield Color, got %+v", typ)
+	}
+	perm := global.Symbols["Perm"]
+	if perm.Enum == nil || !perm.Enum.Flag {
+		t.Fatalf("expected Perm to be a flag, got %+v", perm.Enum)
+	}
+	if typ := global.Symbols["both"].Inferred; typ == nil || typ.Symbol != perm || typ.Literal != nil {
+		t.Fatalf("expected Perm.R | Perm.W to be Perm, got %+v", typ)
+	}
+	first := global.Symbols["partial"].Inner.Symbols["first"]
+	if typ := first.Inferred; typ == nil || typ.Literal != red {
+		t.Fatalf("expected the as-pattern to bind Literal[Color.RED], got %+v", typ)
+	}
+}
+
+func TestResolveFinalAndClassVarAssignments(t *testing.T) {
+	src := `from typing import ClassVar, Final, final
+
+MAX: Final = 10
+LIMIT: Final[int] = 5
+MAX = 11
+
+class Base:
+    registry: ClassVar[dict] = {}
+    VERSION: Final = 1
+    name: Final[str]
+
+    def __init__(self) -> None:
+        self.name = "base"
+        self.ident: Final = 7
+        self.registry = {}
+
+    @final
+    def save(self) -> None:
+        pass
+
+    def rename(self) -> None:
+        self.ident = 8
+
+    @classmethod
+    def reset(cls) -> None:
+        cls.registry = {}
+
+class Child(Base):
+    VERSION = 2
+
+    def save(self) -> None:
+        pass
+
+b = Base()
+b.registry = {}
+Base.VERSION = 3
+count = MAX + LIMIT
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+
+	want := []string{
+		"cannot assign to final name: MAX",
+		"cannot override final attribute: Base.VERSION",
+		"cannot override final method: Base.save",
+		"cannot assign to class variable through an instance: registry",
+		"cannot assign to final attribute: ident",
+		"cannot assign to class variable through an instance: registry",
+		"cannot assign to final attribute: VERSION",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %+v", len(want), errs)
+	}
+	for i, msg := range want {
+		if errs[i].Msg != msg {
+			t.Fatalf("error %d: expected %q, got %q", i, msg, errs[i].Msg)
+		}
+	}
+
+	max := global.Symbols["MAX"]
+	if max.Kind != SymConstant || !max.Final {
+		t.Fatalf("expected MAX to be a Final constant, got kind %v", max.Kind)
+	}
+	if typ := SymbolType(max); typ == nil || typ.Symbol == nil || typ.Symbol.Name != "int" {
+		t.Fatalf("expected bare Final to take the value type int, got %+v", typ)
+	}
+	if registry := global.Symbols["Base"].Inner.Symbols["registry"]; !registry.ClassVar {
+		t.Fatalf("expected registry to be a ClassVar")
+	}
+}
+
+func TestResolveAbstractInstantiationAndOverrides(t *testing.T) {
+	src := `class ABCMeta: ...
+class ABC(metaclass=ABCMeta): ...
+def abstractmethod(f): return f
+def override(f): return f
+
+class Plugin(ABC):
+    @abstractmethod
+    def load(self, path: str) -> None: ...
+
+    @abstractmethod
+    def name(self) -> str: ...
+
+    def describe(self, verbose=False) -> str:
+        return ""
+
+    @classmethod
+    def create(cls):
+        return cls()
+
+class Partial(Plugin):
+    def load(self, path: str) -> None:
+        pass
+
+    def describe(self) -> str:
+        return ""
+
+class Full(Partial):
+    @override
+    def name(self) -> str:
+        return "full"
+
+    @override
+    def unload(self) -> None:
+        pass
+
+    def load(self, path, mode) -> None:
+        pass
+
+class Narrow(Full):
+    def load(self, path) -> None:
+        pass
+
+class Meta(metaclass=ABCMeta):
+    @abstractmethod
+    def run(self): ...
+
+Plugin()
+Partial()
+Full()
+Meta()
+`
+	p := parser.New(src)
+	tree := p.Parse()
+	if errs := p.Errors(); len(errs) != 0 {
+		t.Fatalf("unexpected parse errors: %+v", errs)
+	}
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+
+	want := []string{
+		"incompatible override of Plugin.describe: accepts 0 positional arguments, base accepts 1",
+		"method marked @override does not override a base class member: unload",
+		"incompatible override of Partial.load: requires 2 positional arguments, base requires 1",
+		"incompatible override of Full.load: accepts 1 positional argument, base accepts 2",
+		"cannot instantiate abstract class Plugin with abstract methods: load, name",
+		"cannot instantiate abstract class Partial with abstract methods: name",
+		"cannot instantiate abstract class Meta with abstract methods: run",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %+v", len(want), errs)
+	}
+	for i, msg := range want {
+		if errs[i].Msg != msg {
+			t.Fatalf("error %d: expected %q, got %q", i, msg, errs[i].Msg)
+		}
+	}
+	if !global.Symbols["Full"].ABC {
+		t.Fatalf("expected Full to inherit ABCMeta")
+	}
+}
+
+func TestResolveInstanceAttrsAcrossMethodsAndBases(t *testing.T) {
+	src := `class Base:
+    def __init__(self):
+        self.size = 1
+
+class Widget(Base):
+    def render(self):
+        label = self.label
+        size = self.size
+        return label
+
+    def setup(self):
+        self.label = "ok"
+        self.label, self.size = "x", "big"
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+
+	render := global.Symbols["Widget"].Inner.Symbols["render"]
+	label := SymbolType(render.Inner.Symbols["label"])
+	if label == nil || label.Symbol == nil || label.Symbol.Name != "str" {
+		t.Fatalf("expected label read before its assignment to be str, got %+v", label)
+	}
+	size := SymbolType(render.Inner.Symbols["size"])
+	if size == nil || size.Kind != TypeUnion || len(size.Union) != 2 {
+		t.Fatalf("expected size to join int from Base with str, got %+v", size)
+	}
+
+	attr := global.Symbols["Widget"].Attrs.Symbols["label"]
+	if len(attr.Assignments) != 2 {
+		t.Fatalf("expected both label assignments to be recorded, got %+v", attr.Assignments)
+	}
+	if decls := InheritedAttrs(global.Symbols["Widget"].Members.Symbols["size"]); len(decls) != 2 {
+		t.Fatalf("expected size declarations from Widget and Base, got %d", len(decls))
+	}
+}
+
+func TestResolveInstanceAttrsFromLaterMethodsWithoutResolvingThemTwice(t *testing.T) {
+	src := `class Config:
+    pass
+
+class Service:
+    def run(self):
+        return self.config, self.port, self.names
+
+    def __init__(self, port: int):
+        self.config = Config()
+        self.port = port
+        self.names = ["a"]
+        self.missing = undefined_name
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	r, errs := Resolve(tree, global)
+	if len(errs) != 1 || errs[0].Msg != "undefined name: undefined_name" {
+		t.Fatalf("expected the undefined name to be reported once, got %+v", errs)
+	}
+
+	service := global.Symbols["Service"]
+	if typ := r.getInferredInstanceAttr(service, "config"); typ == nil || typ.Kind != TypeInstance || typ.Symbol != global.Symbols["Config"] {
+		t.Fatalf("expected config to be a Config instance, got %+v", typ)
+	}
+	if typ := r.getInferredInstanceAttr(service, "port"); typ == nil || typ.Symbol == nil || typ.Symbol.Name != "int" {
+		t.Fatalf("expected port to take the parameter annotation, got %+v", typ)
+	}
+	if typ := r.getInferredInstanceAttr(service, "names"); typ == nil || typ.Kind != TypeList {
+		t.Fatalf("expected names to be a list, got %+v", typ)
+	}
+}
+
+func TestResolveStaticConditionsSkipDeadBranches(t *testing.T) {
+	target := Target{Major: 3, Minor: 10, Platform: "linux"}
+	src := `import sys
+from typing import TYPE_CHECKING
+
+if TYPE_CHECKING:
+    from collections import OrderedDict
+else:
+    OrderedDict = dict
+
+if sys.version_info >= (3, 11):
+    def parse(text) -> int: ...
+else:
+    def parse(text) -> str: ...
+
+class Reader:
+    if sys.platform == "win32":
+        def handle(self): return missing_on_linux
+    elif sys.platform.startswith("linux") and sys.version_info[0] == 3:
+        def fd(self): return 0
+
+if sys.version_info < (3, 10, 2):
+    micro = 1
+
+if True:
+    flag = 1
+else:
+    flag = 2
+
+value = parse("x")
+`
+	tree := parser.New(src).Parse()
+	global, defs := BuildScopesForTarget(tree, src, target)
+	_, errs := ResolveForTarget(tree, global, target)
+
+	want := []string{
+		"code is inactive during type checking",
+		"code is inactive for Python 3.10 on linux",
+		"code is inactive for Python 3.10 on linux",
+		"code is unreachable",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %+v", len(want), errs)
+	}
+	for i, msg := range want {
+		if errs[i].Msg != msg || errs[i].Severity != SeverityHint || !errs[i].Unnecessary {
+			t.Fatalf("diagnostic %d: expected inactive hint %q, got %+v", i, msg, errs[i])
+		}
+	}
+
+	if sym := global.Symbols["OrderedDict"]; sym == nil || sym.Kind != SymImport {
+		t.Fatalf("expected OrderedDict to be bound only by its TYPE_CHECKING import, got %+v", sym)
+	}
+	if got := SymbolType(global.Symbols["value"]); got == nil || got.Symbol == nil || got.Symbol.Name != "str" {
+		t.Fatalf("expected parse from the 3.10 branch to return str, got %+v", got)
+	}
+	reader := global.Symbols["Reader"].Inner.Symbols
+	if reader["handle"] != nil || reader["fd"] == nil {
+		t.Fatalf("expected only the linux method on Reader, got %v", reader)
+	}
+	if global.Symbols["micro"] == nil {
+		t.Fatalf("expected a check on the micro version to keep both branches")
+	}
+	for _, sym := range defs {
+		if sym.Name == "parse" && sym.Span.Start < uint32(strings.Index(src, "else:\n    def parse")) {
+			t.Fatalf("expected the 3.11 definition of parse to be skipped")
+		}
+	}
+}
+
+func TestWalkActiveImportsMarksTypeCheckingImports(t *testing.T) {
+	src := `import os
+if TYPE_CHECKING:
+    import typing_only
+if sys.platform == "win32":
+    import winreg
+elif sys.version_info >= (3, 12):
+    import tomllib
+`
+	tree := parser.New(src).Parse()
+	var got []string
+	WalkActiveImports(tree, Target{Major: 3, Minor: 12, Platform: "darwin"}, func(stmt ast.NodeID, typeChecking bool) {
+		target, _ := tree.AliasParts(tree.Node(stmt).FirstChild)
+		name, _ := tree.NameText(target)
+		got = append(got, name+":"+strconv.FormatBool(typeChecking))
+	})
+	want := []string{"os:false", "typing_only:true", "tomllib:false"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("expected imports %v, got %v", want, got)
+	}
+}
+
+func TestResolveTypeAliasesAndNewType(t *testing.T) {
+	src := `def NewType(name, tp): return tp
+TypeAlias = object
+Optional = object
+
+JSON = dict[str, "JSON"] | list["JSON"] | str | None
+Tree = list["Tree"]
+UserId = NewType("UserId", int)
+Pair: TypeAlias = "tuple[int, int]"
+MaybeUser = Optional[UserId]
+
+def load(doc: JSON, uid: UserId, pair: Pair, maybe: MaybeUser, tree: Tree):
+    child = tree[0]
+    return uid
+
+uid = UserId(5)
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+
+	jsonSym := global.Symbols["JSON"]
+	arms := FlattenUnion(jsonSym.TypeAlias)
+	if len(arms) != 4 || arms[0].Kind != TypeDict || arms[1].Kind != TypeList {
+		t.Fatalf("expected JSON to expand to dict | list | str | None, got %+v", jsonSym.TypeAlias)
+	}
+	if arms[0].Elem.AliasRef != jsonSym || arms[1].Elem.AliasRef != jsonSym {
+		t.Fatalf("expected recursive references to point back at JSON, got %+v", arms)
+	}
+
+	userID := global.Symbols["UserId"]
+	if userID.Kind != SymClass || len(userID.Bases) != 1 || userID.Bases[0].Name != "int" {
+		t.Fatalf("expected UserId to be a subclass-like NewType of int, got %+v", userID)
+	}
+	if got := SymbolType(global.Symbols["uid"]); got == nil || got.Kind != TypeInstance || got.Symbol != userID {
+		t.Fatalf("expected UserId(5) to be a UserId instance, got %+v", got)
+	}
+
+	params := global.Symbols["load"].Inner.Symbols
+	if got := SymbolType(params["doc"]); got != jsonSym.TypeAlias {
+		t.Fatalf("expected doc to take the JSON expansion, got %+v", got)
+	}
+	if got := SymbolType(params["uid"]); got == nil || got.Symbol != userID {
+		t.Fatalf("expected uid parameter to be a UserId, got %+v", got)
+	}
+	if got := SymbolType(params["pair"]); !IsFixedTuple(got) || len(got.Items) != 2 {
+		t.Fatalf("expected the explicit Pair alias to be tuple[int, int], got %+v", got)
+	}
+	if got := FlattenUnion(SymbolType(params["maybe"])); len(got) != 2 || got[0].Symbol != userID {
+		t.Fatalf("expected maybe to be UserId | None, got %+v", got)
+	}
+	if got := SymbolType(params["child"]); got == nil || got.Kind != TypeList {
+		t.Fatalf("expected indexing a Tree to expand the recursive alias, got %+v", got)
+	}
+}
+
+func TestResolveCallsThroughCallableValues(t *testing.T) {
+	src := `def TypeVar(name): return name
+Callable = object
+T = TypeVar("T")
+
+class Request: ...
+class Response: ...
+
+def ok(req: Request) -> Response: ...
+def identity(x: T) -> T: ...
+
+class Counter:
+    def __call__(self, n: int) -> str: ...
+
+class Service:
+    def __init__(self, callback: Callable[[int], str]):
+        self.callback = callback
+
+    def run(self) -> str:
+        return self.callback(1)
+
+    def label(self) -> Request: ...
+
+registry: dict[str, Callable[[Request], Response]] = {}
+handler = registry["x"]
+resp = handler(Request())
+ref = ok
+direct = ref(Request())
+counted = Counter()(3)
+method = Service(Counter()).label
+labelled = method()
+generic = identity
+same = generic(Response())
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+
+	instanceOf := func(name, cls string) {
+		t.Helper()
+		got := SymbolType(global.Symbols[name])
+		if got == nil || got.Symbol == nil || got.Symbol.Name != cls {
+			t.Fatalf("expected %s to be a %s, got %+v", name, cls, got)
+		}
+	}
+	instanceOf("resp", "Response")
+	instanceOf("direct", "Response")
+	instanceOf("counted", "str")
+	instanceOf("labelled", "Request")
+	instanceOf("same", "Response")
+
+	ref := SymbolType(global.Symbols["ref"])
+	if ref == nil || ref.Kind != TypeCallable || len(ref.Params) != 1 || ref.Params[0].Name != "req" {
+		t.Fatalf("expected ref to take the signature of ok, got %+v", ref)
+	}
+	method := SymbolType(global.Symbols["method"])
+	if method == nil || method.Kind != TypeCallable || len(method.Params) != 0 {
+		t.Fatalf("expected the bound method to drop self, got %+v", method)
+	}
+}
+
+func TestResolveGeneratorFunctionTypes(t *testing.T) {
+	src := `Iterator = object
+Generator = object
+
+def count(n: int):
+    i = 0
+    while i < n:
+        yield i
+        i += 1
+    return "done"
+
+def echo():
+    received = yield "ready"
+
+def chain():
+    result = yield from count(3)
+    yield 1.5
+
+def ints() -> Iterator[int]:
+    yield 1
+    yield "two"
+
+def accumulate() -> Generator[int, str, None]:
+    sent = yield 0
+
+async def ticks():
+    yield 1
+
+for value in count(2):
+    pass
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+
+	generatorArgs := func(name string) []*Type {
+		t.Helper()
+		got := global.Symbols[name].Returns
+		if got == nil || got.Kind != TypeInstance || got.Symbol == nil {
+			t.Fatalf("expected %s to return a generator, got %+v", name, got)
+		}
+		return got.Args
+	}
+	argName := func(arg *Type) string {
+		if IsUnknownType(arg) {
+			return "unknown"
+		}
+		return typeLabel(arg)
+	}
+
+	args := generatorArgs("count")
+	if global.Symbols["count"].Returns.Symbol.Name != "Generator" || len(args) != 3 ||
+		argName(args[0]) != "int" || argName(args[1]) != "None" || argName(args[2]) != "str" {
+		t.Fatalf("expected count to be Generator[int, None, str], got %+v", args)
+	}
+	if args := generatorArgs("echo"); argName(args[0]) != "str" || !IsUnknownType(args[1]) || argName(args[2]) != "None" {
+		t.Fatalf("expected echo to accept sent values, got %+v", args)
+	}
+	chain := global.Symbols["chain"]
+	if args := generatorArgs("chain"); argName(args[0]) != "int | float" {
+		t.Fatalf("expected chain to yield the sub-generator's items, got %+v", args)
+	}
+	if got := SymbolType(chain.Inner.Symbols["result"]); got == nil || typeLabel(got) != "str" {
+		t.Fatalf("expected yield from to evaluate to the sub-generator's return, got %+v", got)
+	}
+	if got := SymbolType(global.Symbols["accumulate"].Inner.Symbols["sent"]); got == nil || typeLabel(got) != "str" {
+		t.Fatalf("expected yield to evaluate to the declared send type, got %+v", got)
+	}
+	ticks := global.Symbols["ticks"].Returns
+	if ticks == nil || ticks.Symbol.Name != "AsyncGenerator" || len(ticks.Args) != 2 {
+		t.Fatalf("expected ticks to be an AsyncGenerator, got %+v", ticks)
+	}
+	if got := SymbolType(global.Symbols["value"]); got == nil || typeLabel(got) != "int" {
+		t.Fatalf("expected iterating a generator to bind its yield type, got %+v", got)
+	}
+
+	if len(errs) != 1 || errs[0].Msg != "yield type str is incompatible with declared yield type int" {
+		t.Fatalf("expected one yield mismatch, got %+v", errs)
+	}
+}
+
+func TestUnusedImportsVariablesAndParameters(t *testing.T) {
+	src := `from __future__ import annotations
+import os
+import sys
+import json as json
+from typing import List, Optional
+from collections import OrderedDict
+
+__all__ = ["OrderedDict"]
+
+counter = 0
+
+def load(path: "Optional[str]", verbose):
+    data = sys.argv
+    unused = 1
+    _ignored = 2
+    removed = 3
+    del removed
+    global counter
+    counter = 1
+    for item in data:
+        print(item)
+    for i in range(3):
+        pass
+    for key, value in {}.items():
+        pass
+    with open(path) as handle:
+        pass
+    try:
+        pass
+    except OSError as err:
+        pass
+
+def _helper(value, flag, _skip):
+    return value
+
+def _stub(value):
+    ...
+
+class Box:
+    def _method(self, size):
+        import re
+        return 0
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	r, _ := Resolve(tree, global)
+
+	var got []string
+	for _, diag := range r.UnusedDiagnostics(false) {
+		if diag.Severity != SeverityHint || !diag.Unnecessary {
+			t.Fatalf("expected an unnecessary hint, got %+v", diag)
+		}
+		got = append(got, diag.Msg)
+	}
+	want := []string{
+		"unused import: os",
+		"unused import: List",
+		"unused import: re",
+		"unused variable: unused",
+		"unused parameter: flag",
+		"unused parameter: size",
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected unused diagnostics:\n%s", strings.Join(got, "\n"))
+	}
+
+	for _, diag := range r.UnusedDiagnostics(true) {
+		if strings.HasPrefix(diag.Msg, "unused import: ") && diag.Msg != "unused import: re" {
+			t.Fatalf("expected module imports of a package __init__ to be re-exports, got %q", diag.Msg)
+		}
+	}
+}
+
+func TestRedefinitionsAndBuiltinShadowing(t *testing.T) {
+	src := `import os
+from typing import overload
+
+def handler():
+    return 1
+
+def handler():
+    return 2
+
+def used():
+    return 1
+
+print(used())
+
+def used():
+    return 2
+
+try:
+    from json import loads
+except ImportError:
+    def loads(text):
+        return text
+
+if os.name == "nt":
+    def path():
+        return 1
+else:
+    def path():
+        return 2
+
+@overload
+def parse(value: int) -> int: ...
+@overload
+def parse(value: str) -> str: ...
+def parse(value):
+    return value
+
+class Box:
+    @property
+    def size(self):
+        return 1
+
+    @size.setter
+    def size(self, value):
+        pass
+
+    def area(self):
+        return 1
+
+    def area(self):
+        return 2
+
+class Box:
+    pass
+
+list = [1]
+
+def lookup(id, type=None):
+    for input in ():
+        pass
+    return id, type
+`
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	r, _ := Resolve(tree, global)
+
+	var got []string
+	for _, diag := range r.RedefinitionDiagnostics() {
+		switch diag.Severity {
+		case SeverityWarning:
+			if len(diag.Related) != 1 || diag.Related[0].Span.Start >= diag.Span.Start {
+				t.Fatalf("expected the earlier definition as related information, got %+v", diag)
+			}
+		case SeverityInformation:
+		default:
+			t.Fatalf("unexpected severity for %+v", diag)
+		}
+		got = append(got, diag.Msg)
+	}
+	want := []string{
+		"redefinition of unused name: handler",
+		"redefinition of unused name: area",
+		"redefinition of unused name: Box",
+		"variable list shadows a builtin",
+		"parameter id shadows a builtin",
+		"parameter type shadows a builtin",
+		"variable input shadows a builtin",
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected redefinition diagnostics:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestUndefinedNameSuggestsClosestVisibleName(t *testing.T) {
+	src := "def handle(request):\n    return reqeust, zzz\n"
+	tree := parser.New(src).Parse()
+	global, _ := BuildScopes(tree, src)
+	_, errs := Resolve(tree, global)
+
+	want := map[string]string{
+		"undefined name: reqeust": "request",
+		"undefined name: zzz":     "",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %+v", len(want), errs)
+	}
+	for _, err := range errs {
+		suggestion, ok := want[err.Msg]
+		if !ok || err.Suggestion != suggestion {
+			t.Fatalf("unexpected undefined name error %+v", err)
+		}
+	}
+}
+
+func TestAllEntriesFollowsDynamicForms(t *testing.T) {
+	src := `import sys
+from . import core
+
+__all__ = ["load", "dump"]
+__all__ += ["Reader"]
+__all__.extend(("Writer",))
+__all__.append("VERSION")
+__all__.remove("dump")
+if sys.version_info >= (3, 0):
+    __all__ = __all__ + core.__all__ + ["lod"]
+
+def load(): ...
+class Reader: ...
+class Writer: ...
+VERSION = 1
+`
+	tree := parser.New(src).Parse()
+	names := AllNames(tree, Target{}, func(local string) []string {
+		if local == "core" {
+			return []string{"array"}
+		}
+		return nil
+	})
+	want := []string{"load", "Reader", "Writer", "VERSION", "array", "lod"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected __all__ %v, got %v", want, names)
+	}
+
+	global, _ := BuildScopes(tree, src)
+	r, _ := Resolve(tree, global)
+	errs := r.UndefinedAllDiagnostics(func(name string) bool { return name == "core" })
+	if len(errs) != 1 {
+		t.Fatalf("expected one undefined __all__ entry, got %+v", errs)
+	}
+	if errs[0].Msg != "undefined name in __all__: lod" || errs[0].Suggestion != "load" {
+		t.Fatalf("unexpected diagnostic %+v", errs[0])
+	}
+	if got := src[errs[0].Span.Start:errs[0].Span.End]; got != "lod" {
+		t.Fatalf("expected the span inside the quotes, got %q", got)
+	}
+}
@@ -0,0 +1,370 @@
+package analyser
+
+import (
+	"sort"
+	"strings"
+
+	ast "rahu/parser/ast"
+)
+
+// defineSlots records the names listed in a literal __slots__ as instance
+// attributes of cls. It reports false when __slots__ is computed, since the
+// attribute set then cannot be known statically.
+func (r *Resolver) defineSlots(cls *Symbol, body ast.NodeID) bool {
+	for stmt := r.tree.Nodes[body].FirstChild; stmt != ast.NoNode; stmt = r.tree.Nodes[stmt].NextSibling {
+		var target, value ast.NodeID
+		switch r.tree.Node(stmt).Kind {
+		case ast.NodeAssign:
+			value, target = r.tree.ChildAt(stmt, 0), r.tree.ChildAt(stmt, 1)
+		case ast.NodeAnnAssign:
+			target, _, value = r.tree.AnnAssignParts(stmt)
+		default:
+			continue
+		}
+		if name, _ := r.tree.NameText(target); name != "__slots__" || value == ast.NoNode {
+			continue
+		}
+
+		slots := []ast.NodeID{value}
+		switch r.tree.Node(value).Kind {
+		case ast.NodeTuple, ast.NodeList:
+			slots = r.tree.Children(value)
+		case ast.NodeString:
+		default:
+			return false
+		}
+		for _, slot := range slots {
+			name, ok := r.tree.StringText(slot)
+			if !ok {
+				return false
+			}
+			if _, exists := cls.Inner.Symbols[name]; exists || name == "__dict__" || name == "__weakref__" {
+				continue
+			}
+			cls.Inner.Symbols[name] = &Symbol{
+				Name:  name,
+				Kind:  SymAttr,
+				Span:  r.tree.RangeOf(slot),
+				Scope: cls.Inner,
+				Def:   slot,
+			}
+		}
+	}
+	return true
+}
+
+// collectInstanceAttrs records the type of every self.<attr> assignment in
+// the methods of cls before any of them is resolved, so that a method reading
+// an attribute sees it whatever order the methods appear in. Only literals,
+// constructor calls and annotated parameters are typed here; the resolver
+// joins in the types of other values as it reaches each assignment.
+func (r *Resolver) collectInstanceAttrs(cls *Symbol, body ast.NodeID) {
+	if cls.Attrs == nil || len(cls.Attrs.Symbols) == 0 {
+		return
+	}
+
+	for stmt := r.tree.Nodes[body].FirstChild; stmt != ast.NoNode; stmt = r.tree.Nodes[stmt].NextSibling {
+		if r.tree.Node(stmt).Kind != ast.NodeFunctionDef {
+			continue
+		}
+		name, args, fnBody := r.tree.FunctionParts(stmt)
+		nameText, _ := r.tree.NameText(name)
+		if fn := cls.Inner.Symbols[nameText]; args == ast.NoNode || (fn != nil && fn.Method == MethodStaticMethod) {
+			continue
+		}
+
+		var self string
+		params := make(map[string]ast.NodeID)
+		for arg := r.tree.Nodes[args].FirstChild; arg != ast.NoNode; arg = r.tree.Nodes[arg].NextSibling {
+			paramName, annotation, _ := r.tree.ParamParts(arg)
+			paramText, _ := r.tree.NameText(paramName)
+			if self == "" {
+				self = paramText
+				continue
+			}
+			if annotation != ast.NoNode {
+				params[paramText] = annotation
+			}
+		}
+		if self != "" {
+			r.collectSelfAssignments(cls, self, params, fnBody)
+		}
+	}
+}
+
+// collectSelfAssignments records the self.<attr> assignments under id,
+// leaving out nested functions and classes, which have their own self.
+func (r *Resolver) collectSelfAssignments(cls *Symbol, self string, params map[string]ast.NodeID, id ast.NodeID) {
+	switch r.tree.Node(id).Kind {
+	case ast.NodeFunctionDef, ast.NodeClassDef:
+		return
+	case ast.NodeAssign:
+		value := r.tree.Nodes[id].FirstChild
+		targets := r.tree.Children(id)[1:]
+		if n := r.tree.UnpackCount(id); n > 0 && n <= len(targets) {
+			r.collectSelfAttrTarget(cls, self, params, targets[:n], value)
+			targets = targets[n:]
+		}
+		for _, target := range targets {
+			r.collectSelfAttrTarget(cls, self, params, []ast.NodeID{target}, value)
+		}
+		return
+	case ast.NodeAnnAssign:
+		target, annotation, _ := r.tree.AnnAssignParts(id)
+		if attr, ok := selfAttrName(r.tree, self, target); ok {
+			r.recordInstanceAttr(cls, attr, r.resolveTypeFromExpr(annotation))
+		}
+		return
+	}
+	for child := r.tree.Nodes[id].FirstChild; child != ast.NoNode; child = r.tree.Nodes[child].NextSibling {
+		r.collectSelfAssignments(cls, self, params, child)
+	}
+}
+
+// collectSelfAttrTarget records the type of value for the self.<attr>
+// targets it is assigned to, pairing up the items of a tuple unpacked into
+// several targets.
+func (r *Resolver) collectSelfAttrTarget(cls *Symbol, self string, params map[string]ast.NodeID, targets []ast.NodeID, value ast.NodeID) {
+	if len(targets) == 1 && r.tree.Node(targets[0]).Kind != ast.NodeTuple && r.tree.Node(targets[0]).Kind != ast.NodeList {
+		if attr, ok := selfAttrName(r.tree, self, targets[0]); ok {
+			r.recordInstanceAttr(cls, attr, r.staticValueType(value, params))
+		}
+		return
+	}
+	if len(targets) == 1 {
+		targets = r.tree.Children(targets[0])
+	}
+	switch r.tree.Node(value).Kind {
+	case ast.NodeTuple, ast.NodeList:
+	default:
+		return
+	}
+	values := r.tree.Children(value)
+	if len(targets) != len(values) {
+		return
+	}
+	for i := range targets {
+		r.collectSelfAttrTarget(cls, self, params, targets[i:i+1], values[i])
+	}
+}
+
+// selfAttrName returns attr when target is self.attr.
+func selfAttrName(tree *ast.AST, self string, target ast.NodeID) (string, bool) {
+	if tree.Node(target).Kind != ast.NodeAttribute {
+		return "", false
+	}
+	base, attr := tree.ChildAt(target, 0), tree.ChildAt(target, 1)
+	if name, _ := tree.NameText(base); tree.Node(base).Kind != ast.NodeName || name != self {
+		return "", false
+	}
+	return tree.NameText(attr)
+}
+
+// staticValueType types value without resolving it: literals and displays,
+// calls to classes visible from the current scope, and parameters typed by
+// their annotation. Anything else is unknown.
+func (r *Resolver) staticValueType(value ast.NodeID, params map[string]ast.NodeID) *Type {
+	switch r.tree.Node(value).Kind {
+	case ast.NodeNumber:
+		if lit, ok := r.tree.NumberText(value); ok && strings.ContainsAny(lit, ".eE") {
+			return BuiltinType(BuiltinSymbol("float"))
+		}
+		return BuiltinType(BuiltinSymbol("int"))
+	case ast.NodeString, ast.NodeFString:
+		return BuiltinType(BuiltinSymbol("str"))
+	case ast.NodeBytes:
+		return BuiltinType(BuiltinSymbol("bytes"))
+	case ast.NodeBoolean:
+		return BuiltinType(BuiltinSymbol("bool"))
+	case ast.NodeList, ast.NodeTuple:
+		var items []*Type
+		for child := r.tree.Nodes[value].FirstChild; child != ast.NoNode; child = r.tree.Nodes[child].NextSibling {
+			if r.tree.Node(child).Kind == ast.NodeStarArg {
+				return nil
+			}
+			items = append(items, r.staticValueType(child, params))
+		}
+		if r.tree.Node(value).Kind == ast.NodeTuple {
+			return TupleType(items...)
+		}
+		if len(items) == 0 {
+			return ListType(UnknownType())
+		}
+		return ListType(JoinTypes(items...))
+	case ast.NodeDict:
+		var keyType, elemType *Type
+		for i, child := range r.tree.Children(value) {
+			if i%2 == 0 {
+				keyType = JoinTypes(keyType, r.staticValueType(child, params))
+			} else {
+				elemType = JoinTypes(elemType, r.staticValueType(child, params))
+			}
+		}
+		return DictType(keyType, elemType)
+	case ast.NodeCall:
+		callee := r.tree.Nodes[value].FirstChild
+		name, _ := r.tree.NameText(callee)
+		if r.tree.Node(callee).Kind != ast.NodeName {
+			return nil
+		}
+		sym, ok := r.current.Lookup(name)
+		switch {
+		case !ok || sym == nil:
+			return nil
+		case sym.Kind == SymClass:
+			return InstanceType(sym)
+		case sym.Kind == SymType:
+			return BuiltinType(sym)
+		}
+	case ast.NodeName:
+		name, _ := r.tree.NameText(value)
+		if annotation, ok := params[name]; ok {
+			return r.resolveTypeFromExpr(annotation)
+		}
+	}
+	return nil
+}
+
+// InheritedAttrs returns the instance attribute declarations named like sym
+// along the MRO of the class owning sym, nearest first. Each class keeps its
+// own symbol for an attribute it assigns.
+func InheritedAttrs(sym *Symbol) []*Symbol {
+	if sym == nil || sym.Kind != SymAttr || sym.Scope == nil || sym.Scope.Owner == nil {
+		return nil
+	}
+	var out []*Symbol
+	for _, cls := range MRO(sym.Scope.Owner) {
+		if cls.Attrs == nil {
+			continue
+		}
+		if attr := cls.Attrs.Symbols[sym.Name]; attr != nil {
+			out = append(out, attr)
+		}
+	}
+	return out
+}
+
+// classMembersKnown reports whether every attribute of cls can be
+// enumerated statically: all bases resolved, no __getattr__ or
+// __getattribute__ hook, no computed __slots__ and no decorator that might
+// add members.
+func classMembersKnown(cls *Symbol, seen map[*Symbol]bool) bool {
+	if cls == nil || cls.Kind != SymClass {
+		return false
+	}
+	if seen[cls] {
+		return true
+	}
+	seen[cls] = true
+	if cls.Scope != nil && cls.Scope.Kind == ScopeBuiltin {
+		return cls.Name == "object" || cls.Name == "type"
+	}
+	if cls.DynamicMembers || (cls.Decorated && cls.Dataclass == nil) || cls.Inner == nil {
+		return false
+	}
+	for _, hook := range []string{"__getattr__", "__getattribute__"} {
+		if _, ok := cls.Inner.Symbols[hook]; ok {
+			return false
+		}
+	}
+	for _, base := range cls.Bases {
+		if !classMembersKnown(base, seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// typeMembers are the attributes every class gets from builtins.type,
+// leaving out dunder names, which are never reported.
+var typeMembers = []string{"mro"}
+
+// classMetaclass returns the nearest metaclass declared along the MRO of cls.
+func classMetaclass(cls *Symbol) *Symbol {
+	for _, c := range MRO(cls) {
+		if c.Metaclass != nil {
+			return c.Metaclass
+		}
+	}
+	return nil
+}
+
+// classMemberNames collects the attribute names visible on cls, including
+// those inherited from its bases.
+func (r *Resolver) classMemberNames(cls *Symbol, names map[string]bool, seen map[*Symbol]bool) {
+	if cls == nil || seen[cls] {
+		return
+	}
+	seen[cls] = true
+	if cls.Scope != nil && cls.Scope.Kind == ScopeBuiltin && cls.Name == "type" {
+		for _, name := range typeMembers {
+			names[name] = true
+		}
+	}
+	for _, scope := range []*Scope{cls.Inner, cls.Attrs, cls.Members} {
+		if scope == nil {
+			continue
+		}
+		for name := range scope.Symbols {
+			names[name] = true
+		}
+	}
+	for name := range r.classInstanceAttrs[cls.ID] {
+		names[name] = true
+	}
+	for _, base := range cls.Bases {
+		r.classMemberNames(base, names, seen)
+	}
+}
+
+// reportUnknownAttribute warns about an attribute missing from a class whose
+// members are fully known, suggesting the closest existing member.
+func (r *Resolver) reportUnknownAttribute(attrNameNode ast.NodeID, baseType *Type, attrName string) {
+	if baseType == nil || (baseType.Kind != TypeInstance && baseType.Kind != TypeClass) {
+		return
+	}
+	if strings.HasPrefix(attrName, "__") && strings.HasSuffix(attrName, "__") {
+		return
+	}
+	cls := baseType.Symbol
+	if !classMembersKnown(cls, map[*Symbol]bool{}) {
+		return
+	}
+
+	names := make(map[string]bool)
+	r.classMemberNames(cls, names, map[*Symbol]bool{})
+	if baseType.Kind == TypeClass {
+		// Classes also have the attributes of their metaclass, type by default.
+		for _, name := range typeMembers {
+			names[name] = true
+		}
+		if metaclass := classMetaclass(cls); metaclass != nil {
+			if !classMembersKnown(metaclass, map[*Symbol]bool{}) {
+				return
+			}
+			r.classMemberNames(metaclass, names, map[*Symbol]bool{})
+		}
+	}
+	if names[attrName] {
+		return
+	}
+	candidates := make([]string, 0, len(names))
+	for name := range names {
+		candidates = append(candidates, name)
+	}
+	sort.Strings(candidates)
+
+	subject := "'" + cls.Name + "' object"
+	if baseType.Kind == TypeClass {
+		subject = "class '" + cls.Name + "'"
+	}
+	err := SuggestName(SemanticError{
+		Span:     r.tree.RangeOf(attrNameNode),
+		Msg:      subject + " has no attribute '" + attrName + "'",
+		Severity: SeverityWarning,
+	}, attrName, candidates)
+	if err.Suggestion != "" {
+		err.Msg += "; did you mean '" + err.Suggestion + "'?"
+	}
+	r.errors = append(r.errors, err)
+}
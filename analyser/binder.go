@@ -33,24 +33,33 @@ func (r *Resolver) BindMembers() {
 
 		baseName, _ := r.tree.NameText(base)
 		baseSym := r.Resolved[base]
-		if baseSym == nil {
+		if baseSym == nil && r.exprType(base) == nil {
 			continue
 		}
 
 		attrName, _ := r.tree.NameText(attrNameNode)
 
 		if p.Class != nil && p.SelfName != "" &&
-			baseName == p.SelfName &&
+			baseName == p.SelfName && baseSym != nil &&
 			baseSym.Kind == SymParameter {
 
 			sym, ok := p.Class.Members.Lookup(attrName)
 			if !ok {
-				r.error(r.tree.RangeOf(attrNameNode), "undefined attribute: "+attrName)
+				r.reportUnknownAttribute(attrNameNode, InstanceType(p.Class), attrName)
 				continue
 			}
 
 			r.ResolvedAttr[attrNode] = sym
 			applyInferredType(sym, p.ValueType)
+			if sym.Kind == SymAttr {
+				// Instances may also carry values assigned by base classes.
+				applyInferredType(sym, r.getInferredInstanceAttr(p.Class, attrName))
+			}
+			if p.Write {
+				// cls in a classmethod also takes this path.
+				instance := baseSym.Inferred == nil || baseSym.Inferred.Kind != TypeClass
+				r.checkAttributeWrite(attrNameNode, p.Class, instance, true)
+			}
 			continue
 		}
 
@@ -79,24 +88,30 @@ func (r *Resolver) BindMembers() {
 						continue
 					}
 				}
-				r.error(r.tree.RangeOf(attrNameNode), "undefined attribute: "+attrName)
+				r.reportUnknownAttribute(attrNameNode, baseType, attrName)
 				continue
 			}
 
 			r.ResolvedAttr[attrNode] = sym
 			applyInferredType(sym, p.ValueType)
+			if p.Write && (baseType.Kind == TypeInstance || baseType.Kind == TypeClass) {
+				r.checkAttributeWrite(attrNameNode, baseType.Symbol, baseType.Kind == TypeInstance, false)
+			}
 			continue
 		}
 
-		if baseSym.InstanceOf != nil {
+		if baseSym != nil && baseSym.InstanceOf != nil {
 			sym, ok := baseSym.InstanceOf.Members.Lookup(attrName)
 			if !ok {
-				r.error(r.tree.RangeOf(attrNameNode), "undefined attribute: "+attrName)
+				r.reportUnknownAttribute(attrNameNode, InstanceType(baseSym.InstanceOf), attrName)
 				continue
 			}
 
 			r.ResolvedAttr[attrNode] = sym
 			applyInferredType(sym, p.ValueType)
+			if p.Write {
+				r.checkAttributeWrite(attrNameNode, baseSym.InstanceOf, true, false)
+			}
 			continue
 		}
 	}
@@ -111,7 +126,7 @@ func ResolveWithAttrs(
 	map[ast.NodeID]*Symbol,
 	[]PendingAttr,
 ) {
-	r := newResolver(tree, global)
+	r := newResolver(tree, global, Target{})
 	r.visitModule()
 	PromoteClassMembers(global)
 	r.BindMembers()
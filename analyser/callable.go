@@ -0,0 +1,168 @@
+package analyser
+
+import (
+	"fmt"
+
+	ast "rahu/parser/ast"
+)
+
+// typeVarConstructors are the calls whose result is bound to a type
+// variable, as in T = TypeVar("T") or P = ParamSpec("P").
+var typeVarConstructors = map[string]bool{
+	"TypeVar": true, "typing.TypeVar": true, "typing_extensions.TypeVar": true,
+	"ParamSpec": true, "typing.ParamSpec": true, "typing_extensions.ParamSpec": true,
+}
+
+var callableAnnotations = map[string]bool{
+	"Callable": true, "typing.Callable": true, "typing_extensions.Callable": true,
+	"collections.abc.Callable": true, "abc.Callable": true,
+}
+
+var concatenateAnnotations = map[string]bool{
+	"Concatenate": true, "typing.Concatenate": true, "typing_extensions.Concatenate": true,
+}
+
+// isTypeVarDeclaration reports whether value declares a type variable.
+func isTypeVarDeclaration(tree *ast.AST, value ast.NodeID) bool {
+	return tree.Node(value).Kind == ast.NodeCall && typeVarConstructors[calleeDottedName(tree, value)]
+}
+
+// resolveCallableAnnotation resolves the index of Callable[[A, B], R],
+// Callable[..., R], Callable[P, R] or Callable[Concatenate[A, P], R].
+func (r *Resolver) resolveCallableAnnotation(index ast.NodeID) *Type {
+	if r.tree.Node(index).Kind != ast.NodeTuple || r.tree.ChildCount(index) != 2 {
+		return CallableType(nil, nil)
+	}
+	params := r.tree.ChildAt(index, 0)
+	typ := CallableType(nil, r.resolveAnnotation(r.tree.ChildAt(index, 1)))
+
+	switch r.tree.Node(params).Kind {
+	case ast.NodeList:
+		typ.Params = r.positionalParams(r.tree.Children(params))
+	case ast.NodeSubScript:
+		if !concatenateAnnotations[exprDottedName(r.tree, r.tree.ChildAt(params, 0))] {
+			break
+		}
+		args := r.tree.ChildAt(params, 1)
+		parts := []ast.NodeID{args}
+		if r.tree.Node(args).Kind == ast.NodeTuple {
+			parts = r.tree.Children(args)
+		}
+		spec := r.resolveAnnotation(parts[len(parts)-1])
+		if spec == nil || spec.Kind != TypeVariable {
+			break
+		}
+		typ.Params = r.positionalParams(parts[:len(parts)-1])
+		typ.Spec = spec
+	default:
+		if spec := r.resolveAnnotation(params); spec != nil && spec.Kind == TypeVariable {
+			typ.Params = []*Symbol{}
+			typ.Spec = spec
+		}
+	}
+	return typ
+}
+
+// positionalParams synthesizes the positional-only parameters of a callable
+// annotation, named the way stubs name positional-only parameters.
+func (r *Resolver) positionalParams(annotations []ast.NodeID) []*Symbol {
+	params := make([]*Symbol, 0, len(annotations))
+	for i, annotation := range annotations {
+		params = append(params, &Symbol{
+			Name:      fmt.Sprintf("__p%d", i),
+			Kind:      SymParameter,
+			Inferred:  r.resolveAnnotation(annotation),
+			IsPosOnly: true,
+		})
+	}
+	return params
+}
+
+// functionType returns the callable type of fn, without its first parameter
+// when it is called through an instance or class. Type variables in its
+// signature are kept for the caller to solve.
+func functionType(fn *Symbol, bound bool) *Type {
+	if fn.Decorated {
+		return CallableType(nil, fn.Returns)
+	}
+	params := OrderedParams(fn)
+	if bound && len(params) > 0 && !params[0].IsVarArg && !params[0].IsKwArg {
+		params = params[1:]
+	}
+	if params == nil {
+		params = []*Symbol{}
+	}
+	return CallableType(params, fn.Returns)
+}
+
+// CallableOf returns what calling a value of type t looks like: a callable
+// type itself, the constructor of a class, or __call__ of an instance.
+func CallableOf(t *Type) *Type {
+	if IsUnknownType(t) {
+		return nil
+	}
+	switch t.Kind {
+	case TypeCallable:
+		return t
+	case TypeClass:
+		if t.Symbol == nil {
+			return nil
+		}
+		if init, bound, _ := constructorSignature(t.Symbol); init != nil {
+			typ := functionType(init, bound)
+			typ.Returns = InstanceType(t.Symbol)
+			return typ
+		}
+		return CallableType(nil, InstanceType(t.Symbol))
+	case TypeInstance:
+		call, ok := LookupMemberOnType(t, "__call__")
+		if !ok || call == nil || call.Kind != SymFunction {
+			return nil
+		}
+		typ := functionType(call, true)
+		typ.Returns = BoundReturnType(call, t)
+		return typ
+	}
+	return nil
+}
+
+// ReferenceType returns the type of reading sym as a value: its declared or
+// inferred type, or the callable type of a function or method referenced
+// without being called. recv is the type the method was looked up on.
+func ReferenceType(sym *Symbol, recv *Type) *Type {
+	if sym != nil && sym.Kind == SymFunction && sym.Method == MethodProperty && recv != nil && recv.Kind == TypeClass {
+		// Read through the class, a property is the descriptor itself.
+		if property := BuiltinSymbol("property"); property != nil {
+			return BuiltinType(property)
+		}
+		return nil
+	}
+	if typ := SymbolType(sym); !IsUnknownType(typ) {
+		return typ
+	}
+	if sym == nil || sym.Kind != SymFunction {
+		return nil
+	}
+	return functionType(sym, recv != nil && IsBoundMethod(sym, recv))
+}
+
+// callReturnType returns the result of calling a value of callable type
+// call, solving its type variables against the positional arguments.
+func (r *Resolver) callReturnType(call *Type, expr ast.NodeID) *Type {
+	bindings := newTypeBindings()
+	index := 0
+	for _, arg := range r.tree.Children(expr)[1:] {
+		switch r.tree.Node(arg).Kind {
+		case ast.NodeKeywordArg, ast.NodeKwStarArg:
+			continue
+		case ast.NodeStarArg:
+			// Arguments after *args can no longer be matched by position.
+			return bindings.substitute(call.Returns)
+		}
+		if param := positionalParam(call.Params, index); param != nil {
+			bindings.match(SymbolType(param), r.exprType(arg))
+		}
+		index++
+	}
+	return bindings.substitute(call.Returns)
+}
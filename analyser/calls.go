@@ -0,0 +1,219 @@
+package analyser
+
+import (
+	"fmt"
+	"strings"
+
+	ast "rahu/parser/ast"
+)
+
+// calleeSignature returns the function whose parameters a call binds
+// against, whether its first parameter is supplied implicitly, and the
+// label used in diagnostics. It returns nil when the signature is unknown
+// or may have been replaced by a decorator.
+func (r *Resolver) calleeSignature(callee ast.NodeID) (*Symbol, bool, string) {
+	var sym *Symbol
+	var recv *Type
+	switch r.tree.Node(callee).Kind {
+	case ast.NodeName:
+		sym = r.Resolved[callee]
+	case ast.NodeAttribute:
+		sym = r.ResolvedAttr[callee]
+		recv = r.exprType(r.tree.ChildAt(callee, 0))
+	}
+	if sym == nil {
+		return nil, false, ""
+	}
+
+	if sym.Kind == SymFunction {
+		if sym.Method == MethodProperty || sym.Decorated || !hasSignature(sym) {
+			return nil, false, ""
+		}
+		label := sym.Name + "()"
+		if sym.Scope != nil && sym.Scope.Kind == ScopeClass && sym.Scope.Owner != nil {
+			label = sym.Scope.Owner.Name + "." + label
+		}
+		return sym, IsBoundMethod(sym, recv), label
+	}
+
+	cls := sym
+	if sym.Kind != SymClass {
+		typ := SymbolType(sym)
+		if typ != nil && typ.Kind == TypeCallable && typ.Params != nil && typ.Spec == nil {
+			// A value of callable type, such as a functools.partial object.
+			return &Symbol{Name: sym.Name, Kind: SymFunction, Params: typ.Params, Returns: typ.Returns}, false, sym.Name + "()"
+		}
+		if typ == nil || typ.Kind != TypeClass {
+			return nil, false, ""
+		}
+		cls = typ.Symbol
+	}
+	return constructorSignature(cls)
+}
+
+// constructorSignature returns the __init__ a class call binds against.
+// Classes with a custom __new__ or an unrecognised decorator are skipped.
+func constructorSignature(cls *Symbol) (*Symbol, bool, string) {
+	if cls == nil || (cls.Decorated && cls.Dataclass == nil) {
+		return nil, false, ""
+	}
+	classType := ClassType(cls)
+	if newFn, ok := LookupMemberOnType(classType, "__new__"); ok && newFn != nil {
+		return nil, false, ""
+	}
+	init, ok := LookupMemberOnType(classType, "__init__")
+	if !ok || init == nil || init.Kind != SymFunction || init.Decorated || !hasSignature(init) {
+		return nil, false, ""
+	}
+	return init, true, cls.Name + "()"
+}
+
+func hasSignature(fn *Symbol) bool {
+	return fn.Params != nil || fn.Inner != nil || len(fn.Overloads) > 0
+}
+
+// checkCallArguments reports arity and keyword errors for a call to a
+// function with a known signature, and arguments that do not satisfy a
+// protocol parameter. Unpacked *args and **kwargs at the call site
+// suppress the checks they could satisfy.
+func (r *Resolver) checkCallArguments(call ast.NodeID) {
+	callee := r.tree.Nodes[call].FirstChild
+	fn, bound, label := r.calleeSignature(callee)
+	if fn == nil {
+		return
+	}
+
+	if len(fn.Overloads) > 0 {
+		shape := r.callArgs(call)
+		for i := range shape.Positional {
+			shape.Positional[i] = nil
+		}
+		shape.KeywordTypes = nil
+		if SelectOverload(fn, bound, shape) == nil {
+			r.error(r.tree.RangeOf(callee), "no overload of "+label+" accepts these arguments")
+		}
+		return
+	}
+
+	params := OrderedParams(fn)
+	if bound {
+		if len(params) == 0 {
+			return
+		}
+		if !params[0].IsVarArg && !params[0].IsKwArg {
+			params = params[1:]
+		}
+	}
+
+	var positional, required []*Symbol
+	var varArg, kwArg *Symbol
+	byName := make(map[string]*Symbol, len(params))
+	posOnly := make(map[string]bool)
+	for _, param := range params {
+		switch {
+		case param.IsKwArg:
+			kwArg = param
+		case param.IsVarArg:
+			varArg = param
+		default:
+			if param.IsPosOnly {
+				posOnly[param.Name] = true
+			} else {
+				byName[param.Name] = param
+			}
+			if varArg == nil && !param.IsKwOnly {
+				positional = append(positional, param)
+			}
+			if param.DefaultValue == "" {
+				required = append(required, param)
+			}
+		}
+	}
+
+	supplied := make(map[*Symbol]bool, len(params))
+	seenKeywords := make(map[string]bool)
+	var extra []ast.NodeID
+	starred, kwStarred := false, false
+	index := 0
+	for arg := r.tree.Nodes[callee].NextSibling; arg != ast.NoNode; arg = r.tree.Nodes[arg].NextSibling {
+		switch r.tree.Node(arg).Kind {
+		case ast.NodeStarArg:
+			starred = true
+		case ast.NodeKwStarArg:
+			kwStarred = true
+		case ast.NodeKeywordArg:
+			name, ok := r.tree.NameText(r.tree.ChildAt(arg, 0))
+			if !ok {
+				continue
+			}
+			if seenKeywords[name] {
+				r.error(r.tree.RangeOf(arg), "keyword argument repeated: "+name)
+				continue
+			}
+			seenKeywords[name] = true
+			param := byName[name]
+			switch {
+			case param == nil && kwArg != nil:
+			case param == nil && posOnly[name]:
+				r.error(r.tree.RangeOf(arg), fmt.Sprintf("positional-only argument '%s' passed as keyword to %s", name, label))
+			case param == nil:
+				r.error(r.tree.RangeOf(arg), fmt.Sprintf("unexpected keyword argument '%s' for %s", name, label))
+			case supplied[param]:
+				r.error(r.tree.RangeOf(arg), fmt.Sprintf("multiple values for argument '%s' in %s", name, label))
+			default:
+				supplied[param] = true
+				r.checkProtocolValue(r.tree.RangeOf(arg), param.Inferred, r.exprType(r.tree.ChildAt(arg, 1)))
+			}
+		default:
+			if index < len(positional) {
+				if !starred {
+					supplied[positional[index]] = true
+					r.checkProtocolValue(r.tree.RangeOf(arg), positional[index].Inferred, r.exprType(arg))
+				}
+			} else {
+				extra = append(extra, arg)
+			}
+			index++
+		}
+	}
+
+	if len(extra) > 0 && varArg == nil {
+		span := r.tree.RangeOf(extra[0])
+		span.End = r.tree.RangeOf(extra[len(extra)-1]).End
+		r.error(span, fmt.Sprintf("too many positional arguments for %s: expected %d, got %d", label, len(positional), index))
+	}
+
+	var missing, missingKwOnly []string
+	for _, param := range required {
+		if supplied[param] {
+			continue
+		}
+		switch {
+		case param.IsKwOnly:
+			if !kwStarred {
+				missingKwOnly = append(missingKwOnly, "'"+param.Name+"'")
+			}
+		case param.IsPosOnly:
+			if !starred {
+				missing = append(missing, "'"+param.Name+"'")
+			}
+		default:
+			if !starred && !kwStarred {
+				missing = append(missing, "'"+param.Name+"'")
+			}
+		}
+	}
+	if len(missing) > 0 {
+		r.error(r.tree.RangeOf(callee), missingArgumentsMessage("argument", missing, label))
+	}
+	if len(missingKwOnly) > 0 {
+		r.error(r.tree.RangeOf(callee), missingArgumentsMessage("keyword-only argument", missingKwOnly, label))
+	}
+}
+
+func missingArgumentsMessage(kind string, names []string, label string) string {
+	if len(names) == 1 {
+		return fmt.Sprintf("missing required %s %s for %s", kind, names[0], label)
+	}
+	return fmt.Sprintf("missing required %ss %s for %s", kind, strings.Join(names, ", "), label)
+}
@@ -0,0 +1,287 @@
+package analyser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"rahu/parser/ast"
+)
+
+// Target describes the interpreter that static conditions are evaluated
+// against. A zero Major leaves sys.version_info checks undecided, and an
+// empty Platform leaves sys.platform checks undecided.
+type Target struct {
+	Major    int
+	Minor    int
+	Platform string // the value of sys.platform, e.g. "linux" or "win32"
+}
+
+func (t Target) String() string {
+	version := "Python"
+	if t.Major > 0 {
+		version = fmt.Sprintf("Python %d.%d", t.Major, t.Minor)
+	}
+	if t.Platform == "" {
+		return version
+	}
+	return version + " on " + t.Platform
+}
+
+// PlatformForGOOS maps a Go GOOS value to the matching sys.platform string.
+func PlatformForGOOS(goos string) string {
+	switch goos {
+	case "windows":
+		return "win32"
+	case "":
+		return ""
+	default:
+		return goos
+	}
+}
+
+var typeCheckingNames = map[string]bool{
+	"TYPE_CHECKING": true, "typing.TYPE_CHECKING": true, "typing_extensions.TYPE_CHECKING": true,
+}
+
+// isTypeCheckingGuard reports whether test is a bare TYPE_CHECKING flag.
+func isTypeCheckingGuard(tree *ast.AST, test ast.NodeID) bool {
+	return typeCheckingNames[exprDottedName(tree, test)]
+}
+
+// staticCondition evaluates an if-test that only depends on the target
+// interpreter. known is false when the result depends on runtime state.
+func (t Target) staticCondition(tree *ast.AST, test ast.NodeID) (value, known bool) {
+	if test == ast.NoNode {
+		return false, false
+	}
+	switch tree.Node(test).Kind {
+	case ast.NodeName, ast.NodeAttribute:
+		if isTypeCheckingGuard(tree, test) {
+			return true, true
+		}
+	case ast.NodeBoolean:
+		return boolLiteral(tree, test)
+	case ast.NodeUnaryOp:
+		if ast.UnaryOperator(tree.Node(test).Data) == ast.Not {
+			value, known = t.staticCondition(tree, tree.Node(test).FirstChild)
+			return !value, known
+		}
+	case ast.NodeBooleanOp:
+		// `and` is decided by any false operand and `or` by any true one;
+		// otherwise every operand has to be known.
+		short := ast.BooleanOperator(tree.Node(test).Data) == ast.Or
+		known = true
+		for operand := tree.Node(test).FirstChild; operand != ast.NoNode; operand = tree.Node(operand).NextSibling {
+			v, ok := t.staticCondition(tree, operand)
+			if ok && v == short {
+				return short, true
+			}
+			known = known && ok
+		}
+		return !short, known
+	case ast.NodeCompare:
+		return t.staticComparison(tree, test)
+	case ast.NodeCall:
+		return t.staticPlatformPrefix(tree, test)
+	}
+	return false, false
+}
+
+// staticComparison evaluates `sys.version_info <op> (major, minor)`,
+// `sys.version_info[0] <op> n` and `sys.platform ==/!= "name"`.
+func (t Target) staticComparison(tree *ast.AST, test ast.NodeID) (bool, bool) {
+	left := tree.Node(test).FirstChild
+	cmp := tree.Node(left).NextSibling
+	if cmp == ast.NoNode || tree.Node(cmp).NextSibling != ast.NoNode {
+		return false, false
+	}
+	op := ast.CompareOp(tree.Node(cmp).Data)
+	right := tree.Node(cmp).FirstChild
+
+	if exprDottedName(tree, left) == "sys.platform" {
+		platform, ok := tree.StringText(right)
+		if !ok || t.Platform == "" {
+			return false, false
+		}
+		switch op {
+		case ast.Eq:
+			return t.Platform == platform, true
+		case ast.NotEq:
+			return t.Platform != platform, true
+		}
+		return false, false
+	}
+
+	if t.Major == 0 {
+		return false, false
+	}
+	switch {
+	case exprDottedName(tree, left) == "sys.version_info":
+		if tree.Node(right).Kind != ast.NodeTuple {
+			return false, false
+		}
+		var want []int
+		for elem := tree.Node(right).FirstChild; elem != ast.NoNode; elem = tree.Node(elem).NextSibling {
+			n, ok := intLiteral(tree, elem)
+			if !ok {
+				return false, false
+			}
+			want = append(want, n)
+		}
+		order, ok := compareVersionPrefix([]int{t.Major, t.Minor}, want)
+		if !ok {
+			return false, false
+		}
+		return compareOrder(op, order)
+	case tree.Node(left).Kind == ast.NodeSubScript && exprDottedName(tree, tree.ChildAt(left, 0)) == "sys.version_info":
+		index, ok := intLiteral(tree, tree.ChildAt(left, 1))
+		n, nOK := intLiteral(tree, right)
+		if !ok || !nOK || index < 0 || index > 1 {
+			return false, false
+		}
+		have := []int{t.Major, t.Minor}[index]
+		switch {
+		case have < n:
+			return compareOrder(op, -1)
+		case have > n:
+			return compareOrder(op, 1)
+		default:
+			return compareOrder(op, 0)
+		}
+	}
+	return false, false
+}
+
+// staticPlatformPrefix evaluates sys.platform.startswith("prefix").
+func (t Target) staticPlatformPrefix(tree *ast.AST, call ast.NodeID) (bool, bool) {
+	callee := tree.Node(call).FirstChild
+	arg := tree.Node(callee).NextSibling
+	if exprDottedName(tree, callee) != "sys.platform.startswith" || arg == ast.NoNode ||
+		tree.Node(arg).NextSibling != ast.NoNode || t.Platform == "" {
+		return false, false
+	}
+	prefix, ok := tree.StringText(arg)
+	if !ok {
+		return false, false
+	}
+	return strings.HasPrefix(t.Platform, prefix), true
+}
+
+// compareVersionPrefix orders the target's (major, minor) against a version
+// tuple. sys.version_info always has more fields than two, so it sorts after
+// an equal (major, minor) tuple; a longer tuple with an equal prefix depends
+// on the micro version, which is not known.
+func compareVersionPrefix(have, want []int) (int, bool) {
+	for i := 0; i < len(want); i++ {
+		if i == len(have) {
+			return 0, false
+		}
+		switch {
+		case have[i] < want[i]:
+			return -1, true
+		case have[i] > want[i]:
+			return 1, true
+		}
+	}
+	return 1, true
+}
+
+func compareOrder(op ast.CompareOp, order int) (bool, bool) {
+	switch op {
+	case ast.Eq:
+		return order == 0, true
+	case ast.NotEq:
+		return order != 0, true
+	case ast.Lt:
+		return order < 0, true
+	case ast.LtE:
+		return order <= 0, true
+	case ast.Gt:
+		return order > 0, true
+	case ast.GtE:
+		return order >= 0, true
+	}
+	return false, false
+}
+
+func intLiteral(tree *ast.AST, expr ast.NodeID) (int, bool) {
+	text, ok := tree.NumberText(expr)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(text)
+	return n, err == nil
+}
+
+// ifBranches returns the blocks of an if statement and which of them can run
+// on the target interpreter.
+func (t Target) ifBranches(tree *ast.AST, stmt ast.NodeID) (test, body, orelse ast.NodeID, bodyLive, orelseLive bool) {
+	test = tree.Node(stmt).FirstChild
+	if test != ast.NoNode {
+		body = tree.Node(test).NextSibling
+	}
+	if body != ast.NoNode {
+		orelse = tree.Node(body).NextSibling
+	}
+	value, known := t.staticCondition(tree, test)
+	return test, body, orelse, !known || value, !known || !value
+}
+
+// WalkActiveImports calls fn for every import statement at module level,
+// including those nested in if-branches that can run on the target
+// interpreter. typeChecking reports imports guarded by TYPE_CHECKING, which
+// are needed for typing but never executed.
+func WalkActiveImports(tree *ast.AST, target Target, fn func(stmt ast.NodeID, typeChecking bool)) {
+	if tree == nil || tree.Root == ast.NoNode {
+		return
+	}
+	target.walkActiveImports(tree, tree.Root, false, false, fn)
+}
+
+// WalkRuntimeImports is WalkActiveImports that also enters the blocks of
+// module-level try and with statements. Their imports run when the module
+// is imported, even though a failing one may be caught, so they count for
+// import order but not for binding names.
+func WalkRuntimeImports(tree *ast.AST, target Target, fn func(stmt ast.NodeID, typeChecking bool)) {
+	if tree == nil || tree.Root == ast.NoNode {
+		return
+	}
+	target.walkActiveImports(tree, tree.Root, false, true, fn)
+}
+
+func (t Target) walkActiveImports(tree *ast.AST, block ast.NodeID, typeChecking, guarded bool, fn func(ast.NodeID, bool)) {
+	for stmt := tree.Node(block).FirstChild; stmt != ast.NoNode; stmt = tree.Node(stmt).NextSibling {
+		switch tree.Node(stmt).Kind {
+		case ast.NodeImport, ast.NodeFromImport:
+			fn(stmt, typeChecking)
+		case ast.NodeIf:
+			test, body, orelse, bodyLive, orelseLive := t.ifBranches(tree, stmt)
+			if bodyLive && body != ast.NoNode {
+				t.walkActiveImports(tree, body, typeChecking || isTypeCheckingGuard(tree, test), guarded, fn)
+			}
+			if orelseLive && orelse != ast.NoNode {
+				t.walkActiveImports(tree, orelse, typeChecking, guarded, fn)
+			}
+		case ast.NodeTry:
+			if !guarded {
+				continue
+			}
+			body, excepts, orelse, finally := tree.TryParts(stmt)
+			blocks := []ast.NodeID{body, orelse, finally}
+			for _, except := range excepts {
+				_, _, handler := tree.ExceptParts(except)
+				blocks = append(blocks, handler)
+			}
+			for _, b := range blocks {
+				if b != ast.NoNode {
+					t.walkActiveImports(tree, b, typeChecking, guarded, fn)
+				}
+			}
+		case ast.NodeWith:
+			if _, body := tree.WithParts(stmt); guarded && body != ast.NoNode {
+				t.walkActiveImports(tree, body, typeChecking, guarded, fn)
+			}
+		}
+	}
+}
@@ -0,0 +1,625 @@
+package analyser
+
+import (
+	"strings"
+
+	"rahu/parser/ast"
+)
+
+// DataclassInfo records the fields of a dataclass-like class (dataclasses,
+// attrs, NamedTuple, namedtuple and @dataclass_transform targets) so that
+// subclasses can extend the synthesized constructor.
+type DataclassInfo struct {
+	Fields   []*Symbol       // __init__ parameters in order, inherited fields first
+	InitVars map[string]bool // init-only pseudo-fields, excluded from __match_args__
+}
+
+// dataclassOptions holds the class-level switches of a dataclass-like decorator.
+type dataclassOptions struct {
+	init      bool
+	kwOnly    bool
+	matchArgs bool
+	// attrs strips leading underscores from private attribute names.
+	stripPrivate bool
+	// attr.s without auto_attribs only collects attr.ib() assignments.
+	onlyFieldCalls bool
+}
+
+// fieldSpec describes a field specifier call such as field(...), attr.ib(...)
+// or attrs.field(...) used as the value of a class-level field.
+type fieldSpec struct {
+	defaultValue ast.NodeID
+	hasDefault   bool
+	hasFactory   bool
+	init         bool
+	kwOnly       bool
+}
+
+// exprDottedName renders Name and Attribute chains as "a.b.c".
+func exprDottedName(tree *ast.AST, expr ast.NodeID) string {
+	if expr == ast.NoNode {
+		return ""
+	}
+	switch tree.Node(expr).Kind {
+	case ast.NodeName:
+		name, _ := tree.NameText(expr)
+		return name
+	case ast.NodeAttribute:
+		base := tree.ChildAt(expr, 0)
+		attr := tree.ChildAt(expr, 1)
+		baseName := exprDottedName(tree, base)
+		attrName, _ := tree.NameText(attr)
+		if baseName == "" {
+			return attrName
+		}
+		if attrName == "" {
+			return baseName
+		}
+		return baseName + "." + attrName
+	default:
+		return ""
+	}
+}
+
+func lastDottedSegment(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// calleeDottedName returns the dotted callee name of a call, or the dotted
+// name of expr itself when it is not a call.
+func calleeDottedName(tree *ast.AST, expr ast.NodeID) string {
+	if expr != ast.NoNode && tree.Node(expr).Kind == ast.NodeCall {
+		return exprDottedName(tree, tree.ChildAt(expr, 0))
+	}
+	return exprDottedName(tree, expr)
+}
+
+// keywordArgs maps keyword names to their value nodes for a call.
+func keywordArgs(tree *ast.AST, call ast.NodeID) map[string]ast.NodeID {
+	if call == ast.NoNode || tree.Node(call).Kind != ast.NodeCall {
+		return nil
+	}
+	var out map[string]ast.NodeID
+	for arg := tree.Node(tree.Node(call).FirstChild).NextSibling; arg != ast.NoNode; arg = tree.Node(arg).NextSibling {
+		if tree.Node(arg).Kind != ast.NodeKeywordArg {
+			continue
+		}
+		name, ok := tree.NameText(tree.ChildAt(arg, 0))
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]ast.NodeID)
+		}
+		out[name] = tree.ChildAt(arg, 1)
+	}
+	return out
+}
+
+// positionalArgs returns the positional arguments of a call.
+func positionalArgs(tree *ast.AST, call ast.NodeID) []ast.NodeID {
+	if call == ast.NoNode || tree.Node(call).Kind != ast.NodeCall {
+		return nil
+	}
+	var out []ast.NodeID
+	for arg := tree.Node(tree.Node(call).FirstChild).NextSibling; arg != ast.NoNode; arg = tree.Node(arg).NextSibling {
+		switch tree.Node(arg).Kind {
+		case ast.NodeKeywordArg, ast.NodeStarArg, ast.NodeKwStarArg:
+			continue
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+func boolLiteral(tree *ast.AST, expr ast.NodeID) (bool, bool) {
+	if expr == ast.NoNode || tree.Node(expr).Kind != ast.NodeBoolean {
+		return false, false
+	}
+	return tree.Node(expr).Data == uint32(ast.TRUE), true
+}
+
+// fieldSpecifiers maps the qualified names of field specifier functions to
+// whether they take the default as their first positional argument.
+var fieldSpecifiers = map[string]bool{
+	"dataclasses.field":     false,
+	"attr.ib":               true,
+	"attr.attrib":           true,
+	"attr.field":            false,
+	"attrs.field":           false,
+	"pydantic.Field":        true,
+	"pydantic.fields.Field": true,
+}
+
+// parseFieldSpec recognises field specifier calls and extracts their options.
+func parseFieldSpec(tree *ast.AST, target Target, value ast.NodeID) (fieldSpec, bool) {
+	if value == ast.NoNode || tree.Node(value).Kind != ast.NodeCall {
+		return fieldSpec{}, false
+	}
+	positionalDefault, ok := fieldSpecifiers[qualifiedName(tree, target, tree.ChildAt(value, 0))]
+	if !ok {
+		return fieldSpec{}, false
+	}
+
+	spec := fieldSpec{init: true}
+	if positionalDefault {
+		if args := positionalArgs(tree, value); len(args) > 0 {
+			spec.defaultValue = args[0]
+			spec.hasDefault = true
+		}
+	}
+	for name, arg := range keywordArgs(tree, value) {
+		switch name {
+		case "default":
+			spec.defaultValue = arg
+			spec.hasDefault = true
+		case "default_factory", "factory":
+			spec.hasFactory = true
+		case "init":
+			if v, ok := boolLiteral(tree, arg); ok {
+				spec.init = v
+			}
+		case "kw_only":
+			if v, ok := boolLiteral(tree, arg); ok {
+				spec.kwOnly = v
+			}
+		}
+	}
+	return spec, true
+}
+
+// fieldDefaultText renders the default shown in signatures for a class-level
+// field value, unwrapping field specifier calls.
+func (b *ScopeBuilder) fieldDefaultText(value ast.NodeID) string {
+	spec, ok := parseFieldSpec(b.tree, b.target, value)
+	if !ok {
+		return b.extractValue(value)
+	}
+	if spec.hasDefault {
+		return b.extractValue(spec.defaultValue)
+	}
+	if spec.hasFactory {
+		return "<factory>"
+	}
+	return ""
+}
+
+// annotationWrapper reports the typing wrapper of an annotation such as
+// ClassVar[int] or InitVar[str], or the bare name when unsubscripted.
+func annotationWrapper(tree *ast.AST, annotation ast.NodeID) string {
+	if annotation == ast.NoNode {
+		return ""
+	}
+	if tree.Node(annotation).Kind == ast.NodeSubScript {
+		annotation = tree.ChildAt(annotation, 0)
+	}
+	return lastDottedSegment(exprDottedName(tree, annotation))
+}
+
+var attrsDecorators = map[string]bool{
+	"attr.s": true, "attr.attrs": true,
+	"attr.define": true, "attr.mutable": true, "attr.frozen": true,
+	"attrs.define": true, "attrs.mutable": true, "attrs.frozen": true,
+}
+
+// decoratorSymbol returns the resolved symbol of a decorator callee.
+func (r *Resolver) decoratorSymbol(expr ast.NodeID) *Symbol {
+	if expr != ast.NoNode && r.tree.Node(expr).Kind == ast.NodeCall {
+		expr = r.tree.ChildAt(expr, 0)
+	}
+	switch r.tree.Node(expr).Kind {
+	case ast.NodeName:
+		return r.Resolved[expr]
+	case ast.NodeAttribute:
+		return r.ResolvedAttr[expr]
+	}
+	return nil
+}
+
+func hasDataclassTransformDecorator(tree *ast.AST, stmt ast.NodeID) bool {
+	for _, decorator := range tree.Decorators(stmt) {
+		if lastDottedSegment(calleeDottedName(tree, tree.DecoratorExpr(decorator))) == "dataclass_transform" {
+			return true
+		}
+	}
+	return false
+}
+
+func inheritsDataclassTransform(cls *Symbol, seen map[*Symbol]bool) bool {
+	if cls == nil || seen[cls] {
+		return false
+	}
+	seen[cls] = true
+	for _, base := range cls.Bases {
+		if base != nil && (base.DataclassTransform || inheritsDataclassTransform(base, seen)) {
+			return true
+		}
+	}
+	return false
+}
+
+// dataclassOptionsForClass decides whether a class gets a synthesized
+// constructor and with which options.
+func (r *Resolver) dataclassOptionsForClass(stmt ast.NodeID, classSym *Symbol) (dataclassOptions, bool) {
+	for _, decorator := range r.tree.Decorators(stmt) {
+		expr := r.tree.DecoratorExpr(decorator)
+		callee := expr
+		if r.tree.Node(callee).Kind == ast.NodeCall {
+			callee = r.tree.ChildAt(callee, 0)
+		}
+		name := r.qualifiedName(callee)
+		opts := dataclassOptions{init: true, matchArgs: true}
+		switch {
+		case lastDottedSegment(name) == "dataclass":
+		case attrsDecorators[name]:
+			opts.stripPrivate = true
+			if name == "attr.s" || name == "attr.attrs" {
+				opts.onlyFieldCalls = true
+			}
+		default:
+			sym := r.decoratorSymbol(expr)
+			if sym == nil || !sym.DataclassTransform {
+				continue
+			}
+		}
+		for kw, arg := range keywordArgs(r.tree, expr) {
+			v, ok := boolLiteral(r.tree, arg)
+			if !ok {
+				continue
+			}
+			switch kw {
+			case "init":
+				opts.init = v
+			case "kw_only":
+				opts.kwOnly = v
+			case "match_args":
+				opts.matchArgs = v
+			case "auto_attribs":
+				opts.onlyFieldCalls = !v
+			}
+		}
+		return opts, true
+	}
+
+	_, bases, _ := r.tree.ClassParts(stmt)
+	for base := r.tree.Node(bases).FirstChild; base != ast.NoNode; base = r.tree.Node(base).NextSibling {
+		if lastDottedSegment(exprDottedName(r.tree, base)) == "NamedTuple" {
+			return dataclassOptions{init: true, matchArgs: true}, true
+		}
+	}
+	if inheritsDataclassTransform(classSym, map[*Symbol]bool{}) {
+		return dataclassOptions{init: true, matchArgs: true}, true
+	}
+	return dataclassOptions{}, false
+}
+
+// synthesizeDataclassMembers defines __init__ and __match_args__ for a
+// dataclass-like class after its body has been resolved.
+func (r *Resolver) synthesizeDataclassMembers(stmt ast.NodeID, classSym *Symbol) {
+	if classSym == nil || classSym.Inner == nil {
+		return
+	}
+	opts, ok := r.dataclassOptionsForClass(stmt, classSym)
+	if !ok {
+		return
+	}
+
+	info := &DataclassInfo{InitVars: make(map[string]bool)}
+	add := func(param *Symbol, initVar bool) {
+		delete(info.InitVars, param.Name)
+		if initVar {
+			info.InitVars[param.Name] = true
+		}
+		for i, existing := range info.Fields {
+			if existing.Name == param.Name {
+				// Redefined fields keep the position of the original.
+				info.Fields[i] = param
+				return
+			}
+		}
+		info.Fields = append(info.Fields, param)
+	}
+	remove := func(name string) {
+		delete(info.InitVars, name)
+		for i, existing := range info.Fields {
+			if existing.Name == name {
+				info.Fields = append(info.Fields[:i], info.Fields[i+1:]...)
+				return
+			}
+		}
+	}
+
+	for i := len(classSym.Bases) - 1; i >= 0; i-- {
+		base := classSym.Bases[i]
+		if base == nil || base.Dataclass == nil {
+			continue
+		}
+		for _, field := range base.Dataclass.Fields {
+			inherited := *field
+			inherited.Scope = nil
+			add(&inherited, base.Dataclass.InitVars[field.Name])
+		}
+	}
+
+	_, _, body := r.tree.ClassParts(stmt)
+	kwOnly := opts.kwOnly
+	for inner := r.tree.Node(body).FirstChild; inner != ast.NoNode; inner = r.tree.Node(inner).NextSibling {
+		var target, annotation, value ast.NodeID
+		switch r.tree.Node(inner).Kind {
+		case ast.NodeAnnAssign:
+			target, annotation, value = r.tree.AnnAssignParts(inner)
+		case ast.NodeAssign:
+			value = r.tree.Node(inner).FirstChild
+			target = r.tree.Node(value).NextSibling
+			if target == ast.NoNode || r.tree.Node(target).NextSibling != ast.NoNode {
+				continue
+			}
+		default:
+			continue
+		}
+		if r.tree.Node(target).Kind != ast.NodeName {
+			continue
+		}
+
+		wrapper := annotationWrapper(r.tree, annotation)
+		if wrapper == "KW_ONLY" {
+			kwOnly = true
+			continue
+		}
+		if wrapper == "ClassVar" {
+			continue
+		}
+		spec, isFieldCall := parseFieldSpec(r.tree, r.target, value)
+		if annotation == ast.NoNode && !(opts.onlyFieldCalls && isFieldCall) {
+			continue
+		}
+		if opts.onlyFieldCalls && !isFieldCall {
+			continue
+		}
+
+		name, _ := r.tree.NameText(target)
+		paramName := name
+		if opts.stripPrivate {
+			paramName = strings.TrimLeft(name, "_")
+		}
+		if isFieldCall && !spec.init {
+			remove(paramName)
+			continue
+		}
+
+		param := &Symbol{
+			Name:     paramName,
+			Kind:     SymParameter,
+			Span:     r.tree.RangeOf(target),
+			Def:      target,
+			IsKwOnly: kwOnly || spec.kwOnly,
+		}
+		if fieldSym := classSym.Inner.Symbols[name]; fieldSym != nil {
+			param.Inferred = fieldSym.Inferred
+			param.DefaultValue = fieldSym.DefaultValue
+			param.URI = fieldSym.URI
+		}
+		hasDefault := value != ast.NoNode && (!isFieldCall || spec.hasDefault || spec.hasFactory)
+		if !hasDefault {
+			param.DefaultValue = ""
+		} else if param.DefaultValue == "" {
+			param.DefaultValue = "..."
+		}
+		add(param, wrapper == "InitVar")
+	}
+
+	// Keyword-only fields follow the positional ones in the signature.
+	ordered := make([]*Symbol, 0, len(info.Fields))
+	for _, field := range info.Fields {
+		if !field.IsKwOnly {
+			ordered = append(ordered, field)
+		}
+	}
+	for _, field := range info.Fields {
+		if field.IsKwOnly {
+			ordered = append(ordered, field)
+		}
+	}
+	info.Fields = ordered
+	classSym.Dataclass = info
+
+	if opts.init {
+		defineSynthesizedInit(classSym, info.Fields)
+	}
+	if opts.matchArgs {
+		var names []string
+		for _, field := range info.Fields {
+			if !field.IsKwOnly && !info.InitVars[field.Name] {
+				names = append(names, field.Name)
+			}
+		}
+		defineMatchArgs(classSym, names)
+	}
+}
+
+// isSynthesized reports whether a class member was produced by this pass
+// rather than declared in source, so it can be replaced on re-resolution.
+func isSynthesized(sym *Symbol) bool {
+	return sym != nil && sym.Def == ast.NoNode
+}
+
+func defineSynthesizedInit(cls *Symbol, fields []*Symbol) {
+	if existing, ok := cls.Inner.Symbols["__init__"]; ok && !isSynthesized(existing) {
+		return
+	}
+
+	initSym := &Symbol{
+		Name: "__init__",
+		Kind: SymFunction,
+		Span: cls.Span,
+		URI:  cls.URI,
+	}
+	scope := NewScope(nil, ScopeFunction)
+	scope.Owner = initSym
+	initSym.Inner = scope
+
+	self := &Symbol{
+		Name:       "self",
+		Kind:       SymParameter,
+		Span:       cls.Span,
+		Inferred:   InstanceType(cls),
+		InstanceOf: cls,
+	}
+	_ = scope.Define(self)
+	initSym.Params = append(initSym.Params, self)
+	for _, field := range fields {
+		param := *field
+		if err := scope.Define(&param); err != nil {
+			continue
+		}
+		initSym.Params = append(initSym.Params, &param)
+	}
+
+	delete(cls.Inner.Symbols, "__init__")
+	_ = cls.Inner.Define(initSym)
+}
+
+func defineMatchArgs(cls *Symbol, names []string) {
+	if existing, ok := cls.Inner.Symbols["__match_args__"]; ok && !isSynthesized(existing) {
+		return
+	}
+
+	items := make([]*Type, len(names))
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		items[i] = BuiltinType(BuiltinSymbol("str"))
+		quoted[i] = "'" + name + "'"
+	}
+	text := "(" + strings.Join(quoted, ", ")
+	if len(names) == 1 {
+		text += ","
+	}
+	text += ")"
+
+	delete(cls.Inner.Symbols, "__match_args__")
+	_ = cls.Inner.Define(&Symbol{
+		Name:         "__match_args__",
+		Kind:         SymVariable,
+		Span:         cls.Span,
+		URI:          cls.URI,
+		Inferred:     TupleType(items...),
+		DefaultValue: text,
+	})
+}
+
+// synthesizeNamedTupleCall builds the class created by the functional forms
+// namedtuple("P", "x y") and NamedTuple("P", [("x", int), ("y", str)]).
+func (r *Resolver) synthesizeNamedTupleCall(call ast.NodeID) *Symbol {
+	callee := lastDottedSegment(calleeDottedName(r.tree, call))
+	if callee != "namedtuple" && callee != "NamedTuple" {
+		return nil
+	}
+	args := positionalArgs(r.tree, call)
+	if len(args) < 2 {
+		return nil
+	}
+	className, ok := r.tree.StringText(args[0])
+	if !ok || className == "" {
+		return nil
+	}
+	// Re-resolving a module must not mint a second class for the same call,
+	// or the assignment target would end up typed as a union of both.
+	for _, sym := range r.current.Symbols {
+		if typ := sym.Inferred; sym.Kind != SymImport && typ != nil && typ.Kind == TypeClass && typ.Symbol != nil &&
+			typ.Symbol.Def == args[0] && typ.Symbol.Name == className && typ.Symbol.Span == r.tree.RangeOf(args[0]) {
+			return typ.Symbol
+		}
+	}
+
+	type namedField struct {
+		name string
+		node ast.NodeID
+		typ  *Type
+	}
+	var fields []namedField
+	spec := args[1]
+	switch r.tree.Node(spec).Kind {
+	case ast.NodeString:
+		text, _ := r.tree.StringText(spec)
+		for _, name := range strings.FieldsFunc(text, func(c rune) bool { return c == ',' || c == ' ' }) {
+			fields = append(fields, namedField{name: name, node: spec})
+		}
+	case ast.NodeList, ast.NodeTuple:
+		for item := r.tree.Node(spec).FirstChild; item != ast.NoNode; item = r.tree.Node(item).NextSibling {
+			switch r.tree.Node(item).Kind {
+			case ast.NodeString:
+				name, _ := r.tree.StringText(item)
+				fields = append(fields, namedField{name: name, node: item})
+			case ast.NodeTuple:
+				nameNode := r.tree.ChildAt(item, 0)
+				name, ok := r.tree.StringText(nameNode)
+				if !ok {
+					continue
+				}
+				fields = append(fields, namedField{
+					name: name,
+					node: nameNode,
+					typ:  r.resolveTypeFromExpr(r.tree.ChildAt(item, 1)),
+				})
+			}
+		}
+	default:
+		return nil
+	}
+
+	defaults := 0
+	if value, ok := keywordArgs(r.tree, call)["defaults"]; ok {
+		switch r.tree.Node(value).Kind {
+		case ast.NodeList, ast.NodeTuple:
+			defaults = r.tree.ChildCount(value)
+		}
+	}
+
+	cls := &Symbol{
+		Name: className,
+		Kind: SymClass,
+		Span: r.tree.RangeOf(args[0]),
+		Def:  args[0],
+	}
+	if tupleSym := BuiltinSymbol("tuple"); tupleSym != nil {
+		cls.Bases = []*Symbol{tupleSym}
+	}
+	cls.Inner = NewScope(nil, ScopeClass)
+	cls.Inner.Owner = cls
+
+	info := &DataclassInfo{InitVars: make(map[string]bool)}
+	names := make([]string, 0, len(fields))
+	for i, field := range fields {
+		if field.name == "" {
+			continue
+		}
+		attr := &Symbol{
+			Name:     field.name,
+			Kind:     SymField,
+			Span:     r.tree.RangeOf(field.node),
+			Inferred: field.typ,
+		}
+		if err := cls.Inner.Define(attr); err != nil {
+			continue
+		}
+		param := &Symbol{
+			Name:     field.name,
+			Kind:     SymParameter,
+			Span:     attr.Span,
+			Inferred: field.typ,
+		}
+		if i >= len(fields)-defaults {
+			param.DefaultValue = "..."
+		}
+		info.Fields = append(info.Fields, param)
+		names = append(names, field.name)
+	}
+	cls.Dataclass = info
+	defineSynthesizedInit(cls, info.Fields)
+	defineMatchArgs(cls, names)
+	promoteOneClass(cls)
+	return cls
+}
@@ -0,0 +1,432 @@
+package analyser
+
+import (
+	"strings"
+
+	ast "rahu/parser/ast"
+)
+
+// cachingDecorators wrap a function in functools._lru_cache_wrapper, which
+// keeps the call signature and adds cache_info and cache_clear.
+var cachingDecorators = map[string]bool{
+	"lru_cache": true, "functools.lru_cache": true,
+	"cache": true, "functools.cache": true,
+}
+
+// contextManagerDecorators turn a generator function into a factory of
+// context managers, mapped to the class typeshed declares for the result.
+var contextManagerDecorators = map[string]string{
+	"contextmanager":                 "_GeneratorContextManager",
+	"contextlib.contextmanager":      "_GeneratorContextManager",
+	"asynccontextmanager":            "_AsyncGeneratorContextManager",
+	"contextlib.asynccontextmanager": "_AsyncGeneratorContextManager",
+}
+
+var wrapsDecorators = map[string]bool{"wraps": true, "functools.wraps": true}
+
+var partialConstructors = map[string]bool{"partial": true, "functools.partial": true}
+
+// applyDecorators replaces the signature of a def with the result of
+// calling its decorators on it, innermost first. When any decorator's
+// result is unknown the def keeps its own signature.
+func (r *Resolver) applyDecorators(stmt ast.NodeID, fn *Symbol) {
+	decorators := r.tree.Decorators(stmt)
+	if len(decorators) == 0 || len(fn.Overloads) > 0 || isOverloadDecorated(r.tree, stmt) {
+		return
+	}
+
+	sig := CallableType(declaredParams(fn), fn.Returns)
+	var object *Type
+	for i := len(decorators) - 1; i >= 0; i-- {
+		result, obj, ok := r.decoratedType(r.tree.DecoratorExpr(decorators[i]), fn, sig)
+		if !ok {
+			return
+		}
+		sig, object = result, obj
+	}
+	fn.Params = sig.Params
+	fn.Returns = sig.Returns
+	fn.Inferred = object
+	fn.Decorated = sig.Params == nil
+}
+
+// decoratedType returns the signature of the callable a decorator produces
+// from one with signature sig, and the type of the object it produces when
+// that is not a plain function.
+func (r *Resolver) decoratedType(expr ast.NodeID, fn *Symbol, sig *Type) (*Type, *Type, bool) {
+	callee := expr
+	isCall := r.tree.Node(expr).Kind == ast.NodeCall
+	if isCall {
+		callee = r.tree.ChildAt(expr, 0)
+	}
+	dotted := exprDottedName(r.tree, callee)
+
+	switch {
+	case isCall && wrapsDecorators[dotted]:
+		// The wrapper keeps its own signature but documents the wrapped function.
+		if wrapped := r.calleeSymbol(r.tree.ChildAt(expr, 1)); wrapped != nil && fn.DocString == "" {
+			fn.DocString = wrapped.DocString
+		}
+		return sig, nil, true
+	case cachingDecorators[dotted]:
+		return sig, r.stdlibInstance(callee, "_lru_cache_wrapper", sig.Returns), true
+	case contextManagerDecorators[dotted] != "":
+		async := strings.HasPrefix(lastDottedSegment(dotted), "async")
+		manager := r.stdlibInstance(callee, contextManagerDecorators[dotted], iterElementType(sig.Returns, async))
+		return CallableType(sig.Params, manager), nil, true
+	case isCall && partialConstructors[dotted]:
+		return r.applyPartialDecorator(expr, sig)
+	case signaturePreservingDecorators[dotted]:
+		return sig, nil, true
+	}
+	switch lastDottedSegment(dotted) {
+	case "setter", "getter", "deleter":
+		return sig, nil, true
+	}
+
+	decorator := r.decoratorSignature(expr, sig)
+	if decorator == nil {
+		return nil, nil, false
+	}
+	return applyCallable(decorator, 0, sig)
+}
+
+// decoratorSignature returns the callable type of a decorator expression.
+// Overloaded decorators are narrowed to the signature accepting sig.
+func (r *Resolver) decoratorSignature(expr ast.NodeID, sig *Type) *Type {
+	if r.tree.Node(expr).Kind == ast.NodeCall {
+		return CallableOf(r.exprType(expr))
+	}
+	fn := r.calleeSymbol(expr)
+	if fn == nil {
+		return nil
+	}
+	if fn.Kind != SymFunction {
+		return CallableOf(SymbolType(fn))
+	}
+	var recv *Type
+	if r.tree.Node(expr).Kind == ast.NodeAttribute {
+		recv = r.exprType(r.tree.ChildAt(expr, 0))
+	}
+	bound := IsBoundMethod(fn, recv)
+	if len(fn.Overloads) > 0 {
+		fn = SelectOverload(fn, bound, CallArgs{Positional: []*Type{sig}})
+		if fn == nil {
+			return nil
+		}
+	}
+	return functionType(fn, bound)
+}
+
+// applyPartialDecorator applies @partial(decorator, *args, **kwargs): the
+// decorated function fills the first positional parameter left unbound.
+func (r *Resolver) applyPartialDecorator(call ast.NodeID, sig *Type) (*Type, *Type, bool) {
+	args := r.tree.Children(call)[1:]
+	if len(args) == 0 {
+		return nil, nil, false
+	}
+	decorator := r.decoratorSignature(args[0], sig)
+	if decorator == nil {
+		return nil, nil, false
+	}
+	positional := 0
+	for _, arg := range args[1:] {
+		switch r.tree.Node(arg).Kind {
+		case ast.NodeStarArg:
+			return nil, nil, false
+		case ast.NodeKeywordArg, ast.NodeKwStarArg:
+		default:
+			positional++
+		}
+	}
+	return applyCallable(decorator, positional, sig)
+}
+
+// partialCallType types partial(fn, *args, **kwargs) as a callable taking
+// the parameters of fn the arguments leave unbound, returning what fn
+// returns. A keyword binds its parameter with a default and leaves the
+// parameters after it keyword-only. It falls back to partial[R] when the
+// signature of fn is unknown or the arguments are unpacked.
+func (r *Resolver) partialCallType(call ast.NodeID) *Type {
+	callee := r.tree.ChildAt(call, 0)
+	if r.qualifiedName(callee) != "functools.partial" {
+		return nil
+	}
+	fn := r.decoratorSignature(r.tree.ChildAt(call, 1), nil)
+	returns := UnknownType()
+	if fn != nil && !IsUnknownType(fn.Returns) && fn.Returns.Kind != TypeVariable {
+		returns = fn.Returns
+	}
+	if fn != nil && fn.Params != nil && fn.Spec == nil {
+		if params, ok := r.partialParams(call, fn.Params); ok {
+			return CallableType(params, returns)
+		}
+	}
+	cls := r.calleeSymbol(callee)
+	if cls == nil || cls.Kind != SymClass {
+		return nil
+	}
+	typ := InstanceType(cls)
+	if !IsUnknownType(returns) {
+		typ.Args = []*Type{returns}
+	}
+	return typ
+}
+
+// partialParams returns the parameters left after partial binds the
+// arguments of call following the wrapped function. It fails on *args or
+// **kwargs, whose bindings are unknown.
+func (r *Resolver) partialParams(call ast.NodeID, params []*Symbol) ([]*Symbol, bool) {
+	positional := 0
+	keywords := make(map[string]bool)
+	for _, arg := range r.tree.Children(call)[2:] {
+		switch r.tree.Node(arg).Kind {
+		case ast.NodeStarArg, ast.NodeKwStarArg:
+			return nil, false
+		case ast.NodeKeywordArg:
+			if name, ok := r.tree.NameText(r.tree.ChildAt(arg, 0)); ok {
+				keywords[name] = true
+			}
+		default:
+			positional++
+		}
+	}
+
+	remaining := make([]*Symbol, 0, len(params))
+	keywordOnly := false
+	for _, param := range params {
+		switch {
+		case param.IsVarArg:
+			positional = 0
+			remaining = append(remaining, param)
+			continue
+		case param.IsKwArg:
+			remaining = append(remaining, param)
+			continue
+		case positional > 0 && !param.IsKwOnly:
+			positional--
+			continue
+		}
+		if keywords[param.Name] && !param.IsPosOnly {
+			bound := *param
+			bound.IsKwOnly = true
+			bound.DefaultValue = "..."
+			remaining = append(remaining, &bound)
+			keywordOnly = true
+			continue
+		}
+		if keywordOnly && !param.IsKwOnly {
+			kwOnly := *param
+			kwOnly.IsKwOnly = true
+			param = &kwOnly
+		}
+		remaining = append(remaining, param)
+	}
+	return remaining, true
+}
+
+// calleeSymbol returns the symbol a name or attribute expression refers to.
+func (r *Resolver) calleeSymbol(expr ast.NodeID) *Symbol {
+	switch r.tree.Node(expr).Kind {
+	case ast.NodeName:
+		return r.Resolved[expr]
+	case ast.NodeAttribute:
+		return r.ResolvedAttr[expr]
+	}
+	return nil
+}
+
+// stdlibInstance returns an instance of the class named name declared next
+// to the function callee refers to, parameterized with arg. It is nil when
+// the defining module is not available.
+func (r *Resolver) stdlibInstance(callee ast.NodeID, name string, arg *Type) *Type {
+	fn := r.calleeSymbol(callee)
+	if fn == nil || fn.Scope == nil {
+		return nil
+	}
+	cls, ok := fn.Scope.Lookup(name)
+	if !ok || cls == nil || cls.Kind != SymClass {
+		return nil
+	}
+	typ := InstanceType(cls)
+	if !IsUnknownType(arg) {
+		typ.Args = []*Type{arg}
+	}
+	return typ
+}
+
+// applyCallable calls decorator with a function of signature sig as its
+// index-th positional argument. Type variables and ParamSpecs in the
+// decorator's parameter are solved against sig and substituted into its
+// return type.
+func applyCallable(decorator *Type, index int, sig *Type) (*Type, *Type, bool) {
+	bindings := newTypeBindings()
+	if param := positionalParam(decorator.Params, index); param != nil {
+		bindings.match(SymbolType(param), sig)
+	}
+	out := bindings.substitute(decorator.Returns)
+	if IsUnknownType(out) {
+		return nil, nil, false
+	}
+	if out.Kind == TypeCallable {
+		return out, nil, true
+	}
+	if call := CallableOf(out); call != nil {
+		return call, out, true
+	}
+	return CallableType(nil, nil), out, true
+}
+
+// positionalParam returns the parameter receiving the index-th positional
+// argument, or nil when there is none.
+func positionalParam(params []*Symbol, index int) *Symbol {
+	for _, param := range params {
+		switch {
+		case param.IsKwArg || param.IsKwOnly:
+			return nil
+		case param.IsVarArg:
+			return param
+		case index == 0:
+			return param
+		}
+		index--
+	}
+	return nil
+}
+
+// typeBindings holds the solutions for type variables and ParamSpecs.
+type typeBindings struct {
+	types map[*Symbol]*Type
+	specs map[*Symbol][]*Symbol
+}
+
+func newTypeBindings() typeBindings {
+	return typeBindings{types: map[*Symbol]*Type{}, specs: map[*Symbol][]*Symbol{}}
+}
+
+// match solves the type variables in param so that it describes arg.
+func (b typeBindings) match(param, arg *Type) {
+	if param == nil || IsUnknownType(arg) {
+		return
+	}
+	switch param.Kind {
+	case TypeVariable:
+		if _, ok := b.types[param.Symbol]; !ok {
+			b.types[param.Symbol] = arg
+		}
+	case TypeCallable:
+		if arg.Kind != TypeCallable {
+			return
+		}
+		if arg.Params != nil {
+			for i, p := range param.Params {
+				if i < len(arg.Params) {
+					b.match(SymbolType(p), SymbolType(arg.Params[i]))
+				}
+			}
+			if param.Spec != nil && len(arg.Params) >= len(param.Params) {
+				b.specs[param.Spec.Symbol] = arg.Params[len(param.Params):]
+			}
+		}
+		b.match(param.Returns, arg.Returns)
+	case TypeInstance:
+		if len(param.Args) == 0 || param.Symbol == nil {
+			return
+		}
+		if arg.Kind == TypeInstance && arg.Symbol != nil && arg.Symbol.Name == param.Symbol.Name && len(arg.Args) == len(param.Args) {
+			for i := range param.Args {
+				b.match(param.Args[i], arg.Args[i])
+			}
+			return
+		}
+		if parameterizedProtocols[param.Symbol.Name] {
+			b.match(param.Args[0], iterElementType(arg, asyncProtocols[param.Symbol.Name]))
+		}
+	case TypeList, TypeSet:
+		if arg.Kind == param.Kind {
+			b.match(param.Elem, arg.Elem)
+		}
+	case TypeDict:
+		if arg.Kind == TypeDict {
+			b.match(param.Key, arg.Key)
+			b.match(param.Elem, arg.Elem)
+		}
+	}
+}
+
+// substitute replaces the solved type variables in t. Unsolved variables
+// become unknown, as does a callable forwarding an unsolved ParamSpec.
+func (b typeBindings) substitute(t *Type) *Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind {
+	case TypeVariable:
+		return b.types[t.Symbol]
+	case TypeCallable:
+		out := CallableType(nil, b.substitute(t.Returns))
+		if t.Params == nil {
+			return out
+		}
+		params := make([]*Symbol, 0, len(t.Params))
+		for _, param := range t.Params {
+			params = append(params, b.substituteParam(param))
+		}
+		if t.Spec != nil {
+			spec, ok := b.specs[t.Spec.Symbol]
+			if !ok {
+				return out
+			}
+			params = append(params, spec...)
+		}
+		out.Params = params
+		return out
+	case TypeInstance, TypeClass:
+		if len(t.Args) == 0 {
+			return t
+		}
+		out := *t
+		out.Args = make([]*Type, len(t.Args))
+		for i, arg := range t.Args {
+			out.Args[i] = b.substitute(arg)
+		}
+		return &out
+	case TypeList:
+		return ListType(b.substitute(t.Elem))
+	case TypeSet:
+		return SetType(b.substitute(t.Elem))
+	case TypeDict:
+		return DictType(b.substitute(t.Key), b.substitute(t.Elem))
+	case TypeTuple:
+		if t.Elem != nil {
+			return VarTupleType(b.substitute(t.Elem))
+		}
+		items := make([]*Type, len(t.Items))
+		for i, item := range t.Items {
+			items[i] = b.substitute(item)
+		}
+		return TupleType(items...)
+	case TypeUnion:
+		arms := make([]*Type, 0, len(t.Union))
+		for _, arm := range t.Union {
+			arms = append(arms, b.substitute(arm))
+		}
+		return JoinTypes(arms...)
+	}
+	return t
+}
+
+// substituteParam returns param with its annotated type substituted.
+func (b typeBindings) substituteParam(param *Symbol) *Symbol {
+	typ := SymbolType(param)
+	if IsUnknownType(typ) {
+		return param
+	}
+	solved := b.substitute(typ)
+	if SameType(solved, typ) {
+		return param
+	}
+	clone := *param
+	clone.Inferred = solved
+	return &clone
+}
@@ -0,0 +1,417 @@
+package analyser
+
+import (
+	"strings"
+
+	ast "rahu/parser/ast"
+)
+
+// enumBases are the enum module classes whose subclasses are enumerations.
+var enumBases = map[string]bool{
+	"Enum": true, "IntEnum": true, "StrEnum": true, "ReprEnum": true, "Flag": true, "IntFlag": true,
+}
+
+// flagBases are the enumerations whose members combine with |, so a value
+// is not necessarily one of the declared members.
+var flagBases = map[string]bool{"Flag": true, "IntFlag": true}
+
+var enumAutoCalls = map[string]bool{"auto": true, "enum.auto": true}
+
+var enumMemberCalls = map[string]bool{"member": true, "enum.member": true}
+
+var enumNonMemberCalls = map[string]bool{"nonmember": true, "enum.nonmember": true}
+
+var assertNeverFuncs = map[string]bool{
+	"assert_never": true, "typing.assert_never": true, "typing_extensions.assert_never": true,
+}
+
+// EnumInfo records the members of an enumeration in declaration order.
+type EnumInfo struct {
+	Members []*Symbol
+	Values  []*Type // Type of each member's value, parallel to Members
+	Flag    bool    // Flag or IntFlag: members combine into other values
+}
+
+// valueOf returns the value type of member, or nil when it is not a member.
+func (e *EnumInfo) valueOf(member *Symbol) *Type {
+	for i, m := range e.Members {
+		if m == member {
+			return e.Values[i]
+		}
+	}
+	return nil
+}
+
+// EnumMemberType returns the Literal[Cls.MEMBER] type of an enum member.
+func EnumMemberType(cls, member *Symbol) *Type {
+	return &Type{Kind: TypeInstance, Symbol: cls, Literal: member}
+}
+
+// IsEnumMember reports whether sym is a member of the enumeration whose
+// body defines it.
+func IsEnumMember(sym *Symbol) bool {
+	owner := OwnerClass(sym)
+	return owner != nil && owner.Enum != nil && owner.Enum.valueOf(sym) != nil
+}
+
+// isEnumBase reports whether subclassing base makes a class an enumeration.
+func isEnumBase(base *Symbol) bool {
+	return base != nil && (base.Enum != nil || enumBases[base.Name])
+}
+
+// enumClass returns the enumeration t is an instance of, or nil.
+func enumClass(t *Type) *Symbol {
+	if IsUnknownType(t) || t.Kind != TypeInstance || t.Symbol == nil || t.Symbol.Enum == nil {
+		return nil
+	}
+	return t.Symbol
+}
+
+func sameEnum(a, b *Symbol) bool {
+	return a != nil && b != nil && a.Enum != nil && a.Enum == b.Enum
+}
+
+// enumMixin returns the builtin type an enumeration's values are converted
+// to, "int" or "str", or "" when values keep their own types.
+func enumMixin(cls *Symbol) string {
+	for _, sym := range MRO(cls) {
+		switch sym.Name {
+		case "int", "IntEnum", "IntFlag":
+			return "int"
+		case "str", "StrEnum":
+			return "str"
+		}
+	}
+	return ""
+}
+
+// isEnumMemberName reports whether an enum body assignment to name creates
+// a member. Dunder, _sunder_ and private names are ordinary attributes.
+func isEnumMemberName(name string) bool {
+	if strings.HasPrefix(name, "__") {
+		return false
+	}
+	return !(len(name) > 1 && strings.HasPrefix(name, "_") && strings.HasSuffix(name, "_"))
+}
+
+// collectEnumMembers records the members an enumeration body declares and
+// types each as Literal[Cls.MEMBER]. Assigning an earlier member's name
+// creates an alias rather than a new member.
+func (r *Resolver) collectEnumMembers(cls *Symbol, body ast.NodeID) {
+	info := &EnumInfo{}
+	for _, base := range cls.Bases {
+		if (base.Enum != nil && base.Enum.Flag) || flagBases[base.Name] {
+			info.Flag = true
+		}
+	}
+	mixin := enumMixin(cls)
+
+	for stmt := r.tree.Node(body).FirstChild; stmt != ast.NoNode; stmt = r.tree.Node(stmt).NextSibling {
+		var value ast.NodeID
+		var targets []ast.NodeID
+		switch r.tree.Node(stmt).Kind {
+		case ast.NodeAssign:
+			if r.tree.UnpackCount(stmt) > 0 {
+				continue
+			}
+			value = r.tree.Node(stmt).FirstChild
+			for target := r.tree.Node(value).NextSibling; target != ast.NoNode; target = r.tree.Node(target).NextSibling {
+				targets = append(targets, target)
+			}
+		case ast.NodeAnnAssign:
+			var target ast.NodeID
+			target, _, value = r.tree.AnnAssignParts(stmt)
+			if value == ast.NoNode {
+				continue
+			}
+			targets = append(targets, target)
+		default:
+			continue
+		}
+
+		valueType, ok := r.enumValueType(value, mixin)
+		if !ok {
+			continue
+		}
+		var alias *Symbol
+		if r.tree.Node(value).Kind == ast.NodeName {
+			if sym := r.Resolved[value]; sym != nil && info.valueOf(sym) != nil {
+				alias = sym
+			}
+		}
+		for _, target := range targets {
+			name, ok := r.tree.NameText(target)
+			if !ok || r.tree.Node(target).Kind != ast.NodeName || !isEnumMemberName(name) {
+				continue
+			}
+			sym := cls.Inner.Symbols[name]
+			if sym == nil || sym.Kind != SymVariable {
+				continue
+			}
+			if alias != nil {
+				sym.Inferred = EnumMemberType(cls, alias)
+				sym.InstanceOf = cls
+				continue
+			}
+			if info.valueOf(sym) != nil {
+				continue
+			}
+			info.Members = append(info.Members, sym)
+			info.Values = append(info.Values, valueType)
+			sym.Inferred = EnumMemberType(cls, sym)
+			sym.InstanceOf = cls
+			alias = sym
+		}
+	}
+	cls.Enum = info
+}
+
+// enumValueType returns the type of the value a member assignment stores.
+// The boolean is false when the assignment does not create a member.
+func (r *Resolver) enumValueType(value ast.NodeID, mixin string) (*Type, bool) {
+	callee := calleeDottedName(r.tree, value)
+	isCall := r.tree.Node(value).Kind == ast.NodeCall
+	switch {
+	case isCall && enumNonMemberCalls[callee]:
+		return nil, false
+	case isCall && enumAutoCalls[callee]:
+		if mixin == "str" {
+			return BuiltinType(BuiltinSymbol("str")), true
+		}
+		return BuiltinType(BuiltinSymbol("int")), true
+	}
+	typ := r.exprType(value)
+	if isCall && enumMemberCalls[callee] {
+		args := positionalArgs(r.tree, value)
+		if len(args) == 0 {
+			return nil, false
+		}
+		typ = r.exprType(args[0])
+	} else if typ != nil && (typ.Kind == TypeClass || typ.Kind == TypeCallable) {
+		// Nested classes and functions stay ordinary attributes.
+		return nil, false
+	}
+	if mixin != "" {
+		return BuiltinType(BuiltinSymbol(mixin)), true
+	}
+	if typ == nil {
+		typ = UnknownType()
+	}
+	return typ, true
+}
+
+// EnumValueType returns the type of .value on a value of type t: the value
+// of a known member, or of any member of the enumeration.
+func EnumValueType(t *Type) *Type {
+	if !IsUnknownType(t) && t.Kind == TypeUnion {
+		values := make([]*Type, 0, len(t.Union))
+		for _, arm := range t.Union {
+			values = append(values, EnumValueType(arm))
+		}
+		return JoinTypes(values...)
+	}
+	cls := enumClass(t)
+	if cls == nil {
+		return nil
+	}
+	if t.Literal != nil {
+		return cls.Enum.valueOf(t.Literal)
+	}
+	return JoinTypes(cls.Enum.Values...)
+}
+
+// enumAttributeType types the name and value attributes of enum members.
+func enumAttributeType(t *Type, attr string) *Type {
+	if enumClass(t) == nil {
+		return nil
+	}
+	switch attr {
+	case "value", "_value_":
+		return EnumValueType(t)
+	case "name", "_name_":
+		return BuiltinType(BuiltinSymbol("str"))
+	}
+	return nil
+}
+
+// flagOpType is the result of combining members of a Flag enumeration with
+// |, which is an instance of the flag rather than one of its members.
+func flagOpType(op ast.Operator, left, right *Type) *Type {
+	if op != ast.BitOr {
+		return nil
+	}
+	cls := enumClass(left)
+	if cls == nil || !cls.Enum.Flag {
+		return nil
+	}
+	if other := enumClass(right); other != nil {
+		if !sameEnum(cls, other) {
+			return nil
+		}
+	} else if sym := builtinOperand(right); sym == nil || sym.Name != "int" || enumMixin(cls) != "int" {
+		// IntFlag members also combine with plain ints.
+		return nil
+	}
+	return InstanceType(cls)
+}
+
+// expandEnumArms splits t into its union arms, replacing each instance of
+// an enumeration by its members so that they can be narrowed away one at a
+// time. Flag enumerations are kept whole.
+func expandEnumArms(t *Type) []*Type {
+	var arms []*Type
+	for _, arm := range FlattenUnion(t) {
+		cls := enumClass(arm)
+		if cls == nil || arm.Literal != nil || cls.Enum.Flag || len(cls.Enum.Members) == 0 {
+			arms = append(arms, arm)
+			continue
+		}
+		for _, member := range cls.Enum.Members {
+			arms = append(arms, EnumMemberType(cls, member))
+		}
+	}
+	return arms
+}
+
+// splitEnumArms partitions arms into the members matched by match and the
+// rest.
+func splitEnumArms(arms []*Type, match func(*Type) bool) (matched, rest []*Type) {
+	for _, arm := range arms {
+		if match(arm) {
+			matched = append(matched, arm)
+		} else {
+			rest = append(rest, arm)
+		}
+	}
+	return matched, rest
+}
+
+// isMemberArm returns a matcher for the literal type of member.
+func isMemberArm(member *Type) func(*Type) bool {
+	return func(arm *Type) bool {
+		return arm.Literal != nil && arm.Literal == member.Literal
+	}
+}
+
+// enumComparison recognises `name is Cls.MEMBER` and `name == Cls.MEMBER`
+// tests and their negations. positive is false for `is not` and `!=`.
+func (r *Resolver) enumComparison(test ast.NodeID) (name string, member *Type, positive, ok bool) {
+	if test == ast.NoNode || r.tree.Node(test).Kind != ast.NodeCompare {
+		return "", nil, false, false
+	}
+	left := r.tree.Node(test).FirstChild
+	cmp := r.tree.Node(left).NextSibling
+	if r.tree.Node(left).Kind != ast.NodeName || cmp == ast.NoNode || r.tree.Node(cmp).NextSibling != ast.NoNode {
+		return "", nil, false, false
+	}
+	switch ast.CompareOp(r.tree.Node(cmp).Data) {
+	case ast.Is, ast.Eq:
+		positive = true
+	case ast.IsNot, ast.NotEq:
+	default:
+		return "", nil, false, false
+	}
+	member = r.exprType(r.tree.Node(cmp).FirstChild)
+	if enumClass(member) == nil || member.Literal == nil {
+		return "", nil, false, false
+	}
+	name, _ = r.tree.NameText(left)
+	return name, member, positive, true
+}
+
+// narrowEnumComparison returns the types an enum comparison narrows its
+// variable to when the test holds and when it does not. Either is nil when
+// the comparison says nothing about the variable's declared type.
+func (r *Resolver) narrowEnumComparison(test ast.NodeID) (name string, whenTrue, whenFalse *Type) {
+	name, member, positive, ok := r.enumComparison(test)
+	if !ok {
+		return "", nil, nil
+	}
+	arms := expandEnumArms(r.narrowedNameType(name))
+	matched, rest := splitEnumArms(arms, isMemberArm(member))
+	if len(matched) == 0 {
+		return "", nil, nil
+	}
+	whenTrue, whenFalse = JoinTypes(matched...), JoinTypes(rest...)
+	if !positive {
+		whenTrue, whenFalse = whenFalse, whenTrue
+	}
+	return name, whenTrue, whenFalse
+}
+
+// narrowedNameType returns the type name currently has, including any
+// narrowing in effect.
+func (r *Resolver) narrowedNameType(name string) *Type {
+	if t, ok := r.typeConstraints[name]; ok {
+		return t
+	}
+	sym, ok := r.current.Lookup(name)
+	if !ok {
+		return nil
+	}
+	return SymbolType(sym)
+}
+
+// patternArms splits the remaining arms of a match subject into those a
+// case pattern matches and those left for later cases. refutable is false
+// when the pattern matches anything.
+func (r *Resolver) patternArms(pattern ast.NodeID, arms []*Type) (matched, rest []*Type, refutable bool) {
+	switch r.tree.Node(pattern).Kind {
+	case ast.NodeName:
+		return arms, nil, false
+	case ast.NodeMatchAs:
+		return r.patternArms(r.tree.ChildAt(pattern, 0), arms)
+	case ast.NodeBinOp:
+		left := r.tree.Node(pattern).FirstChild
+		leftMatched, rest, leftRefutable := r.patternArms(left, arms)
+		rightMatched, rest, rightRefutable := r.patternArms(r.tree.Node(left).NextSibling, rest)
+		return append(leftMatched, rightMatched...), rest, leftRefutable && rightRefutable
+	case ast.NodeAttribute:
+		member := r.exprType(pattern)
+		if enumClass(member) == nil || member.Literal == nil {
+			return nil, arms, true
+		}
+		matched, rest = splitEnumArms(arms, isMemberArm(member))
+		return matched, rest, true
+	case ast.NodeCall:
+		// A class pattern without subpatterns matches every instance.
+		cls := r.tree.Node(pattern).FirstChild
+		clsType := r.exprType(cls)
+		if r.tree.Node(cls).NextSibling != ast.NoNode || IsUnknownType(clsType) || clsType.Kind != TypeClass || clsType.Symbol == nil {
+			return nil, arms, true
+		}
+		matched, rest = splitEnumArms(arms, func(arm *Type) bool {
+			sym := nominalSymbol(arm)
+			return sym != nil && (sameEnum(sym, clsType.Symbol) || isSubclassOf(sym, clsType.Symbol, map[*Symbol]bool{}))
+		})
+		return matched, rest, true
+	}
+	return nil, arms, true
+}
+
+// checkAssertNever reports an assert_never() call whose argument can still
+// be an enum member, naming the members no branch handled.
+func (r *Resolver) checkAssertNever(call ast.NodeID) {
+	if !assertNeverFuncs[calleeDottedName(r.tree, call)] {
+		return
+	}
+	args := positionalArgs(r.tree, call)
+	if len(args) != 1 {
+		return
+	}
+	var unhandled []string
+	for _, arm := range expandEnumArms(r.exprType(args[0])) {
+		cls := enumClass(arm)
+		if cls == nil {
+			continue
+		}
+		if arm.Literal != nil {
+			unhandled = append(unhandled, cls.Name+"."+arm.Literal.Name)
+		} else {
+			unhandled = append(unhandled, cls.Name)
+		}
+	}
+	if len(unhandled) > 0 {
+		r.error(r.tree.RangeOf(args[0]), "assert_never() reached with unhandled enum values: "+strings.Join(unhandled, ", "))
+	}
+}
@@ -0,0 +1,128 @@
+package analyser
+
+import (
+	"sort"
+
+	"rahu/parser/ast"
+)
+
+// finalDecorators mark methods that subclasses may not override.
+var finalDecorators = map[string]bool{
+	"final": true, "typing.final": true, "typing_extensions.final": true,
+}
+
+// annotationQualifiers reports whether an annotation declares a Final name
+// or a ClassVar, looking through nested wrappers such as ClassVar[Final[int]].
+func annotationQualifiers(tree *ast.AST, annotation ast.NodeID) (final, classVar bool) {
+	for annotation != ast.NoNode {
+		switch annotationWrapper(tree, annotation) {
+		case "Final":
+			final = true
+		case "ClassVar":
+			classVar = true
+		default:
+			return final, classVar
+		}
+		if tree.Node(annotation).Kind != ast.NodeSubScript {
+			break
+		}
+		annotation = tree.ChildAt(annotation, 1)
+	}
+	return final, classVar
+}
+
+// isBareQualifier reports an unsubscripted Final or ClassVar annotation,
+// whose type comes from the assigned value instead.
+func isBareQualifier(tree *ast.AST, annotation ast.NodeID) bool {
+	if annotation == ast.NoNode || tree.Node(annotation).Kind == ast.NodeSubScript {
+		return false
+	}
+	final, classVar := annotationQualifiers(tree, annotation)
+	return final || classVar
+}
+
+// qualifiedDeclaration returns the first declaration of name along the MRO of
+// cls that is annotated Final or ClassVar, or decorated @final.
+func qualifiedDeclaration(cls *Symbol, name string) *Symbol {
+	for _, c := range MRO(cls) {
+		scopes := []*Scope{c.Inner, c.Attrs}
+		if c.Inner == nil && c.Attrs == nil {
+			scopes = []*Scope{c.Members}
+		}
+		for _, scope := range scopes {
+			if scope == nil {
+				continue
+			}
+			if sym := scope.Symbols[name]; sym != nil && (sym.Final || sym.ClassVar) {
+				return sym
+			}
+		}
+	}
+	return nil
+}
+
+// checkFinalNameWrite reports a rebinding of a name declared Final anywhere
+// other than at its declaration.
+func (r *Resolver) checkFinalNameWrite(id ast.NodeID, sym *Symbol) {
+	if sym == nil || !sym.Final || sym.Kind == SymFunction || sym.Def == id {
+		return
+	}
+	r.error(r.tree.RangeOf(id), "cannot assign to final name: "+sym.Name)
+}
+
+// checkAttributeWrite reports assignments to attr that its declaration
+// forbids: rebinding a Final attribute, and setting a ClassVar through an
+// instance. A Final declared in the class body without a value may still be
+// initialized through self.
+func (r *Resolver) checkAttributeWrite(attr ast.NodeID, cls *Symbol, instance, viaSelf bool) {
+	name, _ := r.tree.NameText(attr)
+	decl := qualifiedDeclaration(cls, name)
+	if decl == nil || decl.Def == attr {
+		return
+	}
+	switch {
+	case decl.Final && decl.Kind != SymFunction:
+		if viaSelf && decl.Kind != SymAttr && decl.DefaultValue == "" {
+			return
+		}
+		r.error(r.tree.RangeOf(attr), "cannot assign to final attribute: "+name)
+	case decl.ClassVar && instance:
+		r.error(r.tree.RangeOf(attr), "cannot assign to class variable through an instance: "+name)
+	}
+}
+
+// checkFinalOverrides reports class-body definitions of cls that override a
+// Final attribute or @final method inherited from one of its bases.
+func (r *Resolver) checkFinalOverrides(cls *Symbol) {
+	if cls == nil || cls.Inner == nil {
+		return
+	}
+	mro := MRO(cls)
+	if len(mro) < 2 {
+		return
+	}
+
+	defs := make([]*Symbol, 0, len(cls.Inner.Symbols))
+	for _, sym := range cls.Inner.Symbols {
+		defs = append(defs, sym)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Span.Start < defs[j].Span.Start })
+
+	for _, sym := range defs {
+		for _, base := range mro[1:] {
+			if !DefinesMember(base, sym.Name) {
+				continue
+			}
+			inherited := qualifiedDeclaration(base, sym.Name)
+			if inherited == nil || !inherited.Final {
+				break
+			}
+			what := "attribute"
+			if inherited.Kind == SymFunction {
+				what = "method"
+			}
+			r.error(sym.Span, "cannot override final "+what+": "+base.Name+"."+sym.Name)
+			break
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package analyser
+
+import (
+	"fmt"
+	"strings"
+
+	"rahu/parser/ast"
+)
+
+// generatorInfo collects what the body of the function being resolved
+// yields and returns, along with the types its return annotation declares.
+type generatorInfo struct {
+	async   bool
+	yielded bool
+	used    bool // the value of some yield expression is used
+	yields  []*Type
+	returns []*Type
+
+	declaredYield *Type
+	declaredSend  *Type
+}
+
+// generatorClasses are stand-ins for typing.Generator and
+// typing.AsyncGenerator, used to type unannotated generator functions when
+// typing itself may not be resolved.
+var generatorClasses = map[bool]*Symbol{
+	false: newGeneratorClass("Generator", "__iter__", "__next__", "send", "throw", "close"),
+	true:  newGeneratorClass("AsyncGenerator", "__aiter__", "__anext__", "asend", "athrow", "aclose"),
+}
+
+func newGeneratorClass(name string, methods ...string) *Symbol {
+	cls := &Symbol{Name: name, Kind: SymClass, Members: NewScope(nil, ScopeMember)}
+	for _, method := range methods {
+		_ = cls.Members.Define(&Symbol{Name: method, Kind: SymFunction, Scope: cls.Members})
+	}
+	return cls
+}
+
+// newGeneratorInfo starts collecting the yields of a function whose declared
+// return type is declared.
+func newGeneratorInfo(declared *Type, async bool) *generatorInfo {
+	yield, send, _ := generatorTypes(declared)
+	return &generatorInfo{async: async, declaredYield: yield, declaredSend: send}
+}
+
+// generatorTypes returns the yield, send and return types of a Generator,
+// AsyncGenerator or iterator protocol type; any of them may be nil.
+func generatorTypes(t *Type) (yield, send, ret *Type) {
+	if IsUnknownType(t) || t.Kind != TypeInstance || t.Symbol == nil {
+		return nil, nil, nil
+	}
+	arg := func(i int) *Type {
+		if i < len(t.Args) {
+			return t.Args[i]
+		}
+		return nil
+	}
+	switch t.Symbol.Name {
+	case "Generator":
+		return arg(0), arg(1), arg(2)
+	case "AsyncGenerator":
+		return arg(0), arg(1), nil
+	case "Iterator", "Iterable", "AsyncIterator", "AsyncIterable":
+		return arg(0), nil, nil
+	}
+	return nil, nil, nil
+}
+
+// inferredReturn returns the Generator or AsyncGenerator type of a function
+// that yields, or nil when it never does. The send type is None unless the
+// value of a yield expression is used.
+func (g *generatorInfo) inferredReturn() *Type {
+	if !g.yielded {
+		return nil
+	}
+	var send *Type
+	if !g.used {
+		send = noneAnnotation()
+	}
+	args := []*Type{JoinTypes(g.yields...), send}
+	if !g.async {
+		ret := noneAnnotation()
+		if len(g.returns) > 0 {
+			ret = JoinTypes(g.returns...)
+		}
+		args = append(args, ret)
+	}
+	return &Type{Kind: TypeInstance, Symbol: generatorClasses[g.async], Args: args}
+}
+
+// visitYield resolves a yield or yield from expression. used reports whether
+// its value is consumed, which for a generator means values may be sent in.
+func (r *Resolver) visitYield(expr ast.NodeID, used bool) {
+	value := r.tree.Node(expr).FirstChild
+	r.visitExpr(value, Read)
+	gen := r.generator
+	if gen == nil {
+		return
+	}
+	gen.yielded = true
+	gen.used = gen.used || used
+
+	if r.tree.Node(expr).Data == 1 {
+		// yield from delegates to the sub-iterator: its items are yielded
+		// and its return value is the value of the expression.
+		sub := r.exprType(value)
+		elem := iterElementType(sub, false)
+		gen.yields = append(gen.yields, elem)
+		r.checkYieldType(value, elem)
+		if _, _, ret := generatorTypes(sub); ret != nil {
+			r.setExprType(expr, ret)
+		}
+		return
+	}
+
+	typ := noneAnnotation()
+	if value != ast.NoNode {
+		typ = r.exprType(value)
+	}
+	gen.yields = append(gen.yields, typ)
+	r.checkYieldType(value, typ)
+	r.setExprType(expr, gen.declaredSend)
+}
+
+// checkYieldType reports a yielded value incompatible with the yield type
+// declared by the function's return annotation.
+func (r *Resolver) checkYieldType(value ast.NodeID, typ *Type) {
+	want := r.generator.declaredYield
+	if value == ast.NoNode || IsUnknownType(want) || IsUnknownType(typ) || typeAccepts(want, typ) {
+		return
+	}
+	have, declared := typeLabel(typ), typeLabel(want)
+	if have == "" || declared == "" {
+		return
+	}
+	r.error(r.tree.RangeOf(value), fmt.Sprintf("yield type %s is incompatible with declared yield type %s", have, declared))
+}
+
+// typeLabel names a type for diagnostics by its class, or returns "" when
+// it has no simple name.
+func typeLabel(t *Type) string {
+	if t.Kind == TypeUnion {
+		names := make([]string, 0, len(t.Union))
+		for _, arm := range t.Union {
+			name := typeLabel(arm)
+			if name == "" {
+				return ""
+			}
+			names = append(names, name)
+		}
+		return strings.Join(names, " | ")
+	}
+	sym := nominalSymbol(t)
+	if sym == nil {
+		return ""
+	}
+	if sym.Name == "NoneType" {
+		return "None"
+	}
+	return sym.Name
+}
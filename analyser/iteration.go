@@ -0,0 +1,229 @@
+package analyser
+
+// parameterizedProtocols are the typing generics whose type arguments
+// describe what iterating or awaiting an instance produces. They are
+// recognised by name so annotations keep working when typing itself is
+// unresolved.
+var parameterizedProtocols = map[string]bool{
+	"Iterable":        true,
+	"Iterator":        true,
+	"Generator":       true,
+	"Collection":      true,
+	"Sequence":        true,
+	"MutableSequence": true,
+	"AbstractSet":     true,
+	"MutableSet":      true,
+	"Reversible":      true,
+	"KeysView":        true,
+	"ValuesView":      true,
+	"Mapping":         true,
+	"MutableMapping":  true,
+	"AsyncIterable":   true,
+	"AsyncIterator":   true,
+	"AsyncGenerator":  true,
+	"Awaitable":       true,
+	"Coroutine":       true,
+}
+
+// asyncProtocols are the parameterized protocols consumed by async for.
+var asyncProtocols = map[string]bool{
+	"AsyncIterable":  true,
+	"AsyncIterator":  true,
+	"AsyncGenerator": true,
+}
+
+// iterElementType returns the type a for loop over t binds to its target.
+// Builtin containers use their element types, parameterized protocols their
+// first type argument, and other classes the result of __next__ on the
+// iterator returned by __iter__ (or __anext__ and __aiter__ when async).
+func iterElementType(t *Type, async bool) *Type {
+	if IsUnknownType(t) {
+		return nil
+	}
+	if t.Kind == TypeUnion {
+		elems := make([]*Type, 0, len(t.Union))
+		for _, arm := range t.Union {
+			elems = append(elems, iterElementType(arm, async))
+		}
+		return JoinTypes(elems...)
+	}
+	if !async {
+		switch t.Kind {
+		case TypeClass:
+			// Iterating an enumeration yields its members.
+			if t.Symbol != nil && t.Symbol.Enum != nil {
+				return InstanceType(t.Symbol)
+			}
+		case TypeList, TypeSet:
+			return t.Elem
+		case TypeTuple:
+			if t.Elem != nil {
+				return t.Elem
+			}
+			return JoinTypes(t.Items...)
+		case TypeDict:
+			return t.Key
+		}
+	}
+	if elem := protocolElementType(t, async); elem != nil {
+		return elem
+	}
+	if sym := builtinOperand(t); sym != nil && !async {
+		switch sym.Name {
+		case "str":
+			return BuiltinType(sym)
+		case "bytes", "bytearray", "range":
+			return BuiltinType(BuiltinSymbol("int"))
+		}
+		return nil
+	}
+
+	iterName, nextName := "__iter__", "__next__"
+	if async {
+		iterName, nextName = "__aiter__", "__anext__"
+	}
+	iterator, ok := operatorMethodType(t, iterName, nil)
+	if !ok || IsUnknownType(iterator) {
+		return nil
+	}
+	if elem := protocolElementType(iterator, async); elem != nil {
+		return elem
+	}
+	next, ok := operatorMethodType(iterator, nextName, nil)
+	if !ok {
+		return nil
+	}
+	if async {
+		return awaitedType(next)
+	}
+	return next
+}
+
+// protocolElementType returns the element type of a parameterized iterable
+// protocol such as Iterator[T], or nil for any other type.
+func protocolElementType(t *Type, async bool) *Type {
+	if t.Kind != TypeInstance || t.Symbol == nil || len(t.Args) == 0 {
+		return nil
+	}
+	name := t.Symbol.Name
+	if !parameterizedProtocols[name] || asyncProtocols[name] != async {
+		return nil
+	}
+	switch name {
+	case "Awaitable", "Coroutine":
+		return nil
+	}
+	return t.Args[0]
+}
+
+// contextManagerClasses are the generic context managers whose type
+// argument is what entering them binds.
+var contextManagerClasses = map[string]bool{
+	"ContextManager":                true,
+	"AbstractContextManager":        true,
+	"AsyncContextManager":           true,
+	"AbstractAsyncContextManager":   true,
+	"_GeneratorContextManager":      true,
+	"_AsyncGeneratorContextManager": true,
+}
+
+// contextManagerType returns the type bound by `with t as target`: the
+// result of __enter__, or the awaited result of __aenter__ for async with.
+func contextManagerType(t *Type, async bool) *Type {
+	if IsUnknownType(t) {
+		return nil
+	}
+	if t.Kind == TypeInstance && t.Symbol != nil && len(t.Args) > 0 && contextManagerClasses[t.Symbol.Name] {
+		return t.Args[0]
+	}
+	name := "__enter__"
+	if async {
+		name = "__aenter__"
+	}
+	typ, ok := operatorMethodType(t, name, nil)
+	if !ok {
+		return nil
+	}
+	if async {
+		return awaitedType(typ)
+	}
+	return typ
+}
+
+// awaitedType returns the result of awaiting t. Awaitable[T] and
+// Coroutine[Y, S, T] unwrap to T; other types are taken to be the already
+// awaited result of an async def.
+func awaitedType(t *Type) *Type {
+	if IsUnknownType(t) {
+		return nil
+	}
+	if t.Kind == TypeInstance && t.Symbol != nil && len(t.Args) > 0 {
+		switch t.Symbol.Name {
+		case "Awaitable":
+			return t.Args[0]
+		case "Coroutine":
+			return t.Args[len(t.Args)-1]
+		}
+	}
+	if typ, ok := operatorMethodType(t, "__await__", nil); ok && !IsUnknownType(typ) {
+		if typ.Kind == TypeInstance && typ.Symbol != nil && typ.Symbol.Name == "Generator" && len(typ.Args) == 3 {
+			return typ.Args[2]
+		}
+	}
+	return t
+}
+
+// unpackedTypes returns the types bound to count unpacking targets from a
+// value of type t, where the target at star (or -1) is starred and collects
+// the remaining items into a list. A fixed-length tuple is distributed item
+// by item; other iterables bind their element type to every target. The
+// length is that of the fixed tuple, or -1 when it is not known.
+func unpackedTypes(t *Type, count, star int) ([]*Type, int) {
+	types := make([]*Type, count)
+	if IsUnknownType(t) {
+		return types, -1
+	}
+	if t.Kind == TypeUnion {
+		for _, arm := range t.Union {
+			armTypes, _ := unpackedTypes(arm, count, star)
+			for i := range types {
+				types[i] = JoinTypes(types[i], armTypes[i])
+			}
+		}
+		return types, -1
+	}
+	if IsFixedTuple(t) {
+		n := len(t.Items)
+		if !unpackArityMatches(n, count, star) {
+			return types, n
+		}
+		for i := range types {
+			switch {
+			case star < 0 || i < star:
+				types[i] = t.Items[i]
+			case i == star:
+				types[i] = ListType(JoinTypes(t.Items[star : n-(count-star-1)]...))
+			default:
+				types[i] = t.Items[n-(count-i)]
+			}
+		}
+		return types, n
+	}
+	elem := iterElementType(t, false)
+	for i := range types {
+		types[i] = elem
+		if i == star {
+			types[i] = ListType(elem)
+		}
+	}
+	return types, -1
+}
+
+// unpackArityMatches reports whether n values can be unpacked into count
+// targets, one of which is starred when star is not -1.
+func unpackArityMatches(n, count, star int) bool {
+	if star < 0 {
+		return n == count
+	}
+	return n >= count-1
+}
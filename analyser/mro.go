@@ -0,0 +1,161 @@
+package analyser
+
+import "strings"
+
+// MRO returns the C3 linearization of cls: the class itself followed by its
+// ancestors in method resolution order. When the bases admit no consistent
+// order it falls back to a depth-first walk so lookups still find members.
+func MRO(cls *Symbol) []*Symbol {
+	if cls == nil {
+		return nil
+	}
+	if cls.ancestors != nil {
+		return append([]*Symbol{cls}, cls.ancestors...)
+	}
+	mro, _ := linearize(cls, map[*Symbol]bool{})
+	return mro
+}
+
+// linearize computes the C3 linearization of cls. The boolean is false when
+// the bases of cls itself cannot be merged consistently; inconsistencies
+// further up the hierarchy are reported on the classes that introduce them.
+func linearize(cls *Symbol, visiting map[*Symbol]bool) ([]*Symbol, bool) {
+	if visiting[cls] {
+		return []*Symbol{cls}, false
+	}
+	visiting[cls] = true
+	defer delete(visiting, cls)
+
+	var seqs [][]*Symbol
+	var bases []*Symbol
+	for _, base := range cls.Bases {
+		if base == nil {
+			continue
+		}
+		bases = append(bases, base)
+		if base.ancestors != nil {
+			seqs = append(seqs, append([]*Symbol{base}, base.ancestors...))
+			continue
+		}
+		baseMRO, _ := linearize(base, visiting)
+		seqs = append(seqs, baseMRO)
+	}
+	seqs = append(seqs, bases)
+
+	result := []*Symbol{cls}
+	for {
+		nonEmpty := seqs[:0]
+		for _, seq := range seqs {
+			if len(seq) > 0 {
+				nonEmpty = append(nonEmpty, seq)
+			}
+		}
+		seqs = nonEmpty
+		if len(seqs) == 0 {
+			return result, true
+		}
+
+		var head *Symbol
+		for _, seq := range seqs {
+			if !inAnyTail(seq[0], seqs) {
+				head = seq[0]
+				break
+			}
+		}
+		if head == nil {
+			return depthFirstMRO(cls), false
+		}
+		result = append(result, head)
+		for i, seq := range seqs {
+			if seq[0] == head {
+				seqs[i] = seq[1:]
+			}
+		}
+	}
+}
+
+func inAnyTail(sym *Symbol, seqs [][]*Symbol) bool {
+	for _, seq := range seqs {
+		for _, other := range seq[1:] {
+			if other == sym {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func depthFirstMRO(cls *Symbol) []*Symbol {
+	var result []*Symbol
+	seen := make(map[*Symbol]bool)
+	var walk func(*Symbol)
+	walk = func(c *Symbol) {
+		if c == nil || seen[c] {
+			return
+		}
+		seen[c] = true
+		result = append(result, c)
+		for _, base := range c.Bases {
+			walk(base)
+		}
+	}
+	walk(cls)
+	return result
+}
+
+// DefinesMember reports whether name is defined by cls itself rather than
+// inherited. Classes known only through a flattened member scope (builtins
+// and introspected modules) are treated as defining all of their members.
+func DefinesMember(cls *Symbol, name string) bool {
+	if cls == nil {
+		return false
+	}
+	if cls.Inner == nil && cls.Attrs == nil {
+		if cls.Members == nil {
+			return false
+		}
+		_, ok := cls.Members.Symbols[name]
+		return ok
+	}
+	for _, scope := range []*Scope{cls.Inner, cls.Attrs} {
+		if scope == nil {
+			continue
+		}
+		if _, ok := scope.Symbols[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ownMembers calls fn for every member cls defines itself, in the sense of
+// DefinesMember. Instance attributes come first, as they shadow methods of
+// the same name once promoted.
+func ownMembers(cls *Symbol, fn func(name string, sym *Symbol)) {
+	if cls.Inner == nil && cls.Attrs == nil {
+		if cls.Members != nil {
+			for name, sym := range cls.Members.Symbols {
+				fn(name, sym)
+			}
+		}
+		return
+	}
+	for _, scope := range []*Scope{cls.Attrs, cls.Inner} {
+		if scope == nil {
+			continue
+		}
+		for name, sym := range scope.Symbols {
+			fn(name, sym)
+		}
+	}
+}
+
+func baseNames(bases []*Symbol) string {
+	names := make([]string, 0, len(bases))
+	for _, base := range bases {
+		if base != nil {
+			names = append(names, base.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,286 @@
+package analyser
+
+import ast "rahu/parser/ast"
+
+// binaryDunders maps a binary operator to its method, reflected method and
+// in-place method names.
+var binaryDunders = map[ast.Operator][3]string{
+	ast.Add:      {"__add__", "__radd__", "__iadd__"},
+	ast.Sub:      {"__sub__", "__rsub__", "__isub__"},
+	ast.Mult:     {"__mul__", "__rmul__", "__imul__"},
+	ast.Div:      {"__truediv__", "__rtruediv__", "__itruediv__"},
+	ast.FloorDiv: {"__floordiv__", "__rfloordiv__", "__ifloordiv__"},
+	ast.Mod:      {"__mod__", "__rmod__", "__imod__"},
+	ast.BitOr:    {"__or__", "__ror__", "__ior__"},
+	ast.Pow:      {"__pow__", "__rpow__", "__ipow__"},
+}
+
+// augAssignOperators maps the augmented assignments the parser produces to
+// their binary operator.
+var augAssignOperators = map[ast.AugAssignOp]ast.Operator{
+	ast.AugAdd:      ast.Add,
+	ast.AugSub:      ast.Sub,
+	ast.AugMul:      ast.Mult,
+	ast.AugDiv:      ast.Div,
+	ast.AugFloorDiv: ast.FloorDiv,
+	ast.AugMod:      ast.Mod,
+	ast.AugOr:       ast.BitOr,
+	ast.AugPow:      ast.Pow,
+}
+
+// compareDunders maps a rich comparison to its method and the method tried
+// on the right operand when the left one does not apply.
+var compareDunders = map[ast.CompareOp][2]string{
+	ast.Eq:    {"__eq__", "__eq__"},
+	ast.NotEq: {"__ne__", "__ne__"},
+	ast.Lt:    {"__lt__", "__gt__"},
+	ast.LtE:   {"__le__", "__ge__"},
+	ast.Gt:    {"__gt__", "__lt__"},
+	ast.GtE:   {"__ge__", "__le__"},
+}
+
+var unaryDunders = map[ast.UnaryOperator]string{
+	ast.UAdd: "__pos__",
+	ast.USub: "__neg__",
+}
+
+// numericRanks orders the builtin numeric tower for arithmetic promotion.
+var numericRanks = map[string]int{"bool": 0, "int": 1, "float": 2, "complex": 3}
+
+var numericByRank = []string{"bool", "int", "float", "complex"}
+
+// binaryOpType infers the result of left <op> right. Builtin operands use
+// Python's numeric promotion rules, members of a Flag enumeration combine
+// into an instance of the flag, and other operands dispatch through
+// __op__ and the reflected __rop__, the latter first when the right operand
+// is a subclass of the left overriding it.
+func binaryOpType(op ast.Operator, left, right *Type) *Type {
+	if IsUnknownType(left) || IsUnknownType(right) {
+		return nil
+	}
+	if typ := builtinBinaryOpType(op, left, right); typ != nil {
+		return typ
+	}
+	if typ := flagOpType(op, left, right); typ != nil {
+		return typ
+	}
+	names, ok := binaryDunders[op]
+	if !ok {
+		return nil
+	}
+	return dispatchOperator(left, right, names[0], names[1])
+}
+
+// augAssignType infers the value of target <op>= value, trying the in-place
+// method before the binary operator.
+func augAssignType(op ast.AugAssignOp, target, value *Type) *Type {
+	binOp, ok := augAssignOperators[op]
+	if !ok || IsUnknownType(target) || IsUnknownType(value) {
+		return nil
+	}
+	if builtinOperand(target) == nil {
+		if typ, found := operatorMethodType(target, binaryDunders[binOp][2], value); found {
+			return typ
+		}
+	}
+	return binaryOpType(binOp, target, value)
+}
+
+// compareOpType infers the result of a single comparison. Builtin and
+// membership comparisons are bool; rich comparisons on other classes return
+// whatever their dunder is annotated with.
+func compareOpType(op ast.CompareOp, left, right *Type) *Type {
+	boolType := BuiltinType(BuiltinSymbol("bool"))
+	names, ok := compareDunders[op]
+	if !ok || IsUnknownType(left) || IsUnknownType(right) {
+		return boolType
+	}
+	if builtinOperand(left) != nil && builtinOperand(right) != nil {
+		return boolType
+	}
+	if typ := dispatchOperator(left, right, names[0], names[1]); typ != nil {
+		return typ
+	}
+	return boolType
+}
+
+// unaryOpType infers the result of a unary operator applied to operand.
+func unaryOpType(op ast.UnaryOperator, operand *Type) *Type {
+	if op == ast.Not {
+		return BuiltinType(BuiltinSymbol("bool"))
+	}
+	name, ok := unaryDunders[op]
+	if !ok || IsUnknownType(operand) {
+		return nil
+	}
+	if sym := builtinOperand(operand); sym != nil {
+		rank, numeric := numericRanks[sym.Name]
+		if !numeric {
+			return nil
+		}
+		return numericType(max(rank, numericRanks["int"]))
+	}
+	typ, _ := operatorMethodType(operand, name, nil)
+	return typ
+}
+
+// dispatchOperator applies the forward method of left, falling back to the
+// reflected method of right. A right operand whose class is a proper
+// subclass of the left's and defines the reflected method is tried first.
+func dispatchOperator(left, right *Type, forward, reflected string) *Type {
+	leftCls, rightCls := nominalClass(left), nominalClass(right)
+	if leftCls != nil && rightCls != nil && leftCls != rightCls && isSubclassOf(rightCls, leftCls, map[*Symbol]bool{}) {
+		if typ, found := operatorMethodType(right, reflected, left); found {
+			return typ
+		}
+	}
+	if typ, found := operatorMethodType(left, forward, right); found {
+		return typ
+	}
+	if typ, found := operatorMethodType(right, reflected, left); found {
+		return typ
+	}
+	return nil
+}
+
+// operatorMethodType looks up an operator method on recv and reports its
+// return type for the given argument. The boolean is false when recv has no
+// such method or none of its signatures accepts arg.
+func operatorMethodType(recv *Type, name string, arg *Type) (*Type, bool) {
+	fn, ok := LookupMemberOnType(recv, name)
+	if !ok || fn == nil || fn.Kind != SymFunction {
+		return nil, false
+	}
+	var args CallArgs
+	if arg != nil {
+		args.Positional = []*Type{arg}
+	}
+	sig := SelectOverload(fn, true, args)
+	if sig == nil {
+		return nil, false
+	}
+	return BoundReturnType(sig, recv), true
+}
+
+// BoundReturnType returns the result of calling fn through a receiver of
+// type recv, substituting the receiver for a typing.Self return annotation.
+func BoundReturnType(fn *Symbol, recv *Type) *Type {
+	if fn == nil {
+		return nil
+	}
+	if fn.Returns != nil && fn.Returns.Kind == TypeVariable {
+		// Type variables are only solved for decorators.
+		return nil
+	}
+	if fn.ReturnsSelf && !IsUnknownType(recv) && (recv.Kind == TypeInstance || recv.Kind == TypeClass) {
+		return InstanceType(recv.Symbol)
+	}
+	return fn.Returns
+}
+
+// nominalClass returns the class a type is an instance of, or nil for
+// builtin and structural types.
+func nominalClass(t *Type) *Symbol {
+	if t.Kind != TypeInstance || t.Symbol == nil || isBuiltinSymbol(t.Symbol) {
+		return nil
+	}
+	return t.Symbol
+}
+
+// builtinOperand returns the builtin class of a literal-like operand type,
+// or nil when the operand is a user-defined class.
+func builtinOperand(t *Type) *Symbol {
+	switch t.Kind {
+	case TypeBuiltin, TypeInstance, TypeList, TypeTuple, TypeDict, TypeSet:
+	default:
+		return nil
+	}
+	sym := nominalSymbol(t)
+	if sym == nil || !isBuiltinSymbol(sym) {
+		return nil
+	}
+	return sym
+}
+
+func numericType(rank int) *Type {
+	return BuiltinType(BuiltinSymbol(numericByRank[rank]))
+}
+
+// builtinBinaryOpType covers arithmetic between builtin operands, whose
+// dunder methods carry no return annotations.
+func builtinBinaryOpType(op ast.Operator, left, right *Type) *Type {
+	leftSym, rightSym := builtinOperand(left), builtinOperand(right)
+	if leftSym == nil || rightSym == nil {
+		return nil
+	}
+
+	leftRank, leftNumeric := numericRanks[leftSym.Name]
+	rightRank, rightNumeric := numericRanks[rightSym.Name]
+	if leftNumeric && rightNumeric {
+		rank := max(leftRank, rightRank)
+		switch op {
+		case ast.BitOr:
+			if rank > numericRanks["int"] {
+				return nil
+			}
+			return numericType(rank)
+		case ast.Div:
+			return numericType(max(rank, numericRanks["float"]))
+		}
+		return numericType(max(rank, numericRanks["int"]))
+	}
+
+	isInt := func(name string) bool { return name == "int" || name == "bool" }
+	switch leftSym.Name {
+	case "str", "bytes":
+		switch {
+		case op == ast.Add && rightSym.Name == leftSym.Name,
+			op == ast.Mult && isInt(rightSym.Name),
+			op == ast.Mod:
+			return BuiltinType(leftSym)
+		}
+	case "list":
+		if op == ast.Add && left.Kind == TypeList && right.Kind == TypeList {
+			return ListType(JoinTypes(left.Elem, right.Elem))
+		}
+		if op == ast.Mult && isInt(rightSym.Name) {
+			return left
+		}
+	case "tuple":
+		if op == ast.Add && left.Kind == TypeTuple && right.Kind == TypeTuple {
+			if left.Elem != nil || right.Elem != nil {
+				return VarTupleType(JoinTypes(iterElementType(left, false), iterElementType(right, false)))
+			}
+			items := append(append([]*Type(nil), left.Items...), right.Items...)
+			return TupleType(items...)
+		}
+	case "dict":
+		if op == ast.BitOr && left.Kind == TypeDict && right.Kind == TypeDict {
+			return DictType(JoinTypes(left.Key, right.Key), JoinTypes(left.Elem, right.Elem))
+		}
+	case "set":
+		if op == ast.BitOr && left.Kind == TypeSet && right.Kind == TypeSet {
+			return SetType(JoinTypes(left.Elem, right.Elem))
+		}
+		if op == ast.Sub && left.Kind == TypeSet {
+			return left
+		}
+	case "int", "bool":
+		if op == ast.Mult {
+			switch rightSym.Name {
+			case "str", "bytes", "list":
+				return right
+			}
+		}
+	}
+	return nil
+}
+
+// isSelfAnnotation reports whether a return annotation is typing.Self.
+func isSelfAnnotation(tree *ast.AST, expr ast.NodeID) bool {
+	switch exprDottedName(tree, expr) {
+	case "Self", "typing.Self", "typing_extensions.Self":
+		return true
+	}
+	return false
+}
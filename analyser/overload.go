@@ -0,0 +1,282 @@
+package analyser
+
+import (
+	"sort"
+
+	ast "rahu/parser/ast"
+)
+
+// isOverloadDecorated reports whether a def carries @overload.
+func isOverloadDecorated(tree *ast.AST, stmt ast.NodeID) bool {
+	for _, decorator := range tree.Decorators(stmt) {
+		switch exprDottedName(tree, tree.DecoratorExpr(decorator)) {
+		case "overload", "typing.overload", "typing_extensions.overload":
+			return true
+		}
+	}
+	return false
+}
+
+// OrderedParams returns the parameters of a function in declaration order.
+// A signature replaced by a decorator or synthesized for a class takes
+// precedence over the parameters of the def.
+func OrderedParams(sym *Symbol) []*Symbol {
+	if sym == nil {
+		return nil
+	}
+	if sym.Params != nil {
+		return sym.Params
+	}
+	return declaredParams(sym)
+}
+
+// declaredParams returns the parameters of a def in declaration order.
+func declaredParams(sym *Symbol) []*Symbol {
+	if sym.Inner == nil {
+		return nil
+	}
+	params := make([]*Symbol, 0, len(sym.Inner.Symbols))
+	for _, inner := range sym.Inner.Symbols {
+		if inner != nil && inner.Kind == SymParameter {
+			params = append(params, inner)
+		}
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].Span.Start != params[j].Span.Start {
+			return params[i].Span.Start < params[j].Span.Start
+		}
+		return params[i].Name < params[j].Name
+	})
+	return params
+}
+
+// Signatures returns every callable signature of a function: its @overload
+// variants when it has any, otherwise the function itself.
+func Signatures(sym *Symbol) []*Symbol {
+	if sym == nil {
+		return nil
+	}
+	if len(sym.Overloads) > 0 {
+		return sym.Overloads
+	}
+	return []*Symbol{sym}
+}
+
+// CallArgs describes the arguments supplied at a call site. Types may be nil
+// when unknown; Partial is set while the call is still being typed, so
+// missing required parameters are not held against a signature.
+type CallArgs struct {
+	Positional   []*Type
+	Keywords     []string
+	KeywordTypes []*Type
+	Starred      bool
+	Partial      bool
+}
+
+// AcceptsCall reports whether fn can be called with args. When bound is set
+// the first parameter is treated as already supplied (self or cls).
+func AcceptsCall(fn *Symbol, bound bool, args CallArgs) bool {
+	params := OrderedParams(fn)
+	if bound && len(params) > 0 && !params[0].IsVarArg && !params[0].IsKwArg {
+		params = params[1:]
+	}
+
+	var positional, required []*Symbol
+	var varArg, kwArg *Symbol
+	byName := make(map[string]*Symbol, len(params))
+	for _, param := range params {
+		switch {
+		case param.IsKwArg:
+			kwArg = param
+		case param.IsVarArg:
+			varArg = param
+		default:
+			if !param.IsPosOnly {
+				byName[param.Name] = param
+			}
+			if varArg == nil && !param.IsKwOnly {
+				positional = append(positional, param)
+			}
+			if param.DefaultValue == "" {
+				required = append(required, param)
+			}
+		}
+	}
+
+	supplied := make(map[*Symbol]bool, len(params))
+	for i, argType := range args.Positional {
+		if i >= len(positional) {
+			if varArg == nil && !args.Starred {
+				return false
+			}
+			continue
+		}
+		if !typeAccepts(positional[i].Inferred, argType) {
+			return false
+		}
+		supplied[positional[i]] = true
+	}
+	for i, name := range args.Keywords {
+		param := byName[name]
+		if param == nil {
+			if kwArg == nil {
+				return false
+			}
+			continue
+		}
+		if supplied[param] {
+			return false
+		}
+		if i < len(args.KeywordTypes) && !typeAccepts(param.Inferred, args.KeywordTypes[i]) {
+			return false
+		}
+		supplied[param] = true
+	}
+
+	if args.Partial || args.Starred {
+		return true
+	}
+	for _, param := range required {
+		if !supplied[param] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsBoundMethod reports whether calling fn through a receiver of type recv
+// supplies its first parameter implicitly.
+func IsBoundMethod(fn *Symbol, recv *Type) bool {
+	if fn == nil || fn.Scope == nil {
+		return false
+	}
+	if fn.Scope.Kind != ScopeClass && fn.Scope.Kind != ScopeMember {
+		return false
+	}
+	switch fn.Method {
+	case MethodStaticMethod:
+		return false
+	case MethodClassMethod:
+		return true
+	}
+	return recv == nil || recv.Kind != TypeClass
+}
+
+// SelectOverload returns the first signature of fn accepting args, or nil
+// when none does.
+func SelectOverload(fn *Symbol, bound bool, args CallArgs) *Symbol {
+	for _, sig := range Signatures(fn) {
+		if AcceptsCall(sig, bound, args) {
+			return sig
+		}
+	}
+	return nil
+}
+
+// typeAccepts is a compatibility check between an annotated parameter type
+// and an inferred argument type: nominal for classes, structural for
+// protocols. Unknown types are accepted.
+func typeAccepts(param, arg *Type) bool {
+	return acceptsType(param, arg, 0)
+}
+
+// acceptsType implements typeAccepts, depth counting the protocols whose
+// members are being compared.
+func acceptsType(param, arg *Type, depth int) bool {
+	if IsUnknownType(param) || IsUnknownType(arg) {
+		return true
+	}
+	if param.Kind == TypeVariable || param.Kind == TypeCallable || arg.Kind == TypeVariable {
+		return true
+	}
+	if param.Kind == TypeUnion {
+		for _, member := range param.Union {
+			if acceptsType(member, arg, depth) {
+				return true
+			}
+		}
+		return false
+	}
+	if arg.Kind == TypeUnion {
+		for _, member := range arg.Union {
+			if !acceptsType(param, member, depth) {
+				return false
+			}
+		}
+		return true
+	}
+	if (param.Kind == TypeClass) != (arg.Kind == TypeClass) {
+		return false
+	}
+	if param.Kind == TypeModule || arg.Kind == TypeModule {
+		return param.Kind == arg.Kind
+	}
+
+	want := nominalSymbol(param)
+	have := nominalSymbol(arg)
+	if want == nil || have == nil {
+		return true
+	}
+	if want.Name == "object" && isBuiltinSymbol(want) {
+		return true
+	}
+	if want.Protocol && param.Kind == TypeInstance {
+		if depth >= maxProtocolDepth {
+			return true
+		}
+		missing, incompatible := protocolMismatch(arg, want, depth+1)
+		return len(missing) == 0 && len(incompatible) == 0
+	}
+	if isBuiltinSymbol(want) && isBuiltinSymbol(have) {
+		switch want.Name {
+		case "int":
+			if have.Name == "bool" {
+				return true
+			}
+		case "float":
+			if have.Name == "int" {
+				return true
+			}
+		case "complex":
+			if have.Name == "int" || have.Name == "float" {
+				return true
+			}
+		}
+	}
+	return isSubclassOf(have, want, map[*Symbol]bool{})
+}
+
+// nominalSymbol returns the class symbol a type is an instance of.
+func nominalSymbol(t *Type) *Symbol {
+	switch t.Kind {
+	case TypeList:
+		return BuiltinSymbol("list")
+	case TypeTuple:
+		return BuiltinSymbol("tuple")
+	case TypeDict:
+		return BuiltinSymbol("dict")
+	case TypeSet:
+		return BuiltinSymbol("set")
+	}
+	return t.Symbol
+}
+
+func isBuiltinSymbol(sym *Symbol) bool {
+	return sym.Scope != nil && sym.Scope.Kind == ScopeBuiltin
+}
+
+func isSubclassOf(sym, target *Symbol, seen map[*Symbol]bool) bool {
+	if sym == nil || seen[sym] {
+		return false
+	}
+	if sym == target || (sym.Name == target.Name && isBuiltinSymbol(sym) && isBuiltinSymbol(target)) {
+		return true
+	}
+	seen[sym] = true
+	for _, base := range sym.Bases {
+		if isSubclassOf(base, target, seen) {
+			return true
+		}
+	}
+	return false
+}
@@ -38,6 +38,7 @@ func promoteOneClass(cls *Symbol) {
 	if cls.Members != nil {
 		maps.Copy(fresh.Symbols, cls.Members.Symbols)
 	}
+	fresh.Owner = cls
 	cls.Members = fresh
 
 	// 1. Methods
@@ -63,21 +64,19 @@ func promoteOneClass(cls *Symbol) {
 		}
 	}
 
-	// 3. Base classes — do NOT reassign sym.Scope here. The inherited symbol
-	// belongs to the base; overwriting its Scope with the child's Members
-	// scope is a race when the symbol is shared, and classOwner() on a
-	// Members scope (nil Parent) returns nil anyway, so the assignment was
-	// a no-op for all callers.
-	for _, base := range cls.Bases {
-		if base == nil || base.Members == nil {
-			continue
-		}
-
-		for name, sym := range base.Members.Symbols {
+	// 3. Base classes, in C3 order — do NOT reassign sym.Scope here. The
+	// inherited symbol belongs to the base; overwriting its Scope with the
+	// child's Members scope is a race when the symbol is shared, and
+	// classOwner() on a Members scope (nil Parent) returns nil anyway, so the
+	// assignment was a no-op for all callers.
+	mro, _ := linearize(cls, map[*Symbol]bool{})
+	cls.ancestors = mro[1:]
+	for _, base := range cls.ancestors {
+		ownMembers(base, func(name string, sym *Symbol) {
 			// Do not override child definitions
 			if _, exists := cls.Members.Symbols[name]; !exists {
 				cls.Members.Symbols[name] = sym
 			}
-		}
+		})
 	}
 }
@@ -0,0 +1,307 @@
+package analyser
+
+import (
+	"sort"
+	"strings"
+
+	ast "rahu/parser/ast"
+)
+
+// protocolBases are the special forms that make a class a protocol when
+// listed among its bases, bare or subscripted with type variables.
+var protocolBases = map[string]bool{
+	"Protocol": true, "typing.Protocol": true, "typing_extensions.Protocol": true,
+}
+
+var runtimeCheckableDecorators = map[string]bool{
+	"runtime_checkable": true, "typing.runtime_checkable": true, "typing_extensions.runtime_checkable": true,
+}
+
+// protocolExemptMembers may appear in a protocol body without becoming part
+// of the interface implementations have to provide.
+var protocolExemptMembers = map[string]bool{
+	"__init__":          true,
+	"__slots__":         true,
+	"__doc__":           true,
+	"__module__":        true,
+	"__class_getitem__": true,
+	"__init_subclass__": true,
+}
+
+// maxProtocolDepth bounds how far member types are compared structurally,
+// as protocols commonly refer to themselves or to each other.
+const maxProtocolDepth = 3
+
+// isProtocolBase reports whether a base class expression is typing.Protocol
+// or Protocol[...]. A user class that happens to be named Protocol is an
+// ordinary base.
+func (r *Resolver) isProtocolBase(expr ast.NodeID) bool {
+	if r.tree.Node(expr).Kind == ast.NodeSubScript {
+		expr = r.tree.ChildAt(expr, 0)
+	}
+	if !protocolBases[exprDottedName(r.tree, expr)] {
+		return false
+	}
+	sym := r.calleeSymbol(expr)
+	return sym == nil || sym.Kind != SymClass
+}
+
+func hasRuntimeCheckableDecorator(tree *ast.AST, stmt ast.NodeID) bool {
+	for _, decorator := range tree.Decorators(stmt) {
+		if runtimeCheckableDecorators[exprDottedName(tree, tree.DecoratorExpr(decorator))] {
+			return true
+		}
+	}
+	return false
+}
+
+// protocolClass returns the protocol t is an instance of, or nil.
+func protocolClass(t *Type) *Symbol {
+	if IsUnknownType(t) || t.Kind != TypeInstance || t.Symbol == nil || !t.Symbol.Protocol {
+		return nil
+	}
+	return t.Symbol
+}
+
+// OwnerClass returns the class whose body defines sym, or nil when sym is
+// not a class member.
+func OwnerClass(sym *Symbol) *Symbol {
+	if sym == nil || sym.Scope == nil {
+		return nil
+	}
+	switch sym.Scope.Kind {
+	case ScopeClass, ScopeMember:
+		return sym.Scope.Owner
+	}
+	return nil
+}
+
+// ProtocolMembers returns the sorted names of the members a class has to
+// provide to satisfy protocol, including those of its protocol bases.
+func ProtocolMembers(protocol *Symbol) []string {
+	if protocol == nil || !protocol.Protocol {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, cls := range MRO(protocol) {
+		if !cls.Protocol {
+			continue
+		}
+		ownMembers(cls, func(name string, sym *Symbol) {
+			if seen[name] || protocolExemptMembers[name] || sym == nil || sym.Kind == SymImport {
+				return
+			}
+			seen[name] = true
+			names = append(names, name)
+		})
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProtocolMismatch compares t structurally against protocol. It returns the
+// protocol members t lacks and those it declares with an incompatible type.
+// Both are empty when t satisfies protocol, including when t explicitly
+// subclasses it or its members cannot be enumerated.
+func ProtocolMismatch(t *Type, protocol *Symbol) (missing, incompatible []string) {
+	return protocolMismatch(t, protocol, 0)
+}
+
+// SatisfiesProtocol reports whether a value of type t can be used where an
+// instance of protocol is expected. Unknown types satisfy every protocol.
+func SatisfiesProtocol(t *Type, protocol *Symbol) bool {
+	missing, incompatible := ProtocolMismatch(t, protocol)
+	return len(missing) == 0 && len(incompatible) == 0
+}
+
+// ImplementsProtocol reports whether instances of cls are known to satisfy
+// protocol, either nominally or because cls provides every protocol member.
+// Unlike SatisfiesProtocol it is false when cls cannot be examined.
+func ImplementsProtocol(cls, protocol *Symbol) bool {
+	if cls == nil || protocol == nil || cls == protocol || !protocol.Protocol {
+		return false
+	}
+	if isSubclassOf(cls, protocol, map[*Symbol]bool{}) {
+		return true
+	}
+	if cls.DynamicMembers || len(ProtocolMembers(protocol)) == 0 {
+		return false
+	}
+	return SatisfiesProtocol(InstanceType(cls), protocol)
+}
+
+func protocolMismatch(t *Type, protocol *Symbol, depth int) (missing, incompatible []string) {
+	if IsUnknownType(t) || protocol == nil || !protocol.Protocol {
+		return nil, nil
+	}
+	if t.Kind == TypeUnion {
+		for _, arm := range t.Union {
+			armMissing, armIncompatible := protocolMismatch(arm, protocol, depth)
+			missing = mergeNames(missing, armMissing)
+			incompatible = mergeNames(incompatible, armIncompatible)
+		}
+		return missing, incompatible
+	}
+	if sym := nominalSymbol(t); sym != nil {
+		if sym.DynamicMembers || isSubclassOf(sym, protocol, map[*Symbol]bool{}) {
+			return nil, nil
+		}
+	}
+	if MemberScopeForType(t) == nil {
+		return nil, nil
+	}
+
+	for _, name := range ProtocolMembers(protocol) {
+		want, _ := LookupMemberOnType(InstanceType(protocol), name)
+		have, ok := LookupMemberOnType(t, name)
+		if !ok || have == nil {
+			missing = append(missing, name)
+			continue
+		}
+		if !acceptsType(protocolMemberType(want, t), protocolMemberType(have, t), depth) || !acceptsCallsOf(want, have, t) {
+			incompatible = append(incompatible, name)
+		}
+	}
+	return missing, incompatible
+}
+
+// protocolMemberType is the type compared between a protocol member and its
+// implementation: what a method returns, or the type of an attribute.
+func protocolMemberType(sym *Symbol, recv *Type) *Type {
+	if sym == nil {
+		return nil
+	}
+	if sym.Kind == SymFunction {
+		return BoundReturnType(sym, recv)
+	}
+	return SymbolType(sym)
+}
+
+// acceptsCallsOf reports whether the method have accepts the arguments of
+// every call the protocol method want accepts, comparing how many positional
+// arguments each takes and the keyword-only arguments have requires. Methods
+// whose signatures are unknown are taken to be compatible.
+func acceptsCallsOf(want, have *Symbol, recv *Type) bool {
+	if want == nil || have == nil || want.Kind != SymFunction || have.Kind != SymFunction ||
+		want.Method == MethodProperty || have.Method == MethodProperty ||
+		want.Decorated || have.Decorated || len(want.Overloads) > 0 || len(have.Overloads) > 0 ||
+		!hasSignature(want) || !hasSignature(have) {
+		return true
+	}
+	wantParams := functionType(want, IsBoundMethod(want, recv)).Params
+	haveParams := functionType(have, IsBoundMethod(have, recv)).Params
+
+	wantPositional, wantVarArg, wantKwArg := 0, false, false
+	wantKeywords := make(map[string]bool)
+	for _, param := range wantParams {
+		switch {
+		case param.IsVarArg:
+			wantVarArg = true
+		case param.IsKwArg:
+			wantKwArg = true
+		default:
+			if !param.IsKwOnly {
+				wantPositional++
+			}
+			if !param.IsPosOnly {
+				wantKeywords[param.Name] = true
+			}
+		}
+	}
+
+	required, positional, varArg := 0, 0, false
+	for _, param := range haveParams {
+		switch {
+		case param.IsVarArg:
+			varArg = true
+		case param.IsKwArg:
+		case param.IsKwOnly:
+			if param.DefaultValue == "" && !wantKeywords[param.Name] && !wantKwArg {
+				return false
+			}
+		default:
+			positional++
+			if param.DefaultValue == "" {
+				required++
+			}
+		}
+	}
+	if required > wantPositional {
+		return false
+	}
+	if varArg {
+		return true
+	}
+	return !wantVarArg && positional >= wantPositional
+}
+
+func mergeNames(names, more []string) []string {
+	for _, name := range more {
+		if !containsName(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsName(names []string, name string) bool {
+	for _, existing := range names {
+		if existing == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProtocolValue reports a value of type have used where an instance of
+// the protocol annotated as want is expected but does not satisfy it. Only
+// instances of classes declared in Python source are checked; builtins are
+// left to the nominal checks.
+func (r *Resolver) checkProtocolValue(span ast.Range, want, have *Type) {
+	protocol := protocolClass(want)
+	if protocol == nil || IsUnknownType(have) || have.Kind != TypeInstance || have.Symbol == nil || isBuiltinSymbol(have.Symbol) {
+		return
+	}
+	missing, incompatible := ProtocolMismatch(have, protocol)
+	if len(missing) == 0 && len(incompatible) == 0 {
+		return
+	}
+	var reasons []string
+	if len(missing) > 0 {
+		reasons = append(reasons, "missing "+strings.Join(missing, ", "))
+	}
+	if len(incompatible) > 0 {
+		reasons = append(reasons, "incompatible "+strings.Join(incompatible, ", "))
+	}
+	r.error(span, have.Symbol.Name+" does not satisfy protocol "+protocol.Name+": "+strings.Join(reasons, "; "))
+}
+
+// narrowToProtocol returns the type isinstance(name, protocol) narrows the
+// variable name to: the arms of its current type that satisfy protocol, or
+// the protocol itself when none is known to.
+func (r *Resolver) narrowToProtocol(name string, protocol *Type) *Type {
+	sym, ok := r.current.Lookup(name)
+	if !ok || sym == nil {
+		return protocol
+	}
+	current := SymbolType(sym)
+	if IsUnknownType(current) {
+		return protocol
+	}
+	arms := []*Type{current}
+	if current.Kind == TypeUnion {
+		arms = current.Union
+	}
+	var kept []*Type
+	for _, arm := range arms {
+		if cls := nominalSymbol(arm); cls != nil && ImplementsProtocol(cls, protocol.Symbol) {
+			kept = append(kept, arm)
+		}
+	}
+	if len(kept) == 0 {
+		return protocol
+	}
+	return JoinTypes(kept...)
+}
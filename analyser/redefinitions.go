@@ -0,0 +1,209 @@
+package analyser
+
+import (
+	"sort"
+	"strings"
+
+	"rahu/parser/ast"
+)
+
+// definition is a name bound by a def, class or import statement.
+type definition struct {
+	stmt   ast.NodeID
+	nameID ast.NodeID
+	name   string
+}
+
+// RedefinitionDiagnostics warns about functions and classes that replace an
+// earlier definition of the same block which was never read in between, and
+// reports parameters and variables shadowing a builtin. Definitions in
+// different branches of an if or try, such as an ImportError fallback, are
+// alternatives rather than redefinitions, as are @overload signatures and
+// property accessors, whose decorators read the getter.
+func (r *Resolver) RedefinitionDiagnostics() []SemanticError {
+	var out []SemanticError
+	if r.tree.Root != ast.NoNode {
+		r.checkRedefinitions(r.tree.Root, &out)
+	}
+	return append(out, r.builtinShadowing()...)
+}
+
+// checkRedefinitions checks the statements of block and returns the names
+// they bind in the enclosing scope.
+func (r *Resolver) checkRedefinitions(block ast.NodeID, out *[]SemanticError) []string {
+	var bound []string
+	last := make(map[string]definition)
+	for stmt := r.tree.Node(block).FirstChild; stmt != ast.NoNode; stmt = r.tree.Node(stmt).NextSibling {
+		switch r.tree.Node(stmt).Kind {
+		case ast.NodeFunctionDef, ast.NodeClassDef:
+			var nameID, body ast.NodeID
+			if r.tree.Node(stmt).Kind == ast.NodeFunctionDef {
+				nameID, _, body = r.tree.FunctionParts(stmt)
+			} else {
+				nameID, _, body = r.tree.ClassParts(stmt)
+			}
+			name, ok := r.tree.NameText(nameID)
+			if !ok || name == "<incomplete>" {
+				continue
+			}
+			def := definition{stmt: stmt, nameID: nameID, name: name}
+			if prior, ok := last[name]; ok && r.redefinesUnused(prior, def) {
+				*out = append(*out, SemanticError{
+					Span:     r.tree.RangeOf(nameID),
+					Msg:      "redefinition of unused name: " + name,
+					Severity: SeverityWarning,
+					Related:  []RelatedSpan{{Span: r.tree.RangeOf(prior.nameID), Msg: "previous definition of " + name}},
+				})
+			}
+			last[name] = def
+			bound = append(bound, name)
+			r.checkRedefinitions(body, out)
+		case ast.NodeImport, ast.NodeFromImport:
+			for _, nameID := range importedNames(r.tree, stmt) {
+				name, _ := r.tree.NameText(nameID)
+				last[name] = definition{stmt: stmt, nameID: nameID, name: name}
+				bound = append(bound, name)
+			}
+		default:
+			// Assignments and the branches of compound statements rebind
+			// names without redefining them.
+			for _, name := range r.rebinds(stmt, out) {
+				delete(last, name)
+				bound = append(bound, name)
+			}
+		}
+	}
+	return bound
+}
+
+// rebinds returns the names a simple assignment or the blocks nested in a
+// compound statement bind, checking those blocks for redefinitions.
+func (r *Resolver) rebinds(stmt ast.NodeID, out *[]SemanticError) []string {
+	var names []string
+	switch r.tree.Node(stmt).Kind {
+	case ast.NodeAssign:
+		value := r.tree.Node(stmt).FirstChild
+		for target := r.tree.Node(value).NextSibling; target != ast.NoNode; target = r.tree.Node(target).NextSibling {
+			names = appendTargetNames(r.tree, names, target)
+		}
+		return names
+	case ast.NodeAnnAssign:
+		target, _, _ := r.tree.AnnAssignParts(stmt)
+		return appendTargetNames(r.tree, names, target)
+	}
+	for child := r.tree.Node(stmt).FirstChild; child != ast.NoNode; child = r.tree.Node(child).NextSibling {
+		if r.tree.Node(child).Kind == ast.NodeBlock {
+			names = append(names, r.checkRedefinitions(child, out)...)
+		} else {
+			names = append(names, r.rebinds(child, out)...)
+		}
+	}
+	return names
+}
+
+// appendTargetNames appends the names an assignment target binds.
+func appendTargetNames(tree *ast.AST, names []string, target ast.NodeID) []string {
+	switch tree.Node(target).Kind {
+	case ast.NodeName:
+		if name, ok := tree.NameText(target); ok {
+			names = append(names, name)
+		}
+	case ast.NodeTuple, ast.NodeList:
+		for elt := tree.Node(target).FirstChild; elt != ast.NoNode; elt = tree.Node(elt).NextSibling {
+			names = appendTargetNames(tree, names, elt)
+		}
+	}
+	return names
+}
+
+// redefinesUnused reports whether def replaces prior before anything reads
+// it. Reads are counted up to def's name, so a decorator such as
+// @prior.setter uses the earlier definition.
+func (r *Resolver) redefinesUnused(prior, def definition) bool {
+	if def.name == "_" || isOverloadDecorated(r.tree, prior.stmt) || isOverloadDecorated(r.tree, def.stmt) {
+		return false
+	}
+	candidates := []*Symbol{r.Resolved[prior.nameID], r.Resolved[def.nameID]}
+	for _, imp := range r.imports {
+		if imp.bound == prior.nameID {
+			candidates = append(candidates, imp.sym)
+		}
+	}
+	from, to := r.tree.RangeOf(prior.stmt).End, r.tree.RangeOf(def.nameID).Start
+	for _, sym := range candidates {
+		if sym == nil {
+			continue
+		}
+		for _, offset := range r.reads[sym] {
+			if offset >= from && offset < to {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// importedNames returns the name nodes an import statement binds.
+func importedNames(tree *ast.AST, stmt ast.NodeID) []ast.NodeID {
+	var aliases []ast.NodeID
+	fromImport := tree.Node(stmt).Kind == ast.NodeFromImport
+	if fromImport {
+		_, aliases = tree.FromImportParts(stmt)
+	} else {
+		aliases = tree.Children(stmt)
+	}
+	var names []ast.NodeID
+	for _, alias := range aliases {
+		target, asName := tree.AliasParts(alias)
+		bound := asName
+		if bound == ast.NoNode && fromImport {
+			bound = target
+		} else if bound == ast.NoNode {
+			bound = importBoundName(tree, target)
+		}
+		if name, ok := tree.NameText(bound); ok && name != "*" {
+			names = append(names, bound)
+		}
+	}
+	return names
+}
+
+// builtinShadowing reports module variables and the parameters and local
+// variables of functions named after a builtin. Class attributes such as
+// `id` or `type` are reached through the class and shadow nothing.
+func (r *Resolver) builtinShadowing() []SemanticError {
+	var out []SemanticError
+	report := func(scope *Scope) {
+		syms := make([]*Symbol, 0, len(scope.Symbols))
+		for _, sym := range scope.Symbols {
+			if sym.Scope == scope && (sym.Kind == SymVariable || sym.Kind == SymParameter) &&
+				!strings.HasPrefix(sym.Name, "__") && BuiltinSymbol(sym.Name) != nil {
+				syms = append(syms, sym)
+			}
+		}
+		sort.Slice(syms, func(i, j int) bool { return syms[i].Span.Start < syms[j].Span.Start })
+		for _, sym := range syms {
+			kind := "variable"
+			if sym.Kind == SymParameter {
+				kind = "parameter"
+			}
+			out = append(out, SemanticError{
+				Span:     sym.Span,
+				Msg:      kind + " " + sym.Name + " shadows a builtin",
+				Severity: SeverityInformation,
+			})
+		}
+	}
+
+	report(r.global)
+	for id := ast.NodeID(1); int(id) < len(r.tree.Nodes); id++ {
+		if r.tree.Node(id).Kind != ast.NodeFunctionDef {
+			continue
+		}
+		nameID, _, _ := r.tree.FunctionParts(id)
+		if fn := r.Resolved[nameID]; fn != nil && fn.Inner != nil && fn.Def == nameID {
+			report(fn.Inner)
+		}
+	}
+	return out
+}
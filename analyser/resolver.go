@@ -1,6 +1,7 @@
 package analyser
 
 import (
+	"fmt"
 	"strings"
 
 	"rahu/parser"
@@ -19,10 +20,12 @@ type PendingAttr struct {
 	Class     *Symbol
 	SelfName  string
 	ValueType *Type // inferred type from assignment RHS (nil if not an assignment target)
+	Write     bool  // assignment target rather than an unresolved read
 }
 
 type Resolver struct {
 	tree       *ast.AST
+	target     Target
 	current    *Scope
 	errors     []SemanticError
 	loopDepth  int
@@ -47,14 +50,47 @@ type Resolver struct {
 	// Inferred instance attributes for each class
 	// Maps class SymbolID to map of attribute name -> union type
 	classInstanceAttrs map[SymbolID]map[string]*Type
+
+	// Yields and returns of the function body being resolved
+	generator *generatorInfo
+
+	// Offsets at which each symbol is read, and the names imports bind,
+	// for reporting unused and redefined names
+	global  *Scope
+	reads   map[*Symbol][]uint32
+	imports []importBinding
 }
 
+// Severity grades a SemanticError. The zero value is an error.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInformation
+	SeverityHint
+)
+
 type SemanticError struct {
+	Span     ast.Range
+	Msg      string
+	Severity Severity
+	// Unnecessary asks editors to fade the span, e.g. for inactive code.
+	Unnecessary bool
+	// Related points at other spans of the module the error refers to.
+	Related []RelatedSpan
+	// Suggestion replaces the text of Span to fix a likely typo.
+	Suggestion string
+}
+
+// RelatedSpan is a location of the module relevant to a SemanticError, such
+// as the earlier definition a redefinition replaces.
+type RelatedSpan struct {
 	Span ast.Range
 	Msg  string
 }
 
-func newResolver(tree *ast.AST, global *Scope) *Resolver {
+func newResolver(tree *ast.AST, global *Scope, target Target) *Resolver {
 	resolvedCap := len(tree.Nodes) / 4
 	if resolvedCap < 8 {
 		resolvedCap = 8
@@ -69,6 +105,7 @@ func newResolver(tree *ast.AST, global *Scope) *Resolver {
 	}
 	return &Resolver{
 		tree:               tree,
+		target:             target,
 		current:            global,
 		errors:             nil,
 		loopDepth:          0,
@@ -81,11 +118,22 @@ func newResolver(tree *ast.AST, global *Scope) *Resolver {
 		stringAnnotCache:   make(map[string]*Type),
 		typeConstraints:    make(map[string]*Type),
 		classInstanceAttrs: make(map[SymbolID]map[string]*Type),
+		global:             global,
+		reads:              make(map[*Symbol][]uint32),
 	}
 }
 
+// Resolve resolves tree without a target interpreter, so that conditions on
+// sys.version_info and sys.platform leave both branches live.
 func Resolve(tree *ast.AST, global *Scope) (*Resolver, []SemanticError) {
-	r := newResolver(tree, global)
+	return ResolveForTarget(tree, global, Target{})
+}
+
+// ResolveForTarget resolves tree for the target interpreter, skipping the
+// if-branches it never runs. global must come from BuildScopesForTarget with
+// the same target.
+func ResolveForTarget(tree *ast.AST, global *Scope, target Target) (*Resolver, []SemanticError) {
+	r := newResolver(tree, global, target)
 	r.visitModule()
 	PromoteClassMembers(global)
 	r.BindMembers()
@@ -114,21 +162,64 @@ func (r *Resolver) visitStmt(stmt ast.NodeID) {
 		}
 		r.visitExpr(target, Read)
 		r.visitExpr(value, Read)
+		targetType := r.exprType(target)
 		r.visitExpr(target, Write)
 
+		op := ast.AugAssignOp(r.tree.Nodes[stmt].Data)
+		result := augAssignType(op, targetType, r.exprType(value))
+		if r.tree.Node(target).Kind == ast.NodeName && !IsUnknownType(result) {
+			if sym := r.Resolved[target]; sym != nil {
+				sym.Inferred = UnionType(sym.Inferred, result)
+				if result.Kind == TypeInstance && SameType(sym.Inferred, result) {
+					sym.InstanceOf = result.Symbol
+				} else {
+					sym.InstanceOf = nil
+				}
+				r.setExprType(target, result)
+			}
+		}
+
 	case ast.NodeAssign:
 		value := r.tree.Nodes[stmt].FirstChild
 		if value == ast.NoNode {
 			return
 		}
 
+		if target := r.tree.Nodes[value].NextSibling; r.tree.Node(target).Kind == ast.NodeName &&
+			r.tree.Nodes[target].NextSibling == ast.NoNode && r.isImplicitTypeAlias(value) {
+			r.visitExpr(target, Write)
+			if sym := r.Resolved[target]; sym != nil {
+				r.declareTypeAlias(sym, value)
+			}
+			return
+		}
+
 		r.visitExpr(value, Read)
 		valueType := r.ExprTypes[value]
 
+		typeVar := isTypeVarDeclaration(r.tree, value)
+		newType := isNewTypeDeclaration(r.tree, value)
+		unpackCount := r.tree.UnpackCount(stmt)
+		var unpacked []ast.NodeID
 		for target := r.tree.Nodes[value].NextSibling; target != ast.NoNode; target = r.tree.Nodes[target].NextSibling {
 			r.visitExpr(target, Write)
+			if len(unpacked) < unpackCount {
+				unpacked = append(unpacked, target)
+				continue
+			}
 
 			targetKind := r.tree.Node(target).Kind
+			if sym := r.Resolved[target]; targetKind == ast.NodeName && newType && sym != nil && sym.Kind == SymClass {
+				r.declareNewType(sym, value)
+				continue
+			}
+			if targetKind == ast.NodeName && typeVar {
+				if sym := r.Resolved[target]; sym != nil {
+					sym.Inferred = TypeVarType(sym)
+					sym.InstanceOf = nil
+				}
+				continue
+			}
 			if targetKind == ast.NodeName {
 				sym := r.Resolved[target]
 				if sym != nil {
@@ -149,45 +240,41 @@ func (r *Resolver) visitStmt(stmt ast.NodeID) {
 						r.setExprType(target, valueType)
 					}
 				}
-			} else if targetKind == ast.NodeAttribute && !IsUnknownType(valueType) {
-				// Attribute was just added to PendingAttrs by visitExpr
-				r.PendingAttrs[len(r.PendingAttrs)-1].ValueType = valueType
-
-				// Infer instance attribute for class-level attribute tracking
-				// When we see obj.attr = value, record that the class of obj has 'attr'
-				base := r.tree.ChildAt(target, 0)
-				attrNode := r.tree.ChildAt(target, 1)
-				if base != ast.NoNode && attrNode != ast.NoNode {
-					attrName, _ := r.tree.NameText(attrNode)
-					if attrName != "" {
-						baseType := r.exprType(base)
-						if baseType != nil {
-							// Get the class symbol from the type
-							var classSym *Symbol
-							switch baseType.Kind {
-							case TypeInstance:
-								classSym = baseType.Symbol
-							case TypeClass:
-								classSym = baseType.Symbol
-							}
-							if classSym != nil {
-								r.recordInstanceAttr(classSym, attrName, valueType)
-							}
-						}
-					}
-				}
+			} else if targetKind == ast.NodeAttribute {
+				r.bindAttributeTarget(target, valueType)
+			} else {
+				r.assignTargetType(target, valueType)
+			}
+		}
+		if len(unpacked) > 0 {
+			span := ast.Range{
+				Start: r.tree.RangeOf(unpacked[0]).Start,
+				End:   r.tree.RangeOf(unpacked[len(unpacked)-1]).End,
 			}
+			r.unpackTargets(span, unpacked, valueType)
 		}
 
 	case ast.NodeAnnAssign:
 		target, annotation, value := r.tree.AnnAssignParts(stmt)
+		if r.tree.Node(target).Kind == ast.NodeName && value != ast.NoNode && isTypeAliasAnnotation(r.tree, annotation) {
+			r.visitExpr(annotation, Read)
+			r.visitExpr(target, Write)
+			if sym := r.Resolved[target]; sym != nil {
+				r.declareTypeAlias(sym, value)
+			}
+			return
+		}
 		annotType := r.resolveAnnotation(annotation)
+		if isBareQualifier(r.tree, annotation) {
+			annotType = nil
+		}
 		if value != ast.NoNode {
 			r.visitExpr(value, Read)
 			valueType := r.ExprTypes[value]
 			if IsUnknownType(annotType) && !IsUnknownType(valueType) {
 				annotType = valueType
 			}
+			r.checkProtocolValue(r.tree.RangeOf(value), annotType, valueType)
 		}
 		r.visitExpr(target, Write)
 		targetKind := r.tree.Node(target).Kind
@@ -224,19 +311,52 @@ func (r *Resolver) visitStmt(stmt ast.NodeID) {
 		}
 		r.Resolved[nameID] = classSym
 
+		if classSym != nil {
+			classSym.Bases = nil
+			classSym.Protocol = false
+			classSym.Enum = nil
+			classSym.ABC = r.declaresABCMeta(bases)
+		}
+		metaclass, knownMetaclass := r.resolveMetaclass(bases)
+		if classSym != nil {
+			classSym.Metaclass = metaclass
+		}
+		unknownBase := !knownMetaclass
 		for baseExpr := r.tree.Nodes[bases].FirstChild; baseExpr != ast.NoNode; baseExpr = r.tree.Nodes[baseExpr].NextSibling {
+			if r.tree.Node(baseExpr).Kind == ast.NodeKeywordArg {
+				continue
+			}
+			if r.isProtocolBase(baseExpr) {
+				if classSym != nil {
+					classSym.Protocol = true
+				}
+				continue
+			}
+			if r.isABCBase(baseExpr) {
+				if classSym != nil {
+					classSym.ABC = true
+				}
+				continue
+			}
 			baseSym, ok := r.resolveBaseClassSymbol(baseExpr)
 			if !ok {
+				unknownBase = true
 				continue
 			}
 
 			classSym.Bases = append(classSym.Bases, baseSym)
+			classSym.ABC = classSym.ABC || isABC(baseSym)
 		}
 
 		if classSym == nil || classSym.Inner == nil {
 			r.error(r.tree.RangeOf(nameID), "internal compiler error: missing class symbol or scope for: "+nameText)
 			return
 		}
+		classSym.DynamicMembers = !r.defineSlots(classSym, body) || unknownBase
+		classSym.RuntimeCheckable = classSym.Protocol && hasRuntimeCheckableDecorator(r.tree, stmt)
+		if _, ok := linearize(classSym, map[*Symbol]bool{}); !ok {
+			r.error(r.tree.RangeOf(nameID), "cannot create a consistent method resolution order (MRO) for bases "+baseNames(classSym.Bases))
+		}
 
 		prevScope := r.current
 		prevClass := r.currentClass
@@ -247,24 +367,42 @@ func (r *Resolver) visitStmt(stmt ast.NodeID) {
 		r.currentClass = classSym
 		r.inClass = true
 
+		r.collectInstanceAttrs(classSym, body)
 		for inner := r.tree.Nodes[body].FirstChild; inner != ast.NoNode; inner = r.tree.Nodes[inner].NextSibling {
 			r.visitStmt(inner)
 		}
 
+		classSym.DataclassTransform = hasDataclassTransformDecorator(r.tree, stmt)
+		r.synthesizeDataclassMembers(stmt, classSym)
+		for _, base := range classSym.Bases {
+			if isEnumBase(base) {
+				r.collectEnumMembers(classSym, body)
+				break
+			}
+		}
+		r.checkFinalOverrides(classSym)
+
 		r.current = prevScope
 		r.currentClass = prevClass
 		r.inClass = prevInClass
 		r.selfName = prevSelf
 
 	case ast.NodeFunctionDef:
-		for _, decorator := range r.tree.Decorators(stmt) {
-			r.visitExpr(r.tree.DecoratorExpr(decorator), Read)
-		}
-
 		nameID, args, returnAnnotation, body := r.tree.FunctionPartsWithReturn(stmt)
 		nameText, _ := r.tree.NameText(nameID)
 
-		fnSym := r.current.Symbols[nameText]
+		for _, decorator := range r.tree.Decorators(stmt) {
+			expr := r.tree.DecoratorExpr(decorator)
+			if base, ok := r.propertyAccessorBase(expr, nameText); ok {
+				// @name.setter is looked up on the property object, not on
+				// the getter's return type, so only the base is resolved.
+				r.visitExpr(base, Read)
+				continue
+			}
+			r.visitExpr(expr, Read)
+		}
+
+		fnSym := r.functionSymbolForDef(nameText, nameID)
 		if doc, ok := r.tree.DocString(stmt); ok && fnSym != nil {
 			fnSym.DocString = doc
 		}
@@ -273,6 +411,9 @@ func (r *Resolver) visitStmt(stmt ast.NodeID) {
 			r.error(r.tree.RangeOf(nameID), "internal compiler error: missing function symbol or scope for "+nameText)
 			return
 		}
+		fnSym.DataclassTransform = hasDataclassTransformDecorator(r.tree, stmt)
+		// Drop the signature a previous pass derived from the decorators.
+		fnSym.Params, fnSym.Returns, fnSym.Inferred = nil, nil, nil
 
 		if args != ast.NoNode {
 			for arg := r.tree.Nodes[args].FirstChild; arg != ast.NoNode; arg = r.tree.Nodes[arg].NextSibling {
@@ -290,22 +431,32 @@ func (r *Resolver) visitStmt(stmt ast.NodeID) {
 		}
 		if returnAnnotation != ast.NoNode {
 			fnSym.Returns = r.resolveAnnotation(returnAnnotation)
+			fnSym.ReturnsSelf = isSelfAnnotation(r.tree, returnAnnotation)
+		}
+		if r.currentClass != nil && r.current == r.currentClass.Inner && r.current.Symbols[nameText] == fnSym {
+			r.checkMethodOverride(stmt, fnSym)
 		}
 
 		prevScope := r.current
 		prevInFn := r.inFunction
 		prevSelf := r.selfName
+		prevGenerator := r.generator
 
-		if r.inClass && args != ast.NoNode && r.tree.Nodes[args].FirstChild != ast.NoNode {
+		if r.inClass && fnSym.Method != MethodStaticMethod && args != ast.NoNode && r.tree.Nodes[args].FirstChild != ast.NoNode {
 			selfParam, _, _ := r.tree.ParamParts(r.tree.Nodes[args].FirstChild)
 			selfName, _ := r.tree.NameText(selfParam)
 			r.selfName = selfName
 
-			// Set self parameter's type to instance of the current class
+			// Set self parameter's type to instance of the current class,
+			// or to the class itself for classmethods.
 			if selfName != "" && r.currentClass != nil {
 				if selfSym := fnSym.Inner.Symbols[selfName]; selfSym != nil {
-					selfSym.Inferred = InstanceType(r.currentClass)
-					selfSym.InstanceOf = r.currentClass
+					if fnSym.Method == MethodClassMethod {
+						selfSym.Inferred = ClassType(r.currentClass)
+					} else {
+						selfSym.Inferred = InstanceType(r.currentClass)
+						selfSym.InstanceOf = r.currentClass
+					}
 				}
 			}
 		} else {
@@ -314,49 +465,89 @@ func (r *Resolver) visitStmt(stmt ast.NodeID) {
 
 		r.current = fnSym.Inner
 		r.inFunction = true
+		r.generator = newGeneratorInfo(fnSym.Returns, r.tree.IsAsync(stmt))
 
 		for inner := r.tree.Nodes[body].FirstChild; inner != ast.NoNode; inner = r.tree.Nodes[inner].NextSibling {
 			r.visitStmt(inner)
 		}
+		if returnAnnotation == ast.NoNode {
+			if generatorType := r.generator.inferredReturn(); generatorType != nil {
+				fnSym.Returns = generatorType
+			}
+		}
 
 		r.current = prevScope
 		r.inFunction = prevInFn
 		r.selfName = prevSelf
+		r.generator = prevGenerator
+		r.applyDecorators(stmt, fnSym)
 
 	case ast.NodeExprStmt:
+		if value := r.tree.Nodes[stmt].FirstChild; r.tree.Node(value).Kind == ast.NodeYield {
+			r.visitYield(value, false)
+			break
+		}
 		r.visitExpr(r.tree.Nodes[stmt].FirstChild, Read)
 
 	case ast.NodeIf:
-		test := r.tree.Nodes[stmt].FirstChild
-		body := ast.NoNode
-		orelse := ast.NoNode
-		if test != ast.NoNode {
-			body = r.tree.Nodes[test].NextSibling
-		}
-		if body != ast.NoNode {
-			orelse = r.tree.Nodes[body].NextSibling
-		}
+		test, body, orelse, bodyLive, orelseLive := r.target.ifBranches(r.tree, stmt)
 		r.visitExpr(test, Read)
+		if !bodyLive || !orelseLive {
+			// Only the branch the target interpreter takes is resolved; the
+			// other is reported as inactive code.
+			live, dead := body, orelse
+			if !bodyLive {
+				live, dead = orelse, body
+			}
+			if dead != ast.NoNode {
+				guard := test
+				for r.tree.Node(guard).Kind == ast.NodeUnaryOp {
+					guard = r.tree.Node(guard).FirstChild
+				}
+				msg := "code is inactive for " + r.target.String()
+				if isTypeCheckingGuard(r.tree, guard) {
+					msg = "code is inactive during type checking"
+				} else if _, literal := (Target{}).staticCondition(r.tree, test); literal {
+					// Decided without the target, as with `if True:`.
+					msg = "code is unreachable"
+				}
+				r.inactive(r.tree.RangeOf(dead), msg)
+			}
+			for inner := r.tree.Nodes[live].FirstChild; inner != ast.NoNode; inner = r.tree.Nodes[inner].NextSibling {
+				r.visitStmt(inner)
+			}
+			break
+		}
 
 		// Check for isinstance() type narrowing
-		narrowedVars := make(map[string]struct{})
+		whenTrue := make(map[string]*Type)
+		whenFalse := make(map[string]*Type)
 		if varName, narrowedType, ok := r.extractIsinstanceCheck(test); ok {
-			r.typeConstraints[varName] = narrowedType
-			narrowedVars[varName] = struct{}{}
+			if protocol := protocolClass(narrowedType); protocol != nil {
+				if !protocol.RuntimeCheckable {
+					r.error(r.tree.RangeOf(test), "isinstance() requires a @runtime_checkable protocol: "+protocol.Name)
+				}
+				narrowedType = r.narrowToProtocol(varName, narrowedType)
+			}
+			whenTrue[varName] = narrowedType
+		}
+		// Comparisons against enum members narrow both branches.
+		if varName, matched, rest := r.narrowEnumComparison(test); varName != "" {
+			whenTrue[varName] = matched
+			whenFalse[varName] = rest
 		}
 
+		saved := r.narrow(whenTrue)
 		for inner := r.tree.Nodes[body].FirstChild; inner != ast.NoNode; inner = r.tree.Nodes[inner].NextSibling {
 			r.visitStmt(inner)
 		}
+		r.restoreConstraints(saved)
 
-		// Remove type constraints after visiting the if-body
-		for varName := range narrowedVars {
-			delete(r.typeConstraints, varName)
-		}
-
+		saved = r.narrow(whenFalse)
 		for inner := r.tree.Nodes[orelse].FirstChild; inner != ast.NoNode; inner = r.tree.Nodes[inner].NextSibling {
 			r.visitStmt(inner)
 		}
+		r.restoreConstraints(saved)
 
 	case ast.NodeAssert:
 		test, msg := r.tree.AssertParts(stmt)
@@ -387,6 +578,7 @@ func (r *Resolver) visitStmt(stmt ast.NodeID) {
 		r.visitExpr(iter, Read)
 		r.loopDepth++
 		r.visitExpr(target, Write)
+		r.assignTargetType(target, iterElementType(r.exprType(iter), r.tree.IsAsync(stmt)))
 
 		for inner := r.tree.Nodes[body].FirstChild; inner != ast.NoNode; inner = r.tree.Nodes[inner].NextSibling {
 			r.visitStmt(inner)
@@ -415,14 +607,20 @@ func (r *Resolver) visitStmt(stmt ast.NodeID) {
 			r.error(r.tree.RangeOf(stmt), "return outside function")
 		}
 
-		if value := r.tree.Nodes[stmt].FirstChild; value != ast.NoNode {
+		value := r.tree.Nodes[stmt].FirstChild
+		if value != ast.NoNode {
 			r.visitExpr(value, Read)
 		}
+		if r.generator != nil {
+			if value == ast.NoNode {
+				r.generator.returns = append(r.generator.returns, noneAnnotation())
+			} else {
+				r.generator.returns = append(r.generator.returns, r.exprType(value))
+			}
+		}
 
 	case ast.NodeYield:
-		if value := r.tree.Nodes[stmt].FirstChild; value != ast.NoNode {
-			r.visitExpr(value, Read)
-		}
+		r.visitYield(stmt, false)
 
 	case ast.NodeRaise:
 		exc, cause := r.tree.RaiseParts(stmt)
@@ -438,6 +636,7 @@ func (r *Resolver) visitStmt(stmt ast.NodeID) {
 		r.checkLoopContext(r.tree.RangeOf(stmt), "continue")
 
 	case ast.NodeImport, ast.NodeFromImport:
+		r.recordImports(stmt)
 	case ast.NodeTry:
 		body, excepts, elseBlock, finallyBlock := r.tree.TryParts(stmt)
 		for inner := r.tree.Nodes[body].FirstChild; inner != ast.NoNode; inner = r.tree.Nodes[inner].NextSibling {
@@ -459,11 +658,15 @@ func (r *Resolver) visitStmt(stmt ast.NodeID) {
 			contextExpr, asTarget := r.tree.WithItemParts(item)
 			r.visitExpr(contextExpr, Read)
 			r.visitExpr(asTarget, Write)
+			r.assignTargetType(asTarget, contextManagerType(r.exprType(contextExpr), r.tree.IsAsync(stmt)))
 		}
 		for inner := r.tree.Nodes[body].FirstChild; inner != ast.NoNode; inner = r.tree.Nodes[inner].NextSibling {
 			r.visitStmt(inner)
 		}
 
+	case ast.NodeMatch:
+		r.visitMatch(stmt)
+
 	case ast.NodeExcept:
 		excType, _, body := r.tree.ExceptParts(stmt)
 		r.visitExpr(excType, Read)
@@ -473,6 +676,194 @@ func (r *Resolver) visitStmt(stmt ast.NodeID) {
 	}
 }
 
+// narrow applies the type constraints in narrowed and returns what they
+// replaced, for restoreConstraints.
+func (r *Resolver) narrow(narrowed map[string]*Type) map[string]*Type {
+	saved := make(map[string]*Type, len(narrowed))
+	for name, t := range narrowed {
+		saved[name] = r.typeConstraints[name]
+		r.typeConstraints[name] = t
+	}
+	return saved
+}
+
+func (r *Resolver) restoreConstraints(saved map[string]*Type) {
+	for name, t := range saved {
+		if t == nil {
+			delete(r.typeConstraints, name)
+		} else {
+			r.typeConstraints[name] = t
+		}
+	}
+}
+
+// visitMatch resolves a match statement. Each case narrows a named subject
+// to the values its pattern matches, and an unguarded case removes them
+// from the values later cases see.
+func (r *Resolver) visitMatch(stmt ast.NodeID) {
+	subject, cases := r.tree.MatchParts(stmt)
+	r.visitExpr(subject, Read)
+	subjectName := ""
+	if r.tree.Node(subject).Kind == ast.NodeName {
+		subjectName, _ = r.tree.NameText(subject)
+	}
+	remaining := expandEnumArms(r.exprType(subject))
+
+	for _, clause := range cases {
+		pattern, guard, body := r.tree.MatchCaseParts(clause)
+		r.visitPattern(pattern)
+		matched, rest, refutable := r.patternArms(pattern, remaining)
+		matchedType := JoinTypes(matched...)
+		if !refutable || len(matched) > 0 {
+			r.bindPatternCapture(pattern, matchedType)
+		}
+
+		narrowed := make(map[string]*Type)
+		if subjectName != "" && (!refutable || len(matched) > 0) {
+			narrowed[subjectName] = matchedType
+		}
+		saved := r.narrow(narrowed)
+		r.visitExpr(guard, Read)
+		for inner := r.tree.Nodes[body].FirstChild; inner != ast.NoNode; inner = r.tree.Nodes[inner].NextSibling {
+			r.visitStmt(inner)
+		}
+		r.restoreConstraints(saved)
+
+		if guard == ast.NoNode {
+			remaining = rest
+		}
+	}
+}
+
+// visitPattern resolves the values a case pattern reads and the names it
+// captures.
+func (r *Resolver) visitPattern(id ast.NodeID) {
+	if id == ast.NoNode {
+		return
+	}
+	switch r.tree.Node(id).Kind {
+	case ast.NodeName:
+		if name, _ := r.tree.NameText(id); name != "_" {
+			r.visitExpr(id, Write)
+		}
+	case ast.NodeMatchAs:
+		r.visitPattern(r.tree.ChildAt(id, 0))
+		r.visitPattern(r.tree.ChildAt(id, 1))
+	case ast.NodeStarArg, ast.NodeKwStarArg:
+		r.visitPattern(r.tree.ChildAt(id, 0))
+	case ast.NodeKeywordArg:
+		r.visitPattern(r.tree.ChildAt(id, 1))
+	case ast.NodeCall:
+		cls := r.tree.Node(id).FirstChild
+		r.visitExpr(cls, Read)
+		for arg := r.tree.Node(cls).NextSibling; arg != ast.NoNode; arg = r.tree.Node(arg).NextSibling {
+			r.visitPattern(arg)
+		}
+	case ast.NodeDict:
+		isKey := true
+		for child := r.tree.Node(id).FirstChild; child != ast.NoNode; child = r.tree.Node(child).NextSibling {
+			if r.tree.Node(child).Kind == ast.NodeKwStarArg {
+				r.visitPattern(child)
+				continue
+			}
+			if isKey {
+				r.visitExpr(child, Read)
+			} else {
+				r.visitPattern(child)
+			}
+			isKey = !isKey
+		}
+	case ast.NodeTuple, ast.NodeList, ast.NodeBinOp:
+		for child := r.tree.Node(id).FirstChild; child != ast.NoNode; child = r.tree.Node(child).NextSibling {
+			r.visitPattern(child)
+		}
+	default:
+		r.visitExpr(id, Read)
+	}
+}
+
+// bindPatternCapture types the name a top-level capture or as-pattern binds
+// to the subject values the pattern matched.
+func (r *Resolver) bindPatternCapture(pattern ast.NodeID, matched *Type) {
+	target := pattern
+	if r.tree.Node(pattern).Kind == ast.NodeMatchAs {
+		target = r.tree.ChildAt(pattern, 1)
+	}
+	if r.tree.Node(target).Kind != ast.NodeName || IsUnknownType(matched) {
+		return
+	}
+	if sym := r.Resolved[target]; sym != nil {
+		sym.Inferred = UnionType(sym.Inferred, matched)
+		r.setExprType(target, matched)
+	}
+}
+
+// propertyAccessorBase reports whether expr is a @name.setter, @name.deleter
+// or @name.getter decorator on a property, returning the name node.
+func (r *Resolver) propertyAccessorBase(expr ast.NodeID, name string) (ast.NodeID, bool) {
+	if r.tree.Node(expr).Kind != ast.NodeAttribute {
+		return ast.NoNode, false
+	}
+	switch exprDottedName(r.tree, expr) {
+	case name + ".setter", name + ".deleter", name + ".getter":
+	default:
+		return ast.NoNode, false
+	}
+	property, ok := r.current.Symbols[name]
+	if !ok || property.Method != MethodProperty {
+		return ast.NoNode, false
+	}
+	return r.tree.ChildAt(expr, 0), true
+}
+
+// callArgs collects the inferred argument types of a call.
+func (r *Resolver) callArgs(call ast.NodeID) CallArgs {
+	var args CallArgs
+	callee := r.tree.Nodes[call].FirstChild
+	for arg := r.tree.Nodes[callee].NextSibling; arg != ast.NoNode; arg = r.tree.Nodes[arg].NextSibling {
+		switch r.tree.Node(arg).Kind {
+		case ast.NodeKeywordArg:
+			name, _ := r.tree.NameText(r.tree.ChildAt(arg, 0))
+			args.Keywords = append(args.Keywords, name)
+			args.KeywordTypes = append(args.KeywordTypes, r.exprType(r.tree.ChildAt(arg, 1)))
+		case ast.NodeStarArg, ast.NodeKwStarArg:
+			args.Starred = true
+		default:
+			args.Positional = append(args.Positional, r.exprType(arg))
+		}
+	}
+	return args
+}
+
+// setOverloadReturnType types a call to an overloaded function with the
+// return type of the first overload accepting its arguments.
+func (r *Resolver) setOverloadReturnType(call ast.NodeID, fn *Symbol, bound bool) {
+	sig := SelectOverload(fn, bound, r.callArgs(call))
+	if sig != nil && !IsUnknownType(sig.Returns) {
+		r.setExprType(call, sig.Returns)
+	}
+}
+
+// functionSymbolForDef returns the symbol the scope builder created for a
+// def, including property accessors and @overload stubs that share a name.
+func (r *Resolver) functionSymbolForDef(name string, nameID ast.NodeID) *Symbol {
+	sym := r.current.Symbols[name]
+	if sym == nil || sym.Def == nameID {
+		return sym
+	}
+	for _, accessor := range sym.Accessors {
+		if accessor.Def == nameID {
+			return accessor
+		}
+	}
+	for _, overload := range sym.Overloads {
+		if overload.Def == nameID {
+			return overload
+		}
+	}
+	return sym
+}
+
 func (r *Resolver) checkLoopContext(pos ast.Range, keyword string) {
 	if r.loopDepth == 0 {
 		r.error(pos, keyword+" outside loop")
@@ -492,6 +883,13 @@ func (r *Resolver) resolveBaseClassSymbol(baseExpr ast.NodeID) (*Symbol, bool) {
 		ok = baseSym != nil
 	case ast.NodeAttribute:
 		baseSym, ok = r.resolveAttributeExpr(baseExpr)
+	case ast.NodeCall:
+		// Functional forms such as namedtuple("P", "x y") produce a class.
+		if typ := r.exprType(baseExpr); typ != nil && typ.Kind == TypeClass && typ.Symbol != nil {
+			return typ.Symbol, true
+		}
+		r.error(r.tree.RangeOf(baseExpr), "unsupported base class expression")
+		return nil, false
 	default:
 		r.error(r.tree.RangeOf(baseExpr), "unsupported base class expression")
 		return nil, false
@@ -521,6 +919,31 @@ func (r *Resolver) resolveBaseClassSymbol(baseExpr ast.NodeID) (*Symbol, bool) {
 	return nil, false
 }
 
+// resolveMetaclass returns the class a class base list passes as
+// metaclass=. The boolean is false when the metaclass does not resolve to a
+// class, so the members it gives the class are unknown; type itself and a
+// missing keyword give no metaclass.
+func (r *Resolver) resolveMetaclass(bases ast.NodeID) (*Symbol, bool) {
+	for arg := r.tree.Node(bases).FirstChild; arg != ast.NoNode; arg = r.tree.Node(arg).NextSibling {
+		if r.tree.Node(arg).Kind != ast.NodeKeywordArg {
+			continue
+		}
+		if name, _ := r.tree.NameText(r.tree.ChildAt(arg, 0)); name != "metaclass" {
+			continue
+		}
+		metaclass := r.tree.ChildAt(arg, 1)
+		if sym := r.Resolved[metaclass]; sym != nil && isBuiltinSymbol(sym) && sym.Name == "type" {
+			return nil, true
+		}
+		typ := r.exprType(metaclass)
+		if IsUnknownType(typ) || typ.Kind != TypeClass || typ.Symbol == nil {
+			return nil, false
+		}
+		return typ.Symbol, true
+	}
+	return nil, true
+}
+
 func (r *Resolver) resolveAttributeExpr(expr ast.NodeID) (*Symbol, bool) {
 	if expr == ast.NoNode || r.tree.Node(expr).Kind != ast.NodeAttribute {
 		return nil, false
@@ -561,6 +984,12 @@ func (r *Resolver) resolveAttributeExpr(expr ast.NodeID) (*Symbol, bool) {
 		}
 		if classSym != nil {
 			if inferredType := r.getInferredInstanceAttr(classSym, attrName); inferredType != nil {
+				if sym, ok := LookupMemberOnType(baseType, attrName); ok && sym.Kind == SymAttr {
+					// Keep the declared attribute so navigation still works.
+					r.ResolvedAttr[expr] = sym
+					r.setExprType(expr, inferredType)
+					return sym, true
+				}
 				// Create a synthetic symbol for the inferred attribute
 				attrSym := &Symbol{
 					Name:     attrName,
@@ -596,31 +1025,11 @@ func (r *Resolver) resolveAttributeExpr(expr ast.NodeID) (*Symbol, bool) {
 }
 
 func (r *Resolver) baseExprName(expr ast.NodeID) string {
-	if expr == ast.NoNode {
-		return ""
-	}
-	switch r.tree.Node(expr).Kind {
-	case ast.NodeName:
-		name, _ := r.tree.NameText(expr)
-		return name
-	case ast.NodeAttribute:
-		base := r.tree.ChildAt(expr, 0)
-		attr := r.tree.ChildAt(expr, 1)
-		baseName := r.baseExprName(base)
-		attrName, _ := r.tree.NameText(attr)
-		if baseName == "" {
-			return attrName
-		}
-		if attrName == "" {
-			return baseName
-		}
-		return baseName + "." + attrName
-	default:
-		return ""
-	}
+	return exprDottedName(r.tree, expr)
 }
 
 func (r *Resolver) setExprType(id ast.NodeID, t *Type) {
+	t = expandAlias(t)
 	if id == ast.NoNode || IsUnknownType(t) {
 		return
 	}
@@ -690,27 +1099,23 @@ func (r *Resolver) recordInstanceAttr(classSym *Symbol, attrName string, typ *Ty
 }
 
 // getInferredInstanceAttr retrieves an inferred instance attribute type
-// for a given class and attribute name.
+// for a given class and attribute name, joining the assignments made by the
+// class and every class along its MRO.
 func (r *Resolver) getInferredInstanceAttr(classSym *Symbol, attrName string) *Type {
 	if classSym == nil || attrName == "" {
 		return nil
 	}
 
-	// Check the class itself
-	if attrs, ok := r.classInstanceAttrs[classSym.ID]; ok {
-		if typ, ok := attrs[attrName]; ok {
-			return typ
+	var types []*Type
+	for _, cls := range MRO(classSym) {
+		if typ, ok := r.classInstanceAttrs[cls.ID][attrName]; ok {
+			types = append(types, typ)
 		}
 	}
-
-	// Check parent classes (inheritance)
-	for _, base := range classSym.Bases {
-		if typ := r.getInferredInstanceAttr(base, attrName); typ != nil {
-			return typ
-		}
+	if len(types) == 0 {
+		return nil
 	}
-
-	return nil
+	return JoinTypes(types...)
 }
 
 // extractIsinstanceCheck attempts to extract type narrowing information from
@@ -845,8 +1250,11 @@ func (r *Resolver) resolveSubscriptTypeExpr(expr ast.NodeID) *Type {
 		elemType := r.resolveTypeFromExpr(index)
 		return ListType(elemType)
 	case "tuple":
+		if elem, ok := variadicTupleElem(r.tree, index); ok {
+			return VarTupleType(r.resolveTypeFromExpr(elem))
+		}
 		if r.tree.Node(index).Kind == ast.NodeTuple {
-			// tuple[int, str, ...] - multiple type arguments
+			// tuple[int, str] - multiple type arguments
 			items := make([]*Type, 0, r.tree.ChildCount(index))
 			for child := r.tree.Node(index).FirstChild; child != ast.NoNode; child = r.tree.Node(child).NextSibling {
 				items = append(items, r.resolveTypeFromExpr(child))
@@ -879,16 +1287,24 @@ func (r *Resolver) resolveAnnotation(expr ast.NodeID) *Type {
 
 	r.visitExpr(expr, Read)
 
+	if isSelfAnnotation(r.tree, expr) && r.currentClass != nil {
+		return InstanceType(r.currentClass)
+	}
+
 	switch r.tree.Node(expr).Kind {
 	case ast.NodeName:
 		sym := r.Resolved[expr]
 		if sym == nil {
 			return nil
 		}
-		if sym.Kind == SymClass {
-			return InstanceType(sym)
+		return annotationTypeOf(sym)
+	case ast.NodeNone:
+		return noneAnnotation()
+	case ast.NodeBinOp:
+		if ast.Operator(r.tree.Node(expr).Data) != ast.BitOr {
+			return nil
 		}
-		return SymbolType(sym)
+		return unionAnnotation(r.resolveAnnotation(r.tree.ChildAt(expr, 0)), r.resolveAnnotation(r.tree.ChildAt(expr, 1)))
 	case ast.NodeString:
 		// Handle stringified type annotations (forward references)
 		return r.resolveStringAnnotation(expr)
@@ -942,6 +1358,7 @@ func (r *Resolver) resolveStringAnnotation(expr ast.NodeID) *Type {
 		}
 	}
 
+	r.markNamesRead(subTree, r.tree.RangeOf(expr).Start)
 	if parsedExpr == ast.NoNode {
 		// Parsing failed - cache nil and return
 		// This matches Python's behavior where invalid string annotations
@@ -973,10 +1390,7 @@ func (r *Resolver) resolveParsedAnnotation(expr ast.NodeID, subTree *ast.AST) *T
 		name, _ := subTree.NameText(expr)
 		// Look up the name in the current scope
 		if sym, ok := r.current.Lookup(name); ok && sym != nil {
-			if sym.Kind == SymClass {
-				return InstanceType(sym)
-			}
-			return SymbolType(sym)
+			return annotationTypeOf(sym)
 		}
 		return nil
 	case ast.NodeSubScript:
@@ -999,24 +1413,12 @@ func (r *Resolver) resolveParsedAnnotation(expr ast.NodeID, subTree *ast.AST) *T
 		}
 		return nil
 	case ast.NodeNone:
-		// None literal in annotation - resolve to NoneType
-		if noneSym := BuiltinSymbol("NoneType"); noneSym != nil {
-			return BuiltinType(noneSym)
-		}
-		return nil
+		return noneAnnotation()
 	case ast.NodeBinOp:
 		// Handle union types (X | Y) - Python 3.10+
 		left := subTree.ChildAt(expr, 0)
 		right := subTree.ChildAt(expr, 1)
-		leftType := r.resolveParsedAnnotation(left, subTree)
-		rightType := r.resolveParsedAnnotation(right, subTree)
-		if leftType != nil && rightType != nil {
-			return UnionType(leftType, rightType)
-		}
-		if leftType != nil {
-			return leftType
-		}
-		return rightType
+		return unionAnnotation(r.resolveParsedAnnotation(left, subTree), r.resolveParsedAnnotation(right, subTree))
 	default:
 		return nil
 	}
@@ -1036,6 +1438,9 @@ func (r *Resolver) resolveParsedSubscriptAnnotation(expr ast.NodeID, subTree *as
 	case "list":
 		return ListType(r.resolveParsedAnnotation(index, subTree))
 	case "tuple":
+		if elem, ok := variadicTupleElem(subTree, index); ok {
+			return VarTupleType(r.resolveParsedAnnotation(elem, subTree))
+		}
 		if subTree.Node(index).Kind == ast.NodeTuple {
 			items := make([]*Type, 0, subTree.ChildCount(index))
 			for child := subTree.Node(index).FirstChild; child != ast.NoNode; child = subTree.Node(child).NextSibling {
@@ -1053,14 +1458,41 @@ func (r *Resolver) resolveParsedSubscriptAnnotation(expr ast.NodeID, subTree *as
 		return DictType(r.resolveParsedAnnotation(key, subTree), r.resolveParsedAnnotation(value, subTree))
 	case "set":
 		return SetType(r.resolveParsedAnnotation(index, subTree))
+	case "Optional":
+		return unionAnnotation(r.resolveParsedAnnotation(index, subTree), noneAnnotation())
+	case "Union":
+		if subTree.Node(index).Kind != ast.NodeTuple {
+			return r.resolveParsedAnnotation(index, subTree)
+		}
+		var arms *Type
+		for child := subTree.Node(index).FirstChild; child != ast.NoNode; child = subTree.Node(child).NextSibling {
+			arms = unionAnnotation(arms, r.resolveParsedAnnotation(child, subTree))
+		}
+		return arms
+	case "ClassVar", "Final", "InitVar":
+		return r.resolveParsedAnnotation(index, subTree)
 	default:
 		return nil
 	}
 }
 
+// variadicTupleElem returns T for the index of tuple[T, ...].
+func variadicTupleElem(tree *ast.AST, index ast.NodeID) (ast.NodeID, bool) {
+	if tree.Node(index).Kind != ast.NodeTuple || tree.ChildCount(index) != 2 {
+		return ast.NoNode, false
+	}
+	if tree.Node(tree.ChildAt(index, 1)).Kind != ast.NodeEllipsis {
+		return ast.NoNode, false
+	}
+	return tree.ChildAt(index, 0), true
+}
+
 func (r *Resolver) resolveSubscriptAnnotation(expr ast.NodeID) *Type {
 	base := r.tree.ChildAt(expr, 0)
 	index := r.tree.ChildAt(expr, 1)
+	if callableAnnotations[exprDottedName(r.tree, base)] {
+		return r.resolveCallableAnnotation(index)
+	}
 	if base == ast.NoNode || index == ast.NoNode || r.tree.Node(base).Kind != ast.NodeName {
 		return nil
 	}
@@ -1070,6 +1502,9 @@ func (r *Resolver) resolveSubscriptAnnotation(expr ast.NodeID) *Type {
 	case "list":
 		return ListType(r.resolveAnnotation(index))
 	case "tuple":
+		if elem, ok := variadicTupleElem(r.tree, index); ok {
+			return VarTupleType(r.resolveAnnotation(elem))
+		}
 		if r.tree.Node(index).Kind == ast.NodeTuple {
 			items := make([]*Type, 0, r.tree.ChildCount(index))
 			for child := r.tree.Node(index).FirstChild; child != ast.NoNode; child = r.tree.Node(child).NextSibling {
@@ -1087,9 +1522,38 @@ func (r *Resolver) resolveSubscriptAnnotation(expr ast.NodeID) *Type {
 		return DictType(r.resolveAnnotation(key), r.resolveAnnotation(value))
 	case "set":
 		return SetType(r.resolveAnnotation(index))
-	default:
+	case "Optional":
+		return unionAnnotation(r.resolveAnnotation(index), noneAnnotation())
+	case "Union":
+		if r.tree.Node(index).Kind != ast.NodeTuple {
+			return r.resolveAnnotation(index)
+		}
+		var arms *Type
+		for child := r.tree.Node(index).FirstChild; child != ast.NoNode; child = r.tree.Node(child).NextSibling {
+			arms = unionAnnotation(arms, r.resolveAnnotation(child))
+		}
+		return arms
+	case "ClassVar", "Final", "InitVar":
+		return r.resolveAnnotation(index)
+	case "type", "Type":
+		return nil
+	}
+
+	// Other classes keep their type arguments so protocols such as
+	// Iterator[T] can be followed through iteration and await.
+	sym := r.Resolved[base]
+	if sym == nil || (sym.Kind != SymClass && !parameterizedProtocols[baseName]) {
 		return nil
 	}
+	var args []*Type
+	if r.tree.Node(index).Kind == ast.NodeTuple {
+		for child := r.tree.Node(index).FirstChild; child != ast.NoNode; child = r.tree.Node(child).NextSibling {
+			args = append(args, r.resolveAnnotation(child))
+		}
+	} else {
+		args = append(args, r.resolveAnnotation(index))
+	}
+	return &Type{Kind: TypeInstance, Symbol: sym, Args: args}
 }
 
 func (r *Resolver) resolveName(id ast.NodeID, ctx NameContext) {
@@ -1108,11 +1572,16 @@ func (r *Resolver) resolveName(id ast.NodeID, ctx NameContext) {
 		var ok bool
 		sym, ok = r.current.Lookup(name)
 		if !ok || sym == nil {
-			r.error(span, "undefined name: "+name)
+			r.errors = append(r.errors, SuggestName(SemanticError{Span: span, Msg: "undefined name: " + name}, name, visibleNames(r.current)))
 			return
 		}
 	}
 	r.Resolved[id] = sym
+	if ctx == Write {
+		r.checkFinalNameWrite(id, sym)
+	} else {
+		r.markRead(sym, span.Start)
+	}
 }
 
 func (r *Resolver) visitExpr(expr ast.NodeID, ctx NameContext) {
@@ -1130,7 +1599,7 @@ func (r *Resolver) visitExpr(expr ast.NodeID, ctx NameContext) {
 				// Use the narrowed type from isinstance() check
 				r.setExprType(expr, narrowedType)
 			} else {
-				r.setExprType(expr, SymbolType(r.Resolved[expr]))
+				r.setExprType(expr, ReferenceType(r.Resolved[expr], nil))
 			}
 		}
 		return
@@ -1175,9 +1644,7 @@ func (r *Resolver) visitExpr(expr ast.NodeID, ctx NameContext) {
 		return
 
 	case ast.NodeYield:
-		if value := r.tree.Nodes[expr].FirstChild; value != ast.NoNode {
-			r.visitExpr(value, Read)
-		}
+		r.visitYield(expr, true)
 		return
 
 	case ast.NodeBinOp:
@@ -1188,9 +1655,19 @@ func (r *Resolver) visitExpr(expr ast.NodeID, ctx NameContext) {
 		}
 		r.visitExpr(left, Read)
 		r.visitExpr(right, Read)
+		op := ast.Operator(r.tree.Nodes[expr].Data)
+		r.setExprType(expr, binaryOpType(op, r.exprType(left), r.exprType(right)))
+
+	case ast.NodeAwait:
+		operand := r.tree.Nodes[expr].FirstChild
+		r.visitExpr(operand, Read)
+		r.setExprType(expr, awaitedType(r.exprType(operand)))
 
 	case ast.NodeUnaryOp:
-		r.visitExpr(r.tree.Nodes[expr].FirstChild, Read)
+		operand := r.tree.Nodes[expr].FirstChild
+		r.visitExpr(operand, Read)
+		op := ast.UnaryOperator(r.tree.Nodes[expr].Data)
+		r.setExprType(expr, unaryOpType(op, r.exprType(operand)))
 
 	case ast.NodeBooleanOp:
 		for child := r.tree.Nodes[expr].FirstChild; child != ast.NoNode; child = r.tree.Nodes[child].NextSibling {
@@ -1203,10 +1680,20 @@ func (r *Resolver) visitExpr(expr ast.NodeID, ctx NameContext) {
 			return
 		}
 		r.visitExpr(left, Read)
+		// A chain a < b < c evaluates to one of its comparison results.
+		var results []*Type
+		operand := left
 		for cmp := r.tree.Nodes[left].NextSibling; cmp != ast.NoNode; cmp = r.tree.Nodes[cmp].NextSibling {
-			r.visitExpr(r.tree.Nodes[cmp].FirstChild, Read)
+			right := r.tree.Nodes[cmp].FirstChild
+			r.visitExpr(right, Read)
+			op := ast.CompareOp(r.tree.Nodes[cmp].Data)
+			results = append(results, compareOpType(op, r.exprType(operand), r.exprType(right)))
+			operand = right
 		}
-		r.setExprType(expr, BuiltinType(BuiltinSymbol("bool")))
+		if len(results) == 0 {
+			results = append(results, BuiltinType(BuiltinSymbol("bool")))
+		}
+		r.setExprType(expr, JoinTypes(results...))
 
 	case ast.NodeCall:
 		funcID := r.tree.Nodes[expr].FirstChild
@@ -1214,14 +1701,26 @@ func (r *Resolver) visitExpr(expr ast.NodeID, ctx NameContext) {
 			r.visitExpr(child, Read)
 		}
 
-		if r.tree.Node(funcID).Kind == ast.NodeName {
+		r.checkCallArguments(expr)
+		r.checkAbstractInstantiation(expr)
+		r.checkAssertNever(expr)
+
+		if cls := r.synthesizeNamedTupleCall(expr); cls != nil {
+			r.setExprType(expr, ClassType(cls))
+		} else if typ := r.partialCallType(expr); typ != nil {
+			r.setExprType(expr, typ)
+		} else if r.tree.Node(funcID).Kind == ast.NodeName {
 			sym := r.Resolved[funcID]
 			if sym != nil && sym.Kind == SymClass {
 				r.setExprType(expr, InstanceType(sym))
+			} else if sym != nil && sym.Kind == SymFunction && len(sym.Overloads) > 0 {
+				r.setOverloadReturnType(expr, sym, false)
 			} else if sym != nil && sym.Kind == SymFunction && !IsUnknownType(sym.Returns) {
-				r.setExprType(expr, sym.Returns)
+				r.setExprType(expr, BoundReturnType(sym, nil))
 			} else if sym != nil && sym.Kind == SymType {
 				r.setExprType(expr, BuiltinType(sym))
+			} else if typ := SymbolType(sym); typ != nil && typ.Kind == TypeClass {
+				r.setExprType(expr, InstanceType(typ.Symbol))
 			}
 		} else if r.tree.Node(funcID).Kind == ast.NodeAttribute {
 			base := r.tree.ChildAt(funcID, 0)
@@ -1229,6 +1728,14 @@ func (r *Resolver) visitExpr(expr ast.NodeID, ctx NameContext) {
 			attrName, _ := r.tree.NameText(attr)
 			baseType := r.exprType(base)
 
+			if sym := r.ResolvedAttr[funcID]; sym != nil && sym.Kind == SymFunction && sym.Method != MethodProperty {
+				if len(sym.Overloads) > 0 {
+					r.setOverloadReturnType(expr, sym, IsBoundMethod(sym, baseType))
+				} else if !IsUnknownType(sym.Returns) {
+					r.setExprType(expr, BoundReturnType(sym, baseType))
+				}
+			}
+
 			if attrName == "append" {
 				arg := r.tree.Node(funcID).NextSibling
 				argType := r.exprType(arg)
@@ -1280,10 +1787,25 @@ func (r *Resolver) visitExpr(expr ast.NodeID, ctx NameContext) {
 			}
 		}
 
+		// Anything else of callable type, such as a Callable-annotated
+		// variable, a function reference or an instance with __call__.
+		if r.exprType(expr) == nil {
+			if call := CallableOf(r.exprType(funcID)); call != nil {
+				r.setExprType(expr, r.callReturnType(call, expr))
+			}
+		}
+
 	case ast.NodeTuple, ast.NodeList:
 		itemTypes := make([]*Type, 0)
+		starred := false
 		for child := r.tree.Nodes[expr].FirstChild; child != ast.NoNode; child = r.tree.Nodes[child].NextSibling {
 			r.visitExpr(child, ctx)
+			if r.tree.Node(child).Kind == ast.NodeStarArg {
+				// *xs splices the elements of xs into the display.
+				starred = true
+				itemTypes = append(itemTypes, iterElementType(r.exprType(r.tree.ChildAt(child, 0)), false))
+				continue
+			}
 			itemTypes = append(itemTypes, r.exprType(child))
 		}
 		if r.tree.Node(expr).Kind == ast.NodeList {
@@ -1292,6 +1814,8 @@ func (r *Resolver) visitExpr(expr ast.NodeID, ctx NameContext) {
 				elemType = JoinTypes(itemTypes...)
 			}
 			r.setExprType(expr, ListType(elemType))
+		} else if starred {
+			r.setExprType(expr, VarTupleType(JoinTypes(itemTypes...)))
 		} else {
 			r.setExprType(expr, TupleType(itemTypes...))
 		}
@@ -1304,14 +1828,13 @@ func (r *Resolver) visitExpr(expr ast.NodeID, ctx NameContext) {
 
 	case ast.NodeDict:
 		var keyType, elemType *Type
-		for child := r.tree.Nodes[expr].FirstChild; child != ast.NoNode; child = r.tree.Nodes[child].NextSibling {
+		// Children alternate between keys and values.
+		for i, child := range r.tree.Children(expr) {
 			r.visitExpr(child, Read)
-			// Each child is a key-value pair (NodeTuple with 2 elements)
-			if r.tree.Node(child).Kind == ast.NodeTuple {
-				keyNode := r.tree.ChildAt(child, 0)
-				valueNode := r.tree.ChildAt(child, 1)
-				keyType = JoinTypes(keyType, r.exprType(keyNode))
-				elemType = JoinTypes(elemType, r.exprType(valueNode))
+			if i%2 == 0 {
+				keyType = JoinTypes(keyType, r.exprType(child))
+			} else {
+				elemType = JoinTypes(elemType, r.exprType(child))
 			}
 		}
 		r.setExprType(expr, DictType(keyType, elemType))
@@ -1319,7 +1842,10 @@ func (r *Resolver) visitExpr(expr ast.NodeID, ctx NameContext) {
 	case ast.NodeKeywordArg:
 		r.visitExpr(r.tree.ChildAt(expr, 1), Read)
 
-	case ast.NodeStarArg, ast.NodeKwStarArg:
+	case ast.NodeStarArg:
+		r.visitExpr(r.tree.ChildAt(expr, 0), ctx)
+
+	case ast.NodeKwStarArg:
 		r.visitExpr(r.tree.ChildAt(expr, 0), Read)
 
 	case ast.NodeSubScript:
@@ -1330,7 +1856,7 @@ func (r *Resolver) visitExpr(expr ast.NodeID, ctx NameContext) {
 		}
 		r.visitExpr(base, Read)
 		r.visitExpr(index, Read)
-		if resultType := SubscriptResultType(r.exprType(base)); !IsUnknownType(resultType) {
+		if resultType := expandAlias(SubscriptResultType(r.exprType(base))); !IsUnknownType(resultType) {
 			r.setExprType(expr, resultType)
 		}
 
@@ -1363,8 +1889,14 @@ func (r *Resolver) visitExpr(expr ast.NodeID, ctx NameContext) {
 		}
 
 		if ctx == Read {
+			attrName, _ := r.tree.NameText(r.tree.ChildAt(expr, 1))
+			if typ := enumAttributeType(r.exprType(base), attrName); !IsUnknownType(typ) {
+				r.resolveAttributeExpr(expr)
+				r.setExprType(expr, typ)
+				return
+			}
 			if sym, ok := r.resolveAttributeExpr(expr); ok {
-				if typ := SymbolType(sym); !IsUnknownType(typ) {
+				if typ := ReferenceType(sym, r.exprType(base)); !IsUnknownType(typ) {
 					r.setExprType(expr, typ)
 				}
 				return
@@ -1374,6 +1906,7 @@ func (r *Resolver) visitExpr(expr ast.NodeID, ctx NameContext) {
 			Node:     expr,
 			Class:    r.currentClass,
 			SelfName: r.selfName,
+			Write:    ctx == Write,
 		})
 	}
 }
@@ -1410,7 +1943,7 @@ func (r *Resolver) visitComprehension(id ast.NodeID) {
 	r.visitExpr(iter, Read)
 	r.defineComprehensionTarget(target)
 	r.visitExpr(target, Write)
-	r.assignTargetType(target, SubscriptResultType(r.exprType(iter)))
+	r.assignTargetType(target, iterElementType(r.exprType(iter), false))
 	for _, filter := range filters {
 		r.visitExpr(filter, Read)
 	}
@@ -1436,19 +1969,91 @@ func (r *Resolver) defineComprehensionTarget(target ast.NodeID) {
 }
 
 func (r *Resolver) assignTargetType(target ast.NodeID, typ *Type) {
-	if target == ast.NoNode || IsUnknownType(typ) {
+	if target == ast.NoNode {
 		return
 	}
 	switch r.tree.Node(target).Kind {
 	case ast.NodeName:
-		if sym := r.Resolved[target]; sym != nil {
+		if sym := r.Resolved[target]; sym != nil && !IsUnknownType(typ) {
 			sym.Inferred = JoinTypes(sym.Inferred, typ)
+			r.setExprType(target, typ)
 		}
+	case ast.NodeAttribute:
+		r.bindAttributeTarget(target, typ)
 	case ast.NodeTuple, ast.NodeList:
-		for child := r.tree.Node(target).FirstChild; child != ast.NoNode; child = r.tree.Node(child).NextSibling {
-			r.assignTargetType(child, typ)
+		r.unpackTargets(r.tree.RangeOf(target), r.tree.Children(target), typ)
+	case ast.NodeStarArg:
+		r.error(r.tree.RangeOf(target), "starred assignment target must be in a list or tuple")
+	}
+}
+
+// unpackTargets distributes a value of type typ across the targets of an
+// unpacking assignment, reporting values that cannot be unpacked into them.
+func (r *Resolver) unpackTargets(span ast.Range, targets []ast.NodeID, typ *Type) {
+	star := -1
+	for i, target := range targets {
+		if r.tree.Node(target).Kind != ast.NodeStarArg {
+			continue
+		}
+		if star >= 0 {
+			r.error(r.tree.RangeOf(target), "multiple starred expressions in assignment")
+			return
+		}
+		star = i
+	}
+
+	types, n := unpackedTypes(typ, len(targets), star)
+	if n >= 0 && !unpackArityMatches(n, len(targets), star) {
+		switch {
+		case star >= 0:
+			r.error(span, fmt.Sprintf("not enough values to unpack (expected at least %d, got %d)", len(targets)-1, n))
+		case n > len(targets):
+			r.error(span, fmt.Sprintf("too many values to unpack (expected %d, got %d)", len(targets), n))
+		default:
+			r.error(span, fmt.Sprintf("not enough values to unpack (expected %d, got %d)", len(targets), n))
+		}
+		return
+	}
+	for i, target := range targets {
+		if i == star {
+			target = r.tree.ChildAt(target, 0)
+		}
+		r.assignTargetType(target, types[i])
+	}
+}
+
+// bindAttributeTarget records the type assigned to an attribute target that
+// visitExpr has queued in PendingAttrs.
+func (r *Resolver) bindAttributeTarget(target ast.NodeID, valueType *Type) {
+	if IsUnknownType(valueType) {
+		return
+	}
+	for i := len(r.PendingAttrs) - 1; i >= 0; i-- {
+		if r.PendingAttrs[i].Node == target {
+			r.PendingAttrs[i].ValueType = valueType
+			break
 		}
 	}
+
+	// Infer instance attribute for class-level attribute tracking
+	// When we see obj.attr = value, record that the class of obj has 'attr'
+	base := r.tree.ChildAt(target, 0)
+	attrNode := r.tree.ChildAt(target, 1)
+	if base == ast.NoNode || attrNode == ast.NoNode {
+		return
+	}
+	attrName, _ := r.tree.NameText(attrNode)
+	if attrName == "" {
+		return
+	}
+	baseType := r.exprType(base)
+	if baseType == nil {
+		return
+	}
+	switch baseType.Kind {
+	case TypeInstance, TypeClass:
+		r.recordInstanceAttr(baseType.Symbol, attrName, valueType)
+	}
 }
 
 func (r *Resolver) error(span ast.Range, msg string) {
@@ -1457,3 +2062,21 @@ func (r *Resolver) error(span ast.Range, msg string) {
 		Msg:  msg,
 	})
 }
+
+// inactive marks code the target interpreter never runs.
+func (r *Resolver) inactive(span ast.Range, msg string) {
+	r.errors = append(r.errors, SemanticError{
+		Span:        span,
+		Msg:         msg,
+		Severity:    SeverityHint,
+		Unnecessary: true,
+	})
+}
+
+func (r *Resolver) warning(span ast.Range, msg string) {
+	r.errors = append(r.errors, SemanticError{
+		Span:     span,
+		Msg:      msg,
+		Severity: SeverityWarning,
+	})
+}
@@ -4,6 +4,7 @@ import "rahu/parser/ast"
 
 type ScopeBuilder struct {
 	tree         *ast.AST
+	target       Target
 	source       string // Source text for extracting default values
 	currentClass *Symbol
 	current      *Scope
@@ -81,7 +82,16 @@ func (b *ScopeBuilder) define(scope *Scope, nameID ast.NodeID, kind SymbolKind,
 	return sym
 }
 
+// BuildScopes builds the scopes of tree without a target interpreter, so
+// that conditions on sys.version_info and sys.platform leave both branches
+// live.
 func BuildScopes(tree *ast.AST, source string) (*Scope, map[ast.NodeID]*Symbol) {
+	return BuildScopesForTarget(tree, source, Target{})
+}
+
+// BuildScopesForTarget builds the scopes of tree for the target
+// interpreter, leaving out definitions in if-branches it never runs.
+func BuildScopesForTarget(tree *ast.AST, source string, target Target) (*Scope, map[ast.NodeID]*Symbol) {
 	global := NewScope(builtinScope, ScopeGlobal)
 	defsCap := len(tree.Nodes) / 8
 	if defsCap < 8 {
@@ -89,6 +99,7 @@ func BuildScopes(tree *ast.AST, source string) (*Scope, map[ast.NodeID]*Symbol)
 	}
 	b := &ScopeBuilder{
 		tree:    tree,
+		target:  target,
 		source:  source,
 		current: global,
 		Defs:    make(map[ast.NodeID]*Symbol, defsCap),
@@ -140,6 +151,8 @@ func (b *ScopeBuilder) visitStmt(stmt ast.NodeID) {
 		b.visitExcept(stmt)
 	case ast.NodeWith:
 		b.visitWith(stmt)
+	case ast.NodeMatch:
+		b.visitMatch(stmt)
 	case ast.NodeExprStmt:
 		b.visitExpr(b.tree.Nodes[stmt].FirstChild)
 	case ast.NodeReturn:
@@ -225,29 +238,7 @@ func (b *ScopeBuilder) visitAugAssign(id ast.NodeID) {
 		b.define(b.current, target, SymVariable, b.tree.RangeOf(target))
 
 	case ast.NodeAttribute:
-		if b.currentClass != nil && b.inFunction {
-			base := b.tree.Nodes[target].FirstChild
-			attr := ast.NoNode
-			if base != ast.NoNode {
-				attr = b.tree.Nodes[base].NextSibling
-			}
-			baseName, _ := b.tree.NameText(base)
-			attrName, _ := b.tree.NameText(attr)
-			if b.tree.Node(base).Kind == ast.NodeName && baseName == b.selfName {
-				if b.currentClass.Attrs == nil {
-					b.currentClass.Attrs = NewScope(nil, ScopeAttr)
-				}
-				sym := &Symbol{
-					Name: attrName,
-					Kind: SymAttr,
-					Span: b.tree.RangeOf(attr),
-					Def:  attr,
-					ID:   b.newSymID(),
-				}
-				_ = b.currentClass.Attrs.Define(sym)
-				b.Defs[attr] = sym
-			}
-		}
+		b.defineSelfAttr(target)
 
 	case ast.NodeSubScript:
 		b.visitExpr(target)
@@ -330,7 +321,7 @@ func (b *ScopeBuilder) visitExpr(id ast.NodeID) {
 		b.visitExpr(left)
 		b.visitExpr(right)
 
-	case ast.NodeUnaryOp:
+	case ast.NodeUnaryOp, ast.NodeAwait:
 		b.visitExpr(b.tree.Nodes[id].FirstChild)
 
 	case ast.NodeCompare:
@@ -361,6 +352,13 @@ func (b *ScopeBuilder) defineTargetPattern(id ast.NodeID) {
 		for child := b.tree.Node(id).FirstChild; child != ast.NoNode; child = b.tree.Node(child).NextSibling {
 			b.defineTargetPattern(child)
 		}
+	case ast.NodeStarArg:
+		b.defineTargetPattern(b.tree.ChildAt(id, 0))
+	case ast.NodeAttribute:
+		b.defineSelfAttr(id)
+		b.visitExpr(id)
+	case ast.NodeSubScript:
+		b.visitExpr(id)
 	}
 }
 
@@ -442,21 +440,16 @@ func (b *ScopeBuilder) visitFor(id ast.NodeID) {
 	}
 }
 
+// visitIf binds both branches unless the test is decided by the target
+// interpreter, in which case the dead branch defines nothing.
 func (b *ScopeBuilder) visitIf(id ast.NodeID) {
-	test := b.tree.Nodes[id].FirstChild
-	body := ast.NoNode
-	if test != ast.NoNode {
-		body = b.tree.Nodes[test].NextSibling
-	}
-	for stmt := b.tree.Nodes[body].FirstChild; stmt != ast.NoNode; stmt = b.tree.Nodes[stmt].NextSibling {
-		b.visitStmt(stmt)
-	}
-
-	orelse := ast.NoNode
-	if body != ast.NoNode {
-		orelse = b.tree.Nodes[body].NextSibling
+	_, body, orelse, bodyLive, orelseLive := b.target.ifBranches(b.tree, id)
+	if bodyLive && body != ast.NoNode {
+		for stmt := b.tree.Nodes[body].FirstChild; stmt != ast.NoNode; stmt = b.tree.Nodes[stmt].NextSibling {
+			b.visitStmt(stmt)
+		}
 	}
-	if orelse != ast.NoNode {
+	if orelseLive && orelse != ast.NoNode {
 		for stmt := b.tree.Nodes[orelse].FirstChild; stmt != ast.NoNode; stmt = b.tree.Nodes[stmt].NextSibling {
 			b.visitStmt(stmt)
 		}
@@ -512,6 +505,67 @@ func (b *ScopeBuilder) visitWith(id ast.NodeID) {
 	}
 }
 
+func (b *ScopeBuilder) visitMatch(id ast.NodeID) {
+	subject, cases := b.tree.MatchParts(id)
+	b.visitExpr(subject)
+	for _, clause := range cases {
+		pattern, guard, body := b.tree.MatchCaseParts(clause)
+		b.definePatternCaptures(pattern)
+		b.visitExpr(guard)
+		for stmt := b.tree.Node(body).FirstChild; stmt != ast.NoNode; stmt = b.tree.Node(stmt).NextSibling {
+			b.visitStmt(stmt)
+		}
+	}
+}
+
+// definePatternCaptures defines the names a case pattern binds. Bare names
+// other than the wildcard _ are captures; dotted names, literals and the
+// class of a class pattern are values the pattern reads.
+func (b *ScopeBuilder) definePatternCaptures(id ast.NodeID) {
+	if id == ast.NoNode {
+		return
+	}
+	switch b.tree.Node(id).Kind {
+	case ast.NodeName:
+		if name, _ := b.tree.NameText(id); name != "_" {
+			b.define(b.current, id, SymVariable, b.tree.RangeOf(id))
+		}
+	case ast.NodeMatchAs:
+		b.definePatternCaptures(b.tree.ChildAt(id, 0))
+		b.definePatternCaptures(b.tree.ChildAt(id, 1))
+	case ast.NodeStarArg, ast.NodeKwStarArg:
+		b.definePatternCaptures(b.tree.ChildAt(id, 0))
+	case ast.NodeKeywordArg:
+		b.definePatternCaptures(b.tree.ChildAt(id, 1))
+	case ast.NodeCall:
+		cls := b.tree.Node(id).FirstChild
+		b.visitExpr(cls)
+		for arg := b.tree.Node(cls).NextSibling; arg != ast.NoNode; arg = b.tree.Node(arg).NextSibling {
+			b.definePatternCaptures(arg)
+		}
+	case ast.NodeDict:
+		isKey := true
+		for child := b.tree.Node(id).FirstChild; child != ast.NoNode; child = b.tree.Node(child).NextSibling {
+			if b.tree.Node(child).Kind == ast.NodeKwStarArg {
+				b.definePatternCaptures(child)
+				continue
+			}
+			if isKey {
+				b.visitExpr(child)
+			} else {
+				b.definePatternCaptures(child)
+			}
+			isKey = !isKey
+		}
+	case ast.NodeTuple, ast.NodeList, ast.NodeBinOp:
+		for child := b.tree.Node(id).FirstChild; child != ast.NoNode; child = b.tree.Node(child).NextSibling {
+			b.definePatternCaptures(child)
+		}
+	default:
+		b.visitExpr(id)
+	}
+}
+
 func (b *ScopeBuilder) visitAssign(id ast.NodeID) {
 	firstValue := b.tree.Nodes[id].FirstChild
 	value := firstValue
@@ -523,43 +577,27 @@ func (b *ScopeBuilder) visitAssign(id ast.NodeID) {
 
 		switch b.tree.Node(target).Kind {
 		case ast.NodeName:
+			if firstValue == value && isNewTypeDeclaration(b.tree, firstValue) {
+				b.defineNewType(target)
+				break
+			}
 			sym := b.define(b.current, target, SymVariable, b.tree.RangeOf(target))
 			if sym != nil {
-				sym.DefaultValue = b.extractValue(firstValue)
+				if b.current.Kind == ScopeClass {
+					sym.DefaultValue = b.fieldDefaultText(firstValue)
+				} else {
+					sym.DefaultValue = b.extractValue(firstValue)
+				}
 			}
 
 		case ast.NodeAttribute:
-			if b.currentClass == nil || !b.inFunction {
-				break
-			}
-
-			base := b.tree.Nodes[target].FirstChild
-			attr := ast.NoNode
-			if base != ast.NoNode {
-				attr = b.tree.Nodes[base].NextSibling
-			}
-			baseName, _ := b.tree.NameText(base)
-			attrName, _ := b.tree.NameText(attr)
-			if b.tree.Node(base).Kind != ast.NodeName || baseName != b.selfName {
-				break
-			}
-
-			if b.currentClass.Attrs == nil {
-				b.currentClass.Attrs = NewScope(nil, ScopeAttr)
-			}
-
-			sym := &Symbol{
-				Name: attrName,
-				Kind: SymAttr,
-				Span: b.tree.RangeOf(attr),
-				Def:  attr,
-				ID:   b.newSymID(),
-			}
-			_ = b.currentClass.Attrs.Define(sym)
-			b.Defs[attr] = sym
+			b.defineSelfAttr(target)
 
 		case ast.NodeSubScript:
 			b.visitExpr(target)
+
+		case ast.NodeTuple, ast.NodeList, ast.NodeStarArg:
+			b.defineTargetPattern(target)
 		}
 
 		value = target
@@ -570,22 +608,93 @@ func (b *ScopeBuilder) visitAssign(id ast.NodeID) {
 	}
 }
 
+// defineNewType binds `Name = NewType("Name", base)` as a class with no
+// members of its own; the resolver fills in its base.
+func (b *ScopeBuilder) defineNewType(target ast.NodeID) {
+	sym := b.define(b.current, target, SymClass, b.tree.RangeOf(target))
+	if sym == nil {
+		return
+	}
+	sym.Inner = NewScope(b.current, ScopeClass)
+	sym.Inner.Owner = sym
+}
+
 func (b *ScopeBuilder) visitAnnAssign(id ast.NodeID) {
 	target, annotation, value := b.tree.AnnAssignParts(id)
 	if target == ast.NoNode {
 		return
 	}
 
-	if b.tree.Node(target).Kind == ast.NodeName {
-		sym := b.define(b.current, target, SymVariable, b.tree.RangeOf(target))
+	final, classVar := annotationQualifiers(b.tree, annotation)
+	switch b.tree.Node(target).Kind {
+	case ast.NodeName:
+		kind := SymVariable
+		if final {
+			kind = SymConstant
+		}
+		sym := b.define(b.current, target, kind, b.tree.RangeOf(target))
+		if sym != nil {
+			sym.Final = final
+			sym.ClassVar = classVar && b.current.Kind == ScopeClass
+		}
 		if sym != nil && value != ast.NoNode {
-			sym.DefaultValue = b.extractValue(value)
+			if b.current.Kind == ScopeClass {
+				sym.DefaultValue = b.fieldDefaultText(value)
+			} else {
+				sym.DefaultValue = b.extractValue(value)
+			}
 		}
+
+	case ast.NodeAttribute:
+		if sym := b.defineSelfAttr(target); sym != nil && final {
+			sym.Final = true
+		}
+
+	case ast.NodeSubScript:
+		b.visitExpr(target)
 	}
 	b.visitExpr(annotation)
 	b.visitExpr(value)
 }
 
+// defineSelfAttr records an assignment to self.<attr> inside a method as an
+// instance attribute of the enclosing class. It returns the attribute symbol
+// registered under that name, which is the earliest assignment's, and adds
+// the site to its Assignments.
+func (b *ScopeBuilder) defineSelfAttr(target ast.NodeID) *Symbol {
+	if b.currentClass == nil || !b.inFunction {
+		return nil
+	}
+
+	base := b.tree.Nodes[target].FirstChild
+	attr := ast.NoNode
+	if base != ast.NoNode {
+		attr = b.tree.Nodes[base].NextSibling
+	}
+	baseName, _ := b.tree.NameText(base)
+	attrName, _ := b.tree.NameText(attr)
+	if b.tree.Node(base).Kind != ast.NodeName || baseName != b.selfName {
+		return nil
+	}
+
+	if b.currentClass.Attrs == nil {
+		b.currentClass.Attrs = NewScope(nil, ScopeAttr)
+	}
+
+	sym := &Symbol{
+		Name: attrName,
+		Kind: SymAttr,
+		Span: b.tree.RangeOf(attr),
+		Def:  attr,
+		ID:   b.newSymID(),
+	}
+	_ = b.currentClass.Attrs.Define(sym)
+	b.Defs[attr] = sym
+	registered := b.currentClass.Attrs.Symbols[attrName]
+	registered.Assignments = append(registered.Assignments, sym.Span)
+	return registered
+}
+
 func (b *ScopeBuilder) visitClassDef(id ast.NodeID) {
 	for _, decorator := range b.tree.Decorators(id) {
 		b.visitExpr(b.tree.DecoratorExpr(decorator))
@@ -607,6 +716,7 @@ func (b *ScopeBuilder) visitClassDef(id ast.NodeID) {
 		Def:  name,
 	}
 	classScope.Owner = classSym
+	classSym.Decorated = b.hasOpaqueDecorator(id)
 	_ = b.current.Define(classSym)
 	b.Defs[name] = classSym
 
@@ -649,8 +759,43 @@ func (b *ScopeBuilder) visitFunctionDef(id ast.NodeID) {
 	}
 
 	fnScope.Owner = fnSym
-
-	if err := b.current.Define(fnSym); err != nil {
+	fnSym.Decorated = b.hasOpaqueDecorator(id)
+	fnSym.Final = hasDecoratorIn(b.tree, id, finalDecorators)
+	fnSym.Abstract = hasDecoratorIn(b.tree, id, abstractDecorators)
+
+	var property *Symbol
+	if b.current.Kind == ScopeClass {
+		fnSym.Method, property = b.methodKind(id, nameText)
+	}
+
+	overloaded := isOverloadDecorated(b.tree, id)
+	prior, _ := b.current.LookupLocal(nameText)
+	if prior != nil && (prior.Kind != SymFunction || len(prior.Overloads) == 0) {
+		prior = nil
+	}
+
+	if property != nil {
+		// @name.setter and @name.deleter extend the property instead of
+		// redefining it.
+		property.Accessors = append(property.Accessors, fnSym)
+	} else if prior != nil && overloaded {
+		fnSym.Scope = b.current
+		prior.Overloads = append(prior.Overloads, fnSym)
+	} else if prior != nil {
+		// The implementation following a run of @overload stubs takes
+		// over the name and keeps the collected signatures.
+		fnSym.Scope = b.current
+		fnSym.Overloads = prior.Overloads
+		fnSym.Final = fnSym.Final || prior.Final
+		fnSym.Abstract = fnSym.Abstract || prior.Abstract
+		b.current.Symbols[nameText] = fnSym
+	} else if overloaded {
+		fnSym.Overloads = []*Symbol{fnSym}
+		if err := b.current.Define(fnSym); err != nil {
+			delete(b.current.Symbols, nameText)
+			_ = b.current.Define(fnSym)
+		}
+	} else if err := b.current.Define(fnSym); err != nil {
 		// Allow function definitions to shadow existing symbols in module scope only
 		// This handles fallback patterns like: try: from x import y; except: def y(): ...
 		if b.current.Kind == ScopeGlobal {
@@ -662,7 +807,7 @@ func (b *ScopeBuilder) visitFunctionDef(id ast.NodeID) {
 
 	fnSym.Inner = fnScope
 	prevSelf := b.selfName
-	if b.current.Kind == ScopeClass && args != ast.NoNode {
+	if b.current.Kind == ScopeClass && args != ast.NoNode && fnSym.Method != MethodStaticMethod {
 		firstParam := b.tree.Nodes[args].FirstChild
 		paramName, _, _ := b.tree.ParamParts(firstParam)
 		b.selfName, _ = b.tree.NameText(paramName)
@@ -680,11 +825,12 @@ func (b *ScopeBuilder) visitFunctionDef(id ast.NodeID) {
 			sym := b.define(b.current, paramName, SymParameter, b.tree.RangeOf(paramName))
 			if sym != nil && def != ast.NoNode {
 				sym.DefaultValue = b.extractValue(def)
+			}
+			if sym != nil {
 				sym.IsVarArg = b.tree.ParamIsVarArg(arg)
 				sym.IsKwArg = b.tree.ParamIsKwArg(arg)
-			} else if sym != nil {
-				sym.IsVarArg = b.tree.ParamIsVarArg(arg)
-				sym.IsKwArg = b.tree.ParamIsKwArg(arg)
+				sym.IsKwOnly = b.tree.ParamIsKwOnly(arg)
+				sym.IsPosOnly = b.tree.ParamIsPosOnly(arg)
 			}
 			if annotation != ast.NoNode {
 				b.visitExpr(annotation)
@@ -703,3 +849,55 @@ func (b *ScopeBuilder) visitFunctionDef(id ast.NodeID) {
 	b.selfName = prevSelf
 	b.inFunction = prevInFunc
 }
+
+// methodKind classifies a method from its decorators. For property accessors
+// declared as @name.setter or @name.deleter it also returns the property.
+// signaturePreservingDecorators leave the call signature of the function or
+// class they decorate unchanged.
+var signaturePreservingDecorators = map[string]bool{
+	"staticmethod": true, "classmethod": true,
+	"property": true, "cached_property": true, "functools.cached_property": true,
+	"abstractmethod": true, "abc.abstractmethod": true, "abc.abstractproperty": true,
+	"overload": true, "typing.overload": true, "typing_extensions.overload": true,
+	"final": true, "typing.final": true, "typing_extensions.final": true,
+	"override": true, "typing.override": true, "typing_extensions.override": true,
+	"functools.cache": true, "cache": true, "functools.total_ordering": true, "total_ordering": true,
+	"runtime_checkable": true, "typing.runtime_checkable": true,
+	"unique": true, "enum.unique": true,
+}
+
+// hasOpaqueDecorator reports whether a def or class carries a decorator that
+// may replace its call signature. Property accessors count as preserving.
+func (b *ScopeBuilder) hasOpaqueDecorator(id ast.NodeID) bool {
+	for _, decorator := range b.tree.Decorators(id) {
+		dotted := exprDottedName(b.tree, b.tree.DecoratorExpr(decorator))
+		if signaturePreservingDecorators[dotted] {
+			continue
+		}
+		switch lastDottedSegment(dotted) {
+		case "setter", "getter", "deleter":
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func (b *ScopeBuilder) methodKind(id ast.NodeID, name string) (MethodKind, *Symbol) {
+	for _, decorator := range b.tree.Decorators(id) {
+		dotted := exprDottedName(b.tree, b.tree.DecoratorExpr(decorator))
+		switch dotted {
+		case "property", "cached_property", "functools.cached_property", "abc.abstractproperty":
+			return MethodProperty, nil
+		case "classmethod":
+			return MethodClassMethod, nil
+		case "staticmethod":
+			return MethodStaticMethod, nil
+		case name + ".setter", name + ".deleter", name + ".getter":
+			if property, ok := b.current.Symbols[name]; ok && property.Method == MethodProperty {
+				return MethodProperty, property
+			}
+		}
+	}
+	return MethodPlain, nil
+}
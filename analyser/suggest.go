@@ -0,0 +1,84 @@
+package analyser
+
+// SuggestName attaches to err, reported for a misspelled name, the
+// candidate closest to it. The candidate becomes the replacement a quick fix
+// offers for the span of err; the message is left as it is.
+func SuggestName(err SemanticError, name string, candidates []string) SemanticError {
+	err.Suggestion = closestName(name, candidates)
+	return err
+}
+
+// ConfidentSuggestion reports whether suggestion is close enough to name to
+// be applied without a second look: at most one edit for every four
+// characters, so short names never qualify.
+func ConfidentSuggestion(name, suggestion string) bool {
+	return suggestion != "" && editDistance(name, suggestion)*4 <= len(name)
+}
+
+// visibleNames lists the names a lookup from scope can find.
+func visibleNames(scope *Scope) []string {
+	var names []string
+	for ; scope != nil; scope = scope.Parent {
+		for name := range scope.Symbols {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// minSuggestLength is the shortest name a suggestion is offered for, or
+// offered as: one or two letter names are all within an edit or two of each
+// other, so any match between them is a guess.
+const minSuggestLength = 3
+
+// closestName returns the candidate nearest to name by edit distance, or ""
+// when none is close enough to be a plausible typo. Ties go to the
+// lexicographically smaller candidate so suggestions are stable.
+func closestName(name string, candidates []string) string {
+	if len(name) < minSuggestLength {
+		return ""
+	}
+	limit := max(1, len(name)/3)
+	best := ""
+	bestDist := limit + 1
+	for _, candidate := range candidates {
+		if candidate == name || len(candidate) < minSuggestLength {
+			continue
+		}
+		dist := editDistance(name, candidate)
+		if dist < bestDist || (dist == bestDist && candidate < best) {
+			best = candidate
+			bestDist = dist
+		}
+	}
+	if bestDist > limit {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b, counting an
+// adjacent transposition as a single edit.
+func editDistance(a, b string) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
@@ -43,6 +43,19 @@ const (
 	TypeTuple
 	TypeDict
 	TypeSet
+	TypeVariable
+	TypeCallable
+)
+
+// MethodKind distinguishes the descriptor flavours of functions defined in a
+// class body.
+type MethodKind int
+
+const (
+	MethodPlain MethodKind = iota
+	MethodProperty
+	MethodClassMethod
+	MethodStaticMethod
 )
 
 type Type struct {
@@ -52,27 +65,65 @@ type Type struct {
 	Elem   *Type
 	Items  []*Type
 	Key    *Type
+	Args   []*Type // Type arguments of a parameterized class, e.g. Iterator[int]
+
+	// Literal is the enum member an instance type is narrowed to, as in
+	// Literal[Color.RED].
+	Literal *Symbol
+
+	// Callable types: leading parameters, a ParamSpec forwarding the rest,
+	// and the return type. Params is nil when they are unknown (...).
+	Params  []*Symbol
+	Spec    *Type
+	Returns *Type
+
+	// AliasRef is the type alias a placeholder stands for when the alias
+	// refers to itself, as in JSON = dict[str, "JSON"].
+	AliasRef *Symbol
 }
 
 type Symbol struct {
-	Name         string
-	Kind         SymbolKind
-	Span         ast.Range
-	Scope        *Scope
-	Inner        *Scope
-	Attrs        *Scope
-	Members      *Scope
-	Bases        []*Symbol
-	InstanceOf   *Symbol
-	Inferred     *Type
-	Returns      *Type
-	DocString    string
-	DefaultValue string // Text representation of default/initial value
-	IsVarArg     bool
-	IsKwArg      bool
-	Def          ast.NodeID
-	ID           SymbolID
-	URI          lsp.DocumentURI
+	Name               string
+	Kind               SymbolKind
+	Span               ast.Range
+	Scope              *Scope
+	Inner              *Scope
+	Attrs              *Scope
+	Members            *Scope
+	Bases              []*Symbol
+	InstanceOf         *Symbol
+	Inferred           *Type
+	Returns            *Type
+	DocString          string
+	DefaultValue       string // Text representation of default/initial value
+	IsVarArg           bool
+	IsKwArg            bool
+	IsKwOnly           bool
+	IsPosOnly          bool
+	Decorated          bool           // Wrapped by a decorator that may change its signature
+	DynamicMembers     bool           // Class attributes cannot be enumerated statically
+	Params             []*Symbol      // Explicit parameter order for synthesized callables
+	Dataclass          *DataclassInfo // Synthesized fields for dataclass-like classes
+	Enum               *EnumInfo      // Members of an enum.Enum subclass
+	DataclassTransform bool           // Declared with @dataclass_transform
+	Protocol           bool           // Lists typing.Protocol among its bases
+	RuntimeCheckable   bool           // Protocol usable with isinstance()
+	Final              bool           // Annotated Final, or a method decorated @final
+	Abstract           bool           // Method decorated @abstractmethod
+	ABC                bool           // Class created by ABCMeta, directly or through a base
+	Metaclass          *Symbol        // Class given as metaclass=, when it resolves
+	ClassVar           bool           // Class-body name annotated ClassVar
+	Method             MethodKind
+	Accessors          []*Symbol   // Property setter/deleter definitions sharing this name
+	Assignments        []ast.Range // Every self.<attr> site of an instance attribute, in source order
+	Overloads          []*Symbol   // @overload signatures, in declaration order
+	ReturnsSelf        bool        // Return annotation is typing.Self
+	TypeAlias          *Type       // Expansion of a type alias declared by this name
+	Def                ast.NodeID
+	ID                 SymbolID
+	URI                lsp.DocumentURI
+
+	ancestors []*Symbol // C3 linearization without the class itself, cached on promotion
 }
 
 type ScopeKind int
@@ -332,6 +383,12 @@ func NewBuiltinScopeFromCache(symbols []struct {
 		}
 	}
 
+	// typeshed has no builtin NoneType, but the analyser uses it as the type
+	// of None, as the hardcoded scope does.
+	if _, ok := s.LookupLocal("NoneType"); !ok {
+		_ = s.Define(&Symbol{Name: "NoneType", Kind: SymType, Span: ast.Range{}})
+	}
+
 	// Second pass: set up inheritance for classes
 	for _, sym := range symbols {
 		if sym.Kind == "class" && len(sym.Bases) > 0 {
@@ -443,6 +500,31 @@ func TupleType(items ...*Type) *Type {
 	return &Type{Kind: TypeTuple, Items: items}
 }
 
+// TypeVarType returns the type variable declared by sym, a TypeVar or
+// ParamSpec assignment.
+func TypeVarType(sym *Symbol) *Type {
+	return &Type{Kind: TypeVariable, Symbol: sym}
+}
+
+// CallableType returns a callable taking params and returning returns.
+func CallableType(params []*Symbol, returns *Type) *Type {
+	return &Type{Kind: TypeCallable, Params: params, Returns: returns}
+}
+
+// VarTupleType returns tuple[elem, ...], a tuple of unknown length. Its
+// element type is kept in Elem and Items stays empty.
+func VarTupleType(elem *Type) *Type {
+	if elem == nil {
+		elem = UnknownType()
+	}
+	return &Type{Kind: TypeTuple, Elem: elem}
+}
+
+// IsFixedTuple reports whether t is a tuple whose length is known.
+func IsFixedTuple(t *Type) bool {
+	return t != nil && t.Kind == TypeTuple && t.Elem == nil && len(t.Items) > 0
+}
+
 func DictType(key, value *Type) *Type {
 	if key == nil {
 		key = UnknownType()
@@ -471,11 +553,22 @@ func SameType(a, b *Type) bool {
 	case TypeUnknown:
 		return true
 	case TypeInstance, TypeClass, TypeModule, TypeBuiltin:
-		return a.Symbol == b.Symbol
+		if a.Symbol != b.Symbol || a.Literal != b.Literal || len(a.Args) != len(b.Args) {
+			return false
+		}
+		for i := range a.Args {
+			if !SameType(a.Args[i], b.Args[i]) {
+				return false
+			}
+		}
+		return true
 	case TypeList:
 		return SameType(a.Elem, b.Elem)
 	case TypeTuple:
-		if len(a.Items) != len(b.Items) {
+		if len(a.Items) != len(b.Items) || (a.Elem == nil) != (b.Elem == nil) {
+			return false
+		}
+		if a.Elem != nil && !SameType(a.Elem, b.Elem) {
 			return false
 		}
 		for i := range a.Items {
@@ -488,6 +581,18 @@ func SameType(a, b *Type) bool {
 		return SameType(a.Key, b.Key) && SameType(a.Elem, b.Elem)
 	case TypeSet:
 		return SameType(a.Elem, b.Elem)
+	case TypeVariable:
+		return a.Symbol == b.Symbol
+	case TypeCallable:
+		if len(a.Params) != len(b.Params) || (a.Params == nil) != (b.Params == nil) {
+			return false
+		}
+		for i := range a.Params {
+			if !SameType(SymbolType(a.Params[i]), SymbolType(b.Params[i])) {
+				return false
+			}
+		}
+		return SameType(a.Spec, b.Spec) && SameType(a.Returns, b.Returns)
 	case TypeUnion:
 		if len(a.Union) != len(b.Union) {
 			return false
@@ -564,6 +669,11 @@ func SymbolType(sym *Symbol) *Type {
 	if sym == nil {
 		return nil
 	}
+	if sym.Kind == SymFunction && sym.Method == MethodProperty && !IsUnknownType(sym.Returns) {
+		// Reading a property on an instance yields the getter's return
+		// type; ReferenceType handles reads through the class.
+		return sym.Returns
+	}
 	if sym.Inferred != nil && !IsUnknownType(sym.Inferred) {
 		return sym.Inferred
 	}
@@ -578,7 +688,7 @@ func SymbolType(sym *Symbol) *Type {
 		return ClassType(sym)
 	case SymModule:
 		return ModuleType(sym)
-	case SymType, SymConstant:
+	case SymType:
 		return BuiltinType(sym)
 	default:
 		if sym.Scope != nil && sym.Scope.Kind == ScopeBuiltin {
@@ -634,6 +744,13 @@ func MemberScopeForType(t *Type) *Scope {
 				}
 			}
 		}
+		for _, base := range MRO(t.Symbol)[1:] {
+			ownMembers(base, func(name string, sym *Symbol) {
+				if _, exists := merged.Symbols[name]; !exists {
+					merged.Symbols[name] = sym
+				}
+			})
+		}
 		if len(merged.Symbols) == 0 {
 			return nil
 		}
@@ -688,11 +805,20 @@ func SubscriptResultType(t *Type) *Type {
 		return nil
 	}
 	switch t.Kind {
+	case TypeClass:
+		// Cls["NAME"] looks up a member of an enumeration.
+		if t.Symbol != nil && t.Symbol.Enum != nil {
+			return InstanceType(t.Symbol)
+		}
+		return nil
 	case TypeList:
 		return t.Elem
 	case TypeDict:
 		return t.Elem
 	case TypeTuple:
+		if t.Elem != nil {
+			return t.Elem
+		}
 		if len(t.Items) == 0 {
 			return UnknownType()
 		}
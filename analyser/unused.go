@@ -0,0 +1,239 @@
+package analyser
+
+import (
+	"sort"
+	"strings"
+
+	"rahu/parser/ast"
+)
+
+// importBinding is a name bound by an import statement. Binding the import
+// later rewrites the symbol in place, so its name and scope are remembered
+// as they were at the import.
+type importBinding struct {
+	bound  ast.NodeID
+	name   string
+	sym    *Symbol
+	module bool // bound at module level
+}
+
+// markRead records that sym is read at offset.
+func (r *Resolver) markRead(sym *Symbol, offset uint32) {
+	if sym != nil {
+		r.reads[sym] = append(r.reads[sym], offset)
+	}
+}
+
+// markNamesRead records the names used by an annotation parsed from a
+// string at offset, which are looked up without being resolved one by one.
+func (r *Resolver) markNamesRead(subTree *ast.AST, offset uint32) {
+	for id := ast.NodeID(1); int(id) < len(subTree.Nodes); id++ {
+		if subTree.Node(id).Kind != ast.NodeName {
+			continue
+		}
+		if name, ok := subTree.NameText(id); ok {
+			if sym, ok := r.current.Lookup(name); ok {
+				r.markRead(sym, offset)
+			}
+		}
+	}
+}
+
+// recordImports remembers the symbols an import statement binds in the
+// current scope. Imports from __future__ and redundant aliases such as
+// `import x as x`, the convention for an explicit re-export, are left out.
+func (r *Resolver) recordImports(stmt ast.NodeID) {
+	var aliases []ast.NodeID
+	if r.tree.Node(stmt).Kind == ast.NodeFromImport {
+		module, fromAliases := r.tree.FromImportParts(stmt)
+		if name, _ := r.tree.NameText(module); name == "__future__" {
+			return
+		}
+		aliases = fromAliases
+	} else {
+		aliases = r.tree.Children(stmt)
+	}
+	for _, alias := range aliases {
+		target, asName := r.tree.AliasParts(alias)
+		bound := asName
+		switch {
+		case asName != ast.NoNode:
+			if exprDottedName(r.tree, target) == exprDottedName(r.tree, asName) {
+				continue
+			}
+		case r.tree.Node(stmt).Kind == ast.NodeFromImport:
+			bound = target
+		default:
+			bound = importBoundName(r.tree, target)
+		}
+		name, ok := r.tree.NameText(bound)
+		if !ok || name == "*" {
+			continue
+		}
+		if sym := r.current.Symbols[name]; sym != nil {
+			r.imports = append(r.imports, importBinding{bound: bound, name: name, sym: sym, module: r.current == r.global})
+		}
+	}
+}
+
+// UnusedDiagnostics reports imports, local variables and parameters of
+// private functions that are never read, as hints that editors fade out.
+// Names starting with an underscore are exempt, as are module-level imports
+// listed in __all__ and, when packageInit is set, every module-level import
+// of an __init__.py, which are taken to be re-exports.
+func (r *Resolver) UnusedDiagnostics(packageInit bool) []SemanticError {
+	var out []SemanticError
+	report := func(span ast.Range, msg string) {
+		out = append(out, SemanticError{Span: span, Msg: msg, Severity: SeverityHint, Unnecessary: true})
+	}
+
+	exported := make(map[string]bool)
+	for _, name := range AllNames(r.tree, r.target, nil) {
+		exported[name] = true
+	}
+	reported := make(map[*Symbol]bool)
+	for _, imp := range r.imports {
+		if len(r.reads[imp.sym]) > 0 || reported[imp.sym] || strings.HasPrefix(imp.name, "_") {
+			continue
+		}
+		if imp.module && (packageInit || exported[imp.name]) {
+			continue
+		}
+		reported[imp.sym] = true
+		report(r.tree.RangeOf(imp.bound), "unused import: "+imp.name)
+	}
+
+	for id := ast.NodeID(1); int(id) < len(r.tree.Nodes); id++ {
+		if r.tree.Node(id).Kind != ast.NodeFunctionDef {
+			continue
+		}
+		nameID, args, _, body := r.tree.FunctionPartsWithReturn(id)
+		fn := r.Resolved[nameID]
+		if fn == nil || fn.Inner == nil {
+			continue
+		}
+		declared := outerDeclarations(r.tree, body)
+		idiomatic := idiomaticTargets(r.tree, body)
+		checkParams := isPrivateName(fn.Name) && !isStubBody(r.tree, body) && !fn.Abstract
+		implicit := implicitFirstParam(r.tree, fn, args)
+
+		locals := make([]*Symbol, 0, len(fn.Inner.Symbols))
+		for _, sym := range fn.Inner.Symbols {
+			locals = append(locals, sym)
+		}
+		sort.Slice(locals, func(i, j int) bool { return locals[i].Span.Start < locals[j].Span.Start })
+		for _, sym := range locals {
+			if sym.Scope != fn.Inner || len(r.reads[sym]) > 0 || strings.HasPrefix(sym.Name, "_") || declared[sym.Name] {
+				continue
+			}
+			switch sym.Kind {
+			case SymVariable:
+				if !idiomatic[sym.Name] {
+					report(sym.Span, "unused variable: "+sym.Name)
+				}
+			case SymParameter:
+				if checkParams && sym.Name != implicit {
+					report(sym.Span, "unused parameter: "+sym.Name)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// isPrivateName reports a name private to its module or class, excluding
+// dunder names.
+func isPrivateName(name string) bool {
+	return strings.HasPrefix(name, "_") && !(strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__"))
+}
+
+// implicitFirstParam returns the name of the self or cls parameter of a
+// method, or "" for functions that have none.
+func implicitFirstParam(tree *ast.AST, fn *Symbol, args ast.NodeID) string {
+	if fn.Scope == nil || (fn.Scope.Kind != ScopeClass && fn.Scope.Kind != ScopeMember) ||
+		fn.Method == MethodStaticMethod || args == ast.NoNode {
+		return ""
+	}
+	first := tree.Node(args).FirstChild
+	if first == ast.NoNode {
+		return ""
+	}
+	paramName, _, _ := tree.ParamParts(first)
+	name, _ := tree.NameText(paramName)
+	return name
+}
+
+// outerDeclarations collects the names a function body declares global or
+// nonlocal, whose assignments are not local variables.
+func outerDeclarations(tree *ast.AST, body ast.NodeID) map[string]bool {
+	names := make(map[string]bool)
+	var walk func(ast.NodeID)
+	walk = func(node ast.NodeID) {
+		for child := tree.Node(node).FirstChild; child != ast.NoNode; child = tree.Node(child).NextSibling {
+			switch tree.Node(child).Kind {
+			case ast.NodeGlobal, ast.NodeNonlocal:
+				for name := tree.Node(child).FirstChild; name != ast.NoNode; name = tree.Node(name).NextSibling {
+					if text, ok := tree.NameText(name); ok {
+						names[text] = true
+					}
+				}
+			case ast.NodeFunctionDef, ast.NodeClassDef:
+			default:
+				walk(child)
+			}
+		}
+	}
+	walk(body)
+	return names
+}
+
+// idiomaticTargets collects the names a function body binds as for-loop
+// targets or with ... as and except ... as names, which Python requires
+// even when they are never read.
+func idiomaticTargets(tree *ast.AST, body ast.NodeID) map[string]bool {
+	var names []string
+	var walk func(ast.NodeID)
+	walk = func(node ast.NodeID) {
+		for child := tree.Node(node).FirstChild; child != ast.NoNode; child = tree.Node(child).NextSibling {
+			switch tree.Node(child).Kind {
+			case ast.NodeFor:
+				names = appendTargetNames(tree, names, tree.Node(child).FirstChild)
+			case ast.NodeWithItem:
+				_, asTarget := tree.WithItemParts(child)
+				names = appendTargetNames(tree, names, asTarget)
+			case ast.NodeExcept:
+				_, asName, _ := tree.ExceptParts(child)
+				names = appendTargetNames(tree, names, asName)
+			case ast.NodeFunctionDef, ast.NodeClassDef:
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(body)
+
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// isStubBody reports a function body that only holds a docstring, pass,
+// `...` or a raise, whose parameters document an interface.
+func isStubBody(tree *ast.AST, body ast.NodeID) bool {
+	for stmt := tree.Node(body).FirstChild; stmt != ast.NoNode; stmt = tree.Node(stmt).NextSibling {
+		switch tree.Node(stmt).Kind {
+		case ast.NodePass, ast.NodeRaise:
+		case ast.NodeExprStmt:
+			switch tree.Node(tree.Node(stmt).FirstChild).Kind {
+			case ast.NodeEllipsis, ast.NodeString:
+			default:
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -10,11 +10,28 @@ const (
 	SeverityHint
 )
 
+type DiagnosticTag int
+
+const (
+	_ DiagnosticTag = iota
+	DiagnosticTagUnnecessary
+	DiagnosticTagDeprecated
+)
+
 type Diagnostic struct {
-	Range    Range    `json:"range"`
-	Severity Severity `json:"severity,omitempty"`
-	Code     any      `json:"code,omitempty"`
-	Source   string   `json:"source,omitempty"`
+	Range    Range           `json:"range"`
+	Severity Severity        `json:"severity,omitempty"`
+	Code     any             `json:"code,omitempty"`
+	Source   string          `json:"source,omitempty"`
+	Message  string          `json:"message"`
+	Tags     []DiagnosticTag `json:"tags,omitempty"`
+
+	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
+	Data               any                            `json:"data,omitempty"`
+}
+
+type DiagnosticRelatedInformation struct {
+	Location Location `json:"location"`
 	Message  string   `json:"message"`
 }
 
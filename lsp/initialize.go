@@ -42,7 +42,9 @@ type ServerCapabilities struct {
 	SemanticTokensProvider  any                  `json:"semanticTokensProvider,omitempty"`
 	DefinitionProvider      bool                 `json:"definitionProvider"`
 	ReferencesProvider      bool                 `json:"referencesProvider,omitempty"`
+	ImplementationProvider  bool                 `json:"implementationProvider,omitempty"`
 	RenameProvider          any                  `json:"renameProvider,omitempty"`
 	DocumentSymbolProvider  bool                 `json:"documentSymbolProvider,omitempty"`
 	WorkspaceSymbolProvider bool                 `json:"workspaceSymbolProvider,omitempty"`
+	CodeActionProvider      any                  `json:"codeActionProvider,omitempty"`
 }
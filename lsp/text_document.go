@@ -114,6 +114,11 @@ type DefinitionParams struct {
 	Position           Position               `json:"position"`
 }
 
+type ImplementationParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
 type DocumentSymbolParams struct {
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
 }
@@ -137,6 +142,31 @@ type ReferenceParams struct {
 	Context      ReferenceContext       `json:"context"`
 }
 
+type CodeActionKind string
+
+const (
+	CodeActionQuickFix CodeActionKind = "quickfix"
+)
+
+type CodeActionContext struct {
+	Diagnostics []Diagnostic     `json:"diagnostics"`
+	Only        []CodeActionKind `json:"only,omitempty"`
+}
+
+type CodeActionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Context      CodeActionContext      `json:"context"`
+}
+
+type CodeAction struct {
+	Title       string         `json:"title"`
+	Kind        CodeActionKind `json:"kind,omitempty"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	IsPreferred bool           `json:"isPreferred,omitempty"`
+	Edit        *WorkspaceEdit `json:"edit,omitempty"`
+}
+
 type RenameParams struct {
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
 	Position     Position               `json:"position"`
@@ -168,8 +198,9 @@ type ParameterInformation struct {
 }
 
 type SignatureInformation struct {
-	Label      string                 `json:"label"`
-	Parameters []ParameterInformation `json:"parameters,omitempty"`
+	Label           string                 `json:"label"`
+	Parameters      []ParameterInformation `json:"parameters,omitempty"`
+	ActiveParameter int                    `json:"activeParameter,omitempty"`
 }
 
 type SignatureHelp struct {
@@ -194,14 +225,16 @@ type SemanticTokens struct {
 type CompletionItemKind int
 
 const (
-	CompletionItemKindText     CompletionItemKind = 1
-	CompletionItemKindMethod   CompletionItemKind = 2
-	CompletionItemKindFunction CompletionItemKind = 3
-	CompletionItemKindClass    CompletionItemKind = 7
-	CompletionItemKindModule   CompletionItemKind = 9
-	CompletionItemKindVariable CompletionItemKind = 6
-	CompletionItemKindField    CompletionItemKind = 5
-	CompletionItemKindConstant CompletionItemKind = 21
+	CompletionItemKindText       CompletionItemKind = 1
+	CompletionItemKindMethod     CompletionItemKind = 2
+	CompletionItemKindFunction   CompletionItemKind = 3
+	CompletionItemKindClass      CompletionItemKind = 7
+	CompletionItemKindModule     CompletionItemKind = 9
+	CompletionItemKindVariable   CompletionItemKind = 6
+	CompletionItemKindField      CompletionItemKind = 5
+	CompletionItemKindProperty   CompletionItemKind = 10
+	CompletionItemKindEnumMember CompletionItemKind = 20
+	CompletionItemKindConstant   CompletionItemKind = 21
 )
 
 type CompletionItem struct {
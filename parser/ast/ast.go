@@ -172,6 +172,11 @@ const (
 	NodeWith
 	NodeWithItem
 	NodeDecorator
+	NodeEllipsis
+	NodeAwait
+	NodeMatch
+	NodeMatchCase
+	NodeMatchAs
 )
 
 const NoNode NodeID = 0
@@ -346,8 +351,26 @@ func (a *AST) LastChild(id NodeID) NodeID {
 // NodeAssign invariant
 // Child0 -> value
 // Child 1 ... n -> targets
+// Data -> number of leading targets unpacked from the value (a, b = ...), or 0
 
 // NodeAnnAssign invariant
 // Child0 -> target
 // Child1 -> annotation
 // Child2 -> value (optional)
+
+// NodeMatch invariant
+// Child0 -> subject
+// Child1 ... n -> NodeMatchCase clauses
+
+// NodeMatchCase invariant
+// Child0 -> pattern
+// Child1 -> guard (when Data is 1)
+// Last child -> body block (optional)
+// Patterns reuse expression nodes: Name (capture or wildcard _), Attribute
+// (value), literals, BinOp (| alternatives), Call (class pattern with
+// KeywordArg attributes), Tuple/List (sequence with StarArg rest), Dict
+// (mapping with a trailing KwStarArg rest) and NodeMatchAs.
+
+// NodeMatchAs invariant
+// Child0 -> pattern
+// Child1 -> bound name
@@ -5,8 +5,14 @@ const (
 	ParamFlagHasDefault
 	ParamFlagIsVarArg
 	ParamFlagIsKwArg
+	ParamFlagIsKwOnly
+	ParamFlagIsPosOnly
 )
 
+// NodeFlagAsync marks an async def, async for or async with. It shares the
+// Data field with a function's docstring index, so it uses the top bit.
+const NodeFlagAsync uint32 = 1 << 31
+
 // ChildCount counts all immediate children of a given nodeID
 func (a *AST) ChildCount(id NodeID) int {
 	if id == NoNode {
@@ -192,6 +198,16 @@ func (a *AST) ParamIsKwArg(id NodeID) bool {
 	return id != NoNode && a.Nodes[id].Kind == NodeParam && a.Nodes[id].Data&ParamFlagIsKwArg != 0
 }
 
+// ParamIsKwOnly reports whether a parameter follows *args or a bare '*'.
+func (a *AST) ParamIsKwOnly(id NodeID) bool {
+	return id != NoNode && a.Nodes[id].Kind == NodeParam && a.Nodes[id].Data&ParamFlagIsKwOnly != 0
+}
+
+// ParamIsPosOnly reports whether a parameter precedes a '/' separator.
+func (a *AST) ParamIsPosOnly(id NodeID) bool {
+	return id != NoNode && a.Nodes[id].Kind == NodeParam && a.Nodes[id].Data&ParamFlagIsPosOnly != 0
+}
+
 // AnnAssignParts returns the typed children of an annotated assignment node.
 func (a *AST) AnnAssignParts(id NodeID) (target, annotation, value NodeID) {
 	if id == NoNode || a.Nodes[id].Kind != NodeAnnAssign {
@@ -499,13 +515,62 @@ func (a *AST) WithItemParts(id NodeID) (contextExpr, asTarget NodeID) {
 	return contextExpr, asTarget
 }
 
+// IsAsync reports whether a def, for or with statement is marked async.
+func (a *AST) IsAsync(id NodeID) bool {
+	if id == NoNode {
+		return false
+	}
+	switch a.Nodes[id].Kind {
+	case NodeFunctionDef, NodeFor, NodeWith:
+		return a.Nodes[id].Data&NodeFlagAsync != 0
+	}
+	return false
+}
+
+// MatchParts returns the subject and case clauses of a match statement.
+func (a *AST) MatchParts(id NodeID) (subject NodeID, cases []NodeID) {
+	if id == NoNode || a.Nodes[id].Kind != NodeMatch {
+		return NoNode, nil
+	}
+	subject = a.Nodes[id].FirstChild
+	for child := a.Nodes[subject].NextSibling; child != NoNode; child = a.Nodes[child].NextSibling {
+		cases = append(cases, child)
+	}
+	return subject, cases
+}
+
+// MatchCaseParts returns the pattern, optional guard, and optional body of
+// a case clause.
+func (a *AST) MatchCaseParts(id NodeID) (pattern, guard, body NodeID) {
+	if id == NoNode || a.Nodes[id].Kind != NodeMatchCase {
+		return NoNode, NoNode, NoNode
+	}
+	pattern = a.Nodes[id].FirstChild
+	next := a.Nodes[pattern].NextSibling
+	if a.Nodes[id].Data == 1 {
+		guard = next
+		next = a.Nodes[next].NextSibling
+	}
+	return pattern, guard, next
+}
+
+// UnpackCount returns how many of an assignment's leading targets form a
+// comma-separated unpacking list, as in `a, *b = value`. It is 0 when every
+// target is bound to the whole value.
+func (a *AST) UnpackCount(id NodeID) int {
+	if id == NoNode || a.Nodes[id].Kind != NodeAssign {
+		return 0
+	}
+	return int(a.Nodes[id].Data)
+}
+
 // DocString fetches the docstring stored in a node's Data field.
 func (a *AST) DocString(id NodeID) (string, bool) {
 	if id == NoNode {
 		return "", false
 	}
 
-	idx := a.Nodes[id].Data
+	idx := a.Nodes[id].Data &^ NodeFlagAsync
 	if idx == 0 || int(idx) >= len(a.Strings) {
 		return "", false
 	}
@@ -71,11 +71,16 @@ func _() {
 	_ = x[NodeWith-60]
 	_ = x[NodeWithItem-61]
 	_ = x[NodeDecorator-62]
+	_ = x[NodeEllipsis-63]
+	_ = x[NodeAwait-64]
+	_ = x[NodeMatch-65]
+	_ = x[NodeMatchCase-66]
+	_ = x[NodeMatchAs-67]
 }
 
-const _NodeKind_name = "NodeModuleNodeAssignNodeAugAssignNodeNameNodeNumberNodeStringNodeBytesNodeFStringNodeFStringTextNodeFStringExprNodeBinOpNodeUnaryOpNodeCallNodeAttributeNodeCompareNodeCompareOpNodeBooleanOpNodeBooleanNodeTupleNodeNoneNodeListNodeIfNodeForNodeWhileNodeAssertNodeDelNodeGlobalNodeNonlocalNodeReturnNodeYieldNodeRaiseNodePassNodeBreakNodeContinueNodeFunctionDefNodeClassDefNodeExprStmtNodeBlockNodeArgsNodeErrExpNodeSubScriptNodeBaseListNodeErrStmtNodeParamNodeImportNodeFromImportNodeAliasNodeSliceNodeKeywordArgNodeStarArgNodeKwStarArgNodeDictNodeAnnAssignNodeTryNodeExceptNodeListCompNodeDictCompNodeGeneratorExpNodeConditionalNodeComprehensionNodeWithNodeWithItemNodeDecorator"
+const _NodeKind_name = "NodeModuleNodeAssignNodeAugAssignNodeNameNodeNumberNodeStringNodeBytesNodeFStringNodeFStringTextNodeFStringExprNodeBinOpNodeUnaryOpNodeCallNodeAttributeNodeCompareNodeCompareOpNodeBooleanOpNodeBooleanNodeTupleNodeNoneNodeListNodeIfNodeForNodeWhileNodeAssertNodeDelNodeGlobalNodeNonlocalNodeReturnNodeYieldNodeRaiseNodePassNodeBreakNodeContinueNodeFunctionDefNodeClassDefNodeExprStmtNodeBlockNodeArgsNodeErrExpNodeSubScriptNodeBaseListNodeErrStmtNodeParamNodeImportNodeFromImportNodeAliasNodeSliceNodeKeywordArgNodeStarArgNodeKwStarArgNodeDictNodeAnnAssignNodeTryNodeExceptNodeListCompNodeDictCompNodeGeneratorExpNodeConditionalNodeComprehensionNodeWithNodeWithItemNodeDecoratorNodeEllipsisNodeAwaitNodeMatchNodeMatchCaseNodeMatchAs"
 
-var _NodeKind_index = [...]uint16{0, 10, 20, 33, 41, 51, 61, 70, 81, 96, 111, 120, 131, 139, 152, 163, 176, 189, 200, 209, 217, 225, 231, 238, 247, 257, 264, 274, 286, 296, 305, 314, 322, 331, 343, 358, 370, 382, 391, 399, 409, 422, 434, 445, 454, 464, 478, 487, 496, 510, 521, 534, 542, 555, 562, 572, 584, 596, 612, 627, 644, 652, 664, 677}
+var _NodeKind_index = [...]uint16{0, 10, 20, 33, 41, 51, 61, 70, 81, 96, 111, 120, 131, 139, 152, 163, 176, 189, 200, 209, 217, 225, 231, 238, 247, 257, 264, 274, 286, 296, 305, 314, 322, 331, 343, 358, 370, 382, 391, 399, 409, 422, 434, 445, 454, 464, 478, 487, 496, 510, 521, 534, 542, 555, 562, 572, 584, 596, 612, 627, 644, 652, 664, 677, 689, 698, 707, 720, 731}
 
 func (i NodeKind) String() string {
 	idx := int(i) - 0
@@ -17,6 +17,11 @@ func (p *Parser) parseIndentedBlock(header string) (a.NodeID, uint32, bool) {
 	}
 	p.advance()
 
+	if p.current.Type != l.NEWLINE && p.current.Type != l.EOF {
+		body := p.parseInlineSuite()
+		return body, p.tree.Nodes[body].End, true
+	}
+
 	if p.current.Type != l.NEWLINE {
 		p.errorCurrent("expected newline after '" + header + "'")
 		p.syncTo(l.NEWLINE, l.EOF)
@@ -62,6 +67,28 @@ func (p *Parser) parseIndentedBlock(header string) (a.NodeID, uint32, bool) {
 	return body, endPos, true
 }
 
+// parseInlineSuite parses the simple statements following a ':' on the same
+// line, as in `def f(): ...` or `class C: ...`.
+func (p *Parser) parseInlineSuite() a.NodeID {
+	body := p.tree.NewNode(a.NodeBlock, p.current.Start, p.current.Start)
+	for p.current.Type != l.NEWLINE && p.current.Type != l.EOF {
+		stmt := p.parseStatement()
+		if stmt != a.NoNode {
+			p.tree.AddChild(body, stmt)
+			if p.tree.Nodes[body].FirstChild == stmt {
+				p.tree.Nodes[body].Start = p.tree.Nodes[stmt].Start
+			}
+			p.tree.Nodes[body].End = p.tree.Nodes[stmt].End
+		}
+		if p.current.Type != l.SEMI {
+			break
+		}
+		p.advance()
+	}
+	p.consumeOptionalNewline()
+	return body
+}
+
 func (p *Parser) parseExceptClause() (a.NodeID, bool) {
 	startPos := p.current.Start
 	p.advance()
@@ -385,28 +412,26 @@ func (p *Parser) parseFor() a.NodeID {
 }
 
 func (p *Parser) parseForTarget() a.NodeID {
-	if p.current.Type != l.NAME {
-		p.errorCurrent("expected variable name")
+	// Targets stop short of comparisons so the `in` is left for the caller.
+	first := p.parseForTargetElement()
+	if first == a.NoNode {
 		return p.tree.NewNode(a.NodeErrExp, p.current.Start, p.current.End)
 	}
 
-	first := p.tree.NewNameNode(p.current.Start, p.current.End, p.current.Literal)
-	p.advance()
-
 	if p.current.Type == l.COMMA {
 		tuple := p.tree.NewNode(a.NodeTuple, p.tree.Nodes[first].Start, p.tree.Nodes[first].End)
 		p.tree.AddChild(tuple, first)
 		for p.current.Type == l.COMMA {
 			p.advance()
-			if p.current.Type != l.NAME {
-				p.errorCurrent("expected variable name")
+			if p.current.Type == l.IN {
+				break
+			}
+			newTarget := p.parseForTargetElement()
+			if newTarget == a.NoNode {
 				return tuple
 			}
-
-			newTarget := p.tree.NewNameNode(p.current.Start, p.current.End, p.current.Literal)
 			p.tree.AddChild(tuple, newTarget)
 			p.tree.Nodes[tuple].End = p.tree.Nodes[newTarget].End
-			p.advance()
 		}
 		return tuple
 	}
@@ -414,6 +439,28 @@ func (p *Parser) parseForTarget() a.NodeID {
 	return first
 }
 
+// parseForTargetElement parses one comma-separated loop target: a name,
+// attribute, subscript, starred or parenthesized target.
+func (p *Parser) parseForTargetElement() a.NodeID {
+	switch p.current.Type {
+	case l.NAME, l.LPAR, l.LSQB, l.STAR:
+	default:
+		p.errorCurrent("expected variable name")
+		return a.NoNode
+	}
+	target := p.parseExpression(COMPARE)
+	if target == a.NoNode {
+		p.errorCurrent("expected variable name")
+		return a.NoNode
+	}
+	switch p.tree.Nodes[target].Kind {
+	case a.NodeName, a.NodeAttribute, a.NodeSubScript, a.NodeTuple, a.NodeList, a.NodeStarArg:
+		return target
+	}
+	p.error(a.Range{Start: p.tree.Nodes[target].Start, End: p.tree.Nodes[target].End}, "invalid loop target")
+	return target
+}
+
 func (p *Parser) parseWhile() a.NodeID {
 	startPos := p.current.Start
 	p.advance()
@@ -488,7 +535,13 @@ func (p *Parser) parseClassBases() a.NodeID {
 	p.advance() // consume '('
 
 	for p.current.Type != l.RPAR && p.current.Type != l.EOF {
-		expr := p.parseExpression(LOWEST)
+		var expr a.NodeID
+		if p.current.Type == l.NAME && p.peek.Type == l.EQUAL {
+			// Class keywords such as metaclass=ABCMeta.
+			expr = p.parseClassKeyword()
+		} else {
+			expr = p.parseExpression(LOWEST)
+		}
 		if expr == a.NoNode {
 			p.errorCurrent("expected expression in class base list")
 			p.syncTo(l.COMMA, l.RPAR, l.COLON, l.EOF)
@@ -513,6 +566,21 @@ func (p *Parser) parseClassBases() a.NodeID {
 	return bases
 }
 
+func (p *Parser) parseClassKeyword() a.NodeID {
+	keyword := p.tree.NewNameNode(p.current.Start, p.current.End, p.current.Literal)
+	start := p.current.Start
+	p.advanceBy(2)
+
+	value := p.parseExpression(LOWEST)
+	if value == a.NoNode {
+		return a.NoNode
+	}
+	arg := p.tree.NewNode(a.NodeKeywordArg, start, p.tree.Nodes[value].End)
+	p.tree.AddChild(arg, keyword)
+	p.tree.AddChild(arg, value)
+	return arg
+}
+
 func (p *Parser) parseClass() a.NodeID {
 	startPos := p.current.Start
 	p.advance()
@@ -558,6 +626,17 @@ func (p *Parser) parseClass() a.NodeID {
 	}
 	p.advance()
 
+	if p.current.Type != l.NEWLINE && p.current.Type != l.EOF {
+		body := p.parseInlineSuite()
+		def := p.tree.NewNode(a.NodeClassDef, startPos, p.tree.Nodes[body].End)
+		p.tree.AddChild(def, className)
+		if bases != a.NoNode {
+			p.tree.AddChild(def, bases)
+		}
+		p.tree.AddChild(def, body)
+		return def
+	}
+
 	if p.current.Type != l.NEWLINE {
 		p.errorCurrent("expected newline after `:`")
 		p.syncTo(l.EOF, l.NEWLINE)
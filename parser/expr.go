@@ -194,10 +194,35 @@ func (p *Parser) parsePrimary() a.NodeID {
 		return ret
 
 	case l.NAME:
+		if p.current.Literal == "await" && startsAwaitOperand(p.peek.Type) {
+			startPos := p.current.Start
+			p.advance()
+			operand := p.parseExpression(PREFIX)
+			if operand == a.NoNode {
+				p.errorCurrent("expected expression after 'await'")
+				return p.tree.NewNode(a.NodeErrExp, startPos, p.current.Start)
+			}
+			ret := p.tree.NewNode(a.NodeAwait, startPos, p.tree.Nodes[operand].End)
+			p.tree.AddChild(ret, operand)
+			return ret
+		}
 		ret := p.tree.NewNameNode(p.current.Start, p.current.End, p.current.Literal)
 		p.advance()
 		return ret
 
+	case l.STAR:
+		// Starred target or display element: a, *rest = xs / [*xs, 1]
+		startPos := p.current.Start
+		p.advance()
+		operand := p.parseExpression(COMPARE)
+		if operand == a.NoNode {
+			p.errorCurrent("expected expression after '*'")
+			return p.tree.NewNode(a.NodeErrExp, startPos, p.current.Start)
+		}
+		ret := p.tree.NewNode(a.NodeStarArg, startPos, p.tree.Nodes[operand].End)
+		p.tree.AddChild(ret, operand)
+		return ret
+
 	case l.LPAR:
 		startPos := p.current.Start
 		p.advance()
@@ -355,6 +380,12 @@ func (p *Parser) parsePrimary() a.NodeID {
 		p.advance()
 		ret := p.tree.NewNode(a.NodeNone, startPos, endPos)
 		return ret
+
+	case l.ELLIPSIS:
+		startPos := p.current.Start
+		endPos := p.current.End
+		p.advance()
+		return p.tree.NewNode(a.NodeEllipsis, startPos, endPos)
 	}
 	p.errorCurrent(fmt.Sprintf("unexpected token %v", p.current))
 	p.advance()
@@ -494,3 +525,13 @@ func (p *Parser) parseComprehensionClause() a.NodeID {
 	}
 	return ret
 }
+
+// startsAwaitOperand reports whether a token following the name await can
+// begin its operand, distinguishing await x from a variable named await.
+func startsAwaitOperand(t l.TokenType) bool {
+	switch t {
+	case l.NAME, l.NUMBER, l.STRING, l.FSTRING, l.BSTRING, l.LPAR, l.LSQB, l.LBRACE, l.NONE, l.TRUE, l.FALSE:
+		return true
+	}
+	return false
+}
@@ -48,6 +48,9 @@ func (p *Parser) parseFunc() a.NodeID {
 					p.errorCurrent("invalid positional-only parameter separator")
 				} else {
 					seenPosOnly = true
+					for param := p.tree.Nodes[args].FirstChild; param != a.NoNode; param = p.tree.Nodes[param].NextSibling {
+						p.tree.Nodes[param].Data |= a.ParamFlagIsPosOnly
+					}
 				}
 				p.advance()
 				if p.current.Type == l.COMMA {
@@ -57,6 +60,18 @@ func (p *Parser) parseFunc() a.NodeID {
 				break
 			}
 
+			if p.current.Type == l.STAR && p.peek.Type == l.COMMA {
+				// A bare '*' makes the following parameters keyword-only.
+				if seenVarArg || seenKwArg {
+					p.errorCurrent("invalid keyword-only parameter separator")
+				}
+				seenVarArg = true
+				seenDefault = false
+				p.advanceBy(2)
+				continue
+			}
+
+			kwOnly := seenVarArg
 			param, isVarArg, isKwArg := p.parseParameter()
 			if param == a.NoNode {
 				p.errorCurrent("expected parameter name")
@@ -99,6 +114,9 @@ func (p *Parser) parseFunc() a.NodeID {
 				}
 			}
 
+			if kwOnly && !isVarArg && !isKwArg {
+				p.tree.Nodes[param].Data |= a.ParamFlagIsKwOnly
+			}
 			if args == a.NoNode {
 				args = p.tree.NewNode(a.NodeArgs, p.tree.Nodes[param].Start, p.tree.Nodes[param].End)
 			}
@@ -157,6 +175,20 @@ func (p *Parser) parseFunc() a.NodeID {
 	}
 	p.advance()
 
+	if p.current.Type != l.NEWLINE && p.current.Type != l.EOF {
+		body := p.parseInlineSuite()
+		ret := p.tree.NewNode(a.NodeFunctionDef, startPos, p.tree.Nodes[body].End)
+		p.tree.AddChild(ret, name)
+		if args != a.NoNode {
+			p.tree.AddChild(ret, args)
+		}
+		if returnAnnotation != a.NoNode {
+			p.tree.AddChild(ret, returnAnnotation)
+		}
+		p.tree.AddChild(ret, body)
+		return ret
+	}
+
 	if p.current.Type != l.NEWLINE {
 		p.errorCurrent("expected newline after ':'")
 		p.syncTo(l.NEWLINE, l.EOF)
@@ -341,10 +373,12 @@ func (p *Parser) parseDecoratedDef() a.NodeID {
 	}
 
 	var def a.NodeID
-	switch p.current.Type {
-	case l.DEF:
+	switch {
+	case p.current.Type == l.DEF:
 		def = p.parseFunc()
-	case l.CLASS:
+	case p.isAsyncKeyword() && p.peek.Type == l.DEF:
+		def = p.parseAsync()
+	case p.current.Type == l.CLASS:
 		def = p.parseClass()
 	default:
 		p.errorCurrent("expected function or class definition after decorator")
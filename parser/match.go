@@ -0,0 +1,413 @@
+package parser
+
+import (
+	l "rahu/lexer"
+	a "rahu/parser/ast"
+)
+
+// isMatchStatement reports whether the current token starts a match
+// statement. match is a soft keyword: a line starting with the name match
+// is only a match statement when its logical line ends with ':'.
+func (p *Parser) isMatchStatement() bool {
+	if p.current.Type != l.NAME || p.current.Literal != "match" {
+		return false
+	}
+	switch p.peek.Type {
+	case l.NAME, l.NUMBER, l.STRING, l.FSTRING, l.LPAR, l.LSQB, l.LBRACE, l.MINUS, l.NOT, l.TRUE, l.FALSE, l.NONE, l.STAR:
+	default:
+		return false
+	}
+	return logicalLineEndsWithColon(p.input, p.current.End)
+}
+
+// logicalLineEndsWithColon scans the source from offset to the end of its
+// logical line, skipping brackets, strings and comments, and reports
+// whether the last significant character is ':'.
+func logicalLineEndsWithColon(src string, offset uint32) bool {
+	depth := 0
+	var last byte
+	for i := int(offset); i < len(src); i++ {
+		ch := src[i]
+		switch ch {
+		case '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			i--
+		case '\\':
+			i++
+		case '\n':
+			if depth == 0 {
+				return last == ':'
+			}
+		case '(', '[', '{':
+			depth++
+			last = ch
+		case ')', ']', '}':
+			depth--
+			last = ch
+		case '\'', '"':
+			i = skipStringLiteral(src, i)
+			last = ch
+		case ' ', '\t', '\r':
+		default:
+			last = ch
+		}
+	}
+	return last == ':'
+}
+
+// skipStringLiteral returns the offset of the closing quote of the string
+// literal opening at start.
+func skipStringLiteral(src string, start int) int {
+	quote := src[start]
+	triple := start+2 < len(src) && src[start+1] == quote && src[start+2] == quote
+	i := start + 1
+	if triple {
+		i = start + 3
+	}
+	for ; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '\n':
+			if !triple {
+				return i - 1
+			}
+		case quote:
+			if !triple {
+				return i
+			}
+			if i+2 < len(src) && src[i+1] == quote && src[i+2] == quote {
+				return i + 2
+			}
+		}
+	}
+	return len(src) - 1
+}
+
+// parseMatch parses `match subject:` followed by an indented block of case
+// clauses.
+func (p *Parser) parseMatch() a.NodeID {
+	start := p.current.Start
+	p.advance() // consume 'match'
+
+	ret := p.tree.NewNode(a.NodeMatch, start, start)
+	subject := p.parseExpression(LOWEST)
+	if subject == a.NoNode {
+		p.errorCurrent("expected subject after 'match'")
+		p.syncTo(l.NEWLINE, l.EOF)
+		return ret
+	}
+	if p.current.Type == l.COMMA {
+		subject = p.parseOpenTuple(subject, func() a.NodeID { return p.parseExpression(LOWEST) })
+	}
+	p.tree.AddChild(ret, subject)
+	p.tree.Nodes[ret].End = p.tree.Nodes[subject].End
+
+	if p.current.Type != l.COLON {
+		p.errorCurrent("expected ':' after match subject")
+		p.syncTo(l.COLON, l.NEWLINE, l.EOF)
+		if p.current.Type != l.COLON {
+			return ret
+		}
+	}
+	p.advance()
+	if p.current.Type != l.NEWLINE {
+		p.errorCurrent("expected newline after 'match:'")
+		p.syncTo(l.NEWLINE, l.EOF)
+	}
+	p.consumeBlankLinesBeforeIndent()
+	if p.current.Type != l.INDENT {
+		p.errorCurrent("expected indent block after 'match:'")
+		return ret
+	}
+	p.advance()
+
+	for p.current.Type != l.DEDENT && p.current.Type != l.EOF {
+		if p.current.Type == l.NEWLINE {
+			p.advance()
+			continue
+		}
+		if p.current.Type != l.NAME || p.current.Literal != "case" {
+			p.errorCurrent("expected 'case' in match statement")
+			p.syncTo(l.NEWLINE, l.EOF)
+			continue
+		}
+		clause := p.parseCase()
+		p.tree.AddChild(ret, clause)
+		p.tree.Nodes[ret].End = p.tree.Nodes[clause].End
+	}
+	if p.current.Type == l.DEDENT {
+		p.tree.Nodes[ret].End = p.current.Start
+		p.advance()
+	}
+	return ret
+}
+
+// parseCase parses `case pattern [if guard]:` and its block.
+func (p *Parser) parseCase() a.NodeID {
+	start := p.current.Start
+	p.advance() // consume 'case'
+
+	ret := p.tree.NewNode(a.NodeMatchCase, start, start)
+	pattern := p.parsePattern()
+	if pattern == a.NoNode {
+		p.syncTo(l.COLON, l.NEWLINE, l.EOF)
+		pattern = p.tree.NewNode(a.NodeErrExp, start, p.current.Start)
+	}
+	if p.current.Type == l.COMMA {
+		pattern = p.parseOpenTuple(pattern, p.parseAsPattern)
+	}
+	p.tree.AddChild(ret, pattern)
+	p.tree.Nodes[ret].End = p.tree.Nodes[pattern].End
+
+	if p.current.Type == l.IF {
+		p.advance()
+		guard := p.parseExpression(LOWEST)
+		if guard == a.NoNode {
+			p.errorCurrent("expected expression after 'if' in case clause")
+		} else {
+			p.tree.AddChild(ret, guard)
+			p.tree.Nodes[ret].Data = 1
+		}
+	}
+
+	body, endPos, _ := p.parseIndentedBlock("case")
+	if body != a.NoNode {
+		p.tree.AddChild(ret, body)
+	}
+	p.tree.Nodes[ret].End = endPos
+	return ret
+}
+
+// parseOpenTuple collects the comma-separated elements following first, as
+// in `match a, b:` or `case x, *rest:`, into a tuple.
+func (p *Parser) parseOpenTuple(first a.NodeID, parseElement func() a.NodeID) a.NodeID {
+	ret := p.tree.NewNode(a.NodeTuple, p.tree.Nodes[first].Start, p.tree.Nodes[first].End)
+	p.tree.AddChild(ret, first)
+	for p.current.Type == l.COMMA {
+		p.advance()
+		if p.current.Type == l.COLON || p.current.Type == l.IF {
+			break
+		}
+		elt := parseElement()
+		if elt == a.NoNode {
+			break
+		}
+		p.tree.AddChild(ret, elt)
+		p.tree.Nodes[ret].End = p.tree.Nodes[elt].End
+	}
+	return ret
+}
+
+// parsePattern parses a case pattern. Patterns reuse expression nodes:
+// names are captures (or the wildcard _), dotted names are value patterns,
+// `|` builds a BinOp, class patterns are calls, sequence and mapping
+// patterns are lists, tuples and dicts, and `*rest` / `**rest` are star
+// arguments. `pattern as name` builds a NodeMatchAs.
+func (p *Parser) parsePattern() a.NodeID {
+	return p.parseAsPattern()
+}
+
+func (p *Parser) parseAsPattern() a.NodeID {
+	pattern := p.parseOrPattern()
+	if pattern == a.NoNode || p.current.Type != l.AS {
+		return pattern
+	}
+	p.advance()
+	if p.current.Type != l.NAME {
+		p.errorCurrent("expected name after 'as' in pattern")
+		return pattern
+	}
+	name := p.tree.NewNameNode(p.current.Start, p.current.End, p.current.Literal)
+	p.advance()
+	ret := p.tree.NewNode(a.NodeMatchAs, p.tree.Nodes[pattern].Start, p.tree.Nodes[name].End)
+	p.tree.AddChild(ret, pattern)
+	p.tree.AddChild(ret, name)
+	return ret
+}
+
+func (p *Parser) parseOrPattern() a.NodeID {
+	left := p.parseClosedPattern()
+	for left != a.NoNode && p.current.Type == l.VBAR {
+		p.advance()
+		right := p.parseClosedPattern()
+		if right == a.NoNode {
+			return left
+		}
+		or := p.tree.NewNode(a.NodeBinOp, p.tree.Nodes[left].Start, p.tree.Nodes[right].End)
+		p.tree.Nodes[or].Data = uint32(a.BitOr)
+		p.tree.AddChild(or, left)
+		p.tree.AddChild(or, right)
+		left = or
+	}
+	return left
+}
+
+func (p *Parser) parseClosedPattern() a.NodeID {
+	switch p.current.Type {
+	case l.NUMBER, l.STRING, l.FSTRING, l.MINUS, l.NONE, l.TRUE, l.FALSE:
+		// Literals, including negative numbers, stop at the '|'
+		// separating alternatives.
+		return p.parseExpression(BITOR)
+	case l.NAME:
+		value := p.tree.NewNameNode(p.current.Start, p.current.End, p.current.Literal)
+		p.advance()
+		for p.current.Type == l.DOT {
+			value = p.parseAttribute(value)
+		}
+		if p.current.Type == l.LPAR {
+			return p.parseClassPattern(value)
+		}
+		return value
+	case l.STAR:
+		start := p.current.Start
+		p.advance()
+		if p.current.Type != l.NAME {
+			p.errorCurrent("expected name after '*' in pattern")
+			return a.NoNode
+		}
+		name := p.tree.NewNameNode(p.current.Start, p.current.End, p.current.Literal)
+		p.advance()
+		ret := p.tree.NewNode(a.NodeStarArg, start, p.tree.Nodes[name].End)
+		p.tree.AddChild(ret, name)
+		return ret
+	case l.LPAR:
+		return p.parseSequencePattern(a.NodeTuple, l.RPAR)
+	case l.LSQB:
+		return p.parseSequencePattern(a.NodeList, l.RSQB)
+	case l.LBRACE:
+		return p.parseMappingPattern()
+	}
+	p.errorCurrent("expected pattern")
+	return a.NoNode
+}
+
+// parseSequencePattern parses [p, ...] or (p, ...). A parenthesized single
+// pattern without a trailing comma is a group, not a tuple.
+func (p *Parser) parseSequencePattern(kind a.NodeKind, closing l.TokenType) a.NodeID {
+	start := p.current.Start
+	p.advance()
+	ret := p.tree.NewNode(kind, start, start)
+	count, trailingComma := 0, false
+	for p.current.Type != closing && p.current.Type != l.EOF {
+		elt := p.parseAsPattern()
+		if elt == a.NoNode {
+			p.syncTo(closing, l.NEWLINE, l.EOF)
+			break
+		}
+		p.tree.AddChild(ret, elt)
+		count++
+		trailingComma = false
+		if p.current.Type != l.COMMA {
+			break
+		}
+		p.advance()
+		trailingComma = true
+	}
+	if p.current.Type != closing {
+		p.errorCurrent("expected closing bracket in pattern")
+		return ret
+	}
+	p.tree.Nodes[ret].End = p.current.Start
+	p.advance()
+	if kind == a.NodeTuple && count == 1 && !trailingComma {
+		return p.tree.Nodes[ret].FirstChild
+	}
+	return ret
+}
+
+// parseClassPattern parses the arguments of Cls(p, ..., name=p, ...).
+func (p *Parser) parseClassPattern(cls a.NodeID) a.NodeID {
+	ret := p.tree.NewNode(a.NodeCall, p.tree.Nodes[cls].Start, p.tree.Nodes[cls].End)
+	p.tree.AddChild(ret, cls)
+	p.advance() // consume '('
+	for p.current.Type != l.RPAR && p.current.Type != l.EOF {
+		var arg a.NodeID
+		if p.current.Type == l.NAME && p.peek.Type == l.EQUAL {
+			keyword := p.tree.NewNameNode(p.current.Start, p.current.End, p.current.Literal)
+			start := p.current.Start
+			p.advanceBy(2)
+			value := p.parseAsPattern()
+			if value == a.NoNode {
+				p.syncTo(l.RPAR, l.NEWLINE, l.EOF)
+				break
+			}
+			arg = p.tree.NewNode(a.NodeKeywordArg, start, p.tree.Nodes[value].End)
+			p.tree.AddChild(arg, keyword)
+			p.tree.AddChild(arg, value)
+		} else {
+			arg = p.parseAsPattern()
+			if arg == a.NoNode {
+				p.syncTo(l.RPAR, l.NEWLINE, l.EOF)
+				break
+			}
+		}
+		p.tree.AddChild(ret, arg)
+		if p.current.Type != l.COMMA {
+			break
+		}
+		p.advance()
+	}
+	if p.current.Type != l.RPAR {
+		p.errorCurrent("expected ')' after class pattern arguments")
+		return ret
+	}
+	p.tree.Nodes[ret].End = p.current.Start
+	p.advance()
+	return ret
+}
+
+// parseMappingPattern parses {key: p, ..., **rest}. Keys alternate with
+// their patterns as in a dict display; **rest is a trailing KwStarArg.
+func (p *Parser) parseMappingPattern() a.NodeID {
+	start := p.current.Start
+	p.advance() // consume '{'
+	ret := p.tree.NewNode(a.NodeDict, start, start)
+	for p.current.Type != l.RBRACE && p.current.Type != l.EOF {
+		if p.current.Type == l.DOUBLESTAR {
+			starStart := p.current.Start
+			p.advance()
+			if p.current.Type != l.NAME {
+				p.errorCurrent("expected name after '**' in pattern")
+				break
+			}
+			name := p.tree.NewNameNode(p.current.Start, p.current.End, p.current.Literal)
+			p.advance()
+			rest := p.tree.NewNode(a.NodeKwStarArg, starStart, p.tree.Nodes[name].End)
+			p.tree.AddChild(rest, name)
+			p.tree.AddChild(ret, rest)
+		} else {
+			key := p.parseClosedPattern()
+			if key == a.NoNode {
+				p.syncTo(l.RBRACE, l.NEWLINE, l.EOF)
+				break
+			}
+			if p.current.Type != l.COLON {
+				p.errorCurrent("expected ':' after mapping pattern key")
+				p.syncTo(l.RBRACE, l.NEWLINE, l.EOF)
+				break
+			}
+			p.advance()
+			value := p.parseAsPattern()
+			if value == a.NoNode {
+				p.syncTo(l.RBRACE, l.NEWLINE, l.EOF)
+				break
+			}
+			p.tree.AddChild(ret, key)
+			p.tree.AddChild(ret, value)
+		}
+		if p.current.Type != l.COMMA {
+			break
+		}
+		p.advance()
+	}
+	if p.current.Type != l.RBRACE {
+		p.errorCurrent("expected '}' after mapping pattern")
+		return ret
+	}
+	p.tree.Nodes[ret].End = p.current.Start
+	p.advance()
+	return ret
+}
@@ -27,6 +27,7 @@ type Parser struct {
 	tree    *ast.AST
 
 	errors   []Error
+	input    string
 	inputLen int
 }
 
@@ -60,6 +61,7 @@ func New(input string) *Parser {
 	l := lexer.New(input)
 	p := &Parser{
 		lexer:    l,
+		input:    input,
 		inputLen: len(input),
 	}
 
@@ -20,6 +20,13 @@ func (p *Parser) parseStatement() a.NodeID {
 		p.advance()
 	}
 
+	if p.isAsyncKeyword() {
+		return p.parseAsync()
+	}
+	if p.isMatchStatement() {
+		return p.parseMatch()
+	}
+
 	switch p.current.Type {
 	case l.IF:
 		return p.parseIf()
@@ -32,7 +39,7 @@ func (p *Parser) parseStatement() a.NodeID {
 	case l.NONLOCAL:
 		return p.parseNonlocal()
 
-	case l.NAME, l.NUMBER, l.STRING, l.FSTRING, l.LPAR, l.LSQB, l.LBRACE, l.MINUS, l.PLUS, l.NOT, l.TRUE, l.FALSE, l.NONE, l.YIELD:
+	case l.NAME, l.NUMBER, l.STRING, l.FSTRING, l.LPAR, l.LSQB, l.LBRACE, l.MINUS, l.PLUS, l.NOT, l.TRUE, l.FALSE, l.NONE, l.ELLIPSIS, l.YIELD, l.STAR:
 		return p.dispatchExprParse()
 
 	case l.DEF:
@@ -107,14 +114,14 @@ func (p *Parser) dispatchExprParse() a.NodeID {
 
 	if p.current.Type == l.COLON {
 		switch p.tree.Nodes[expr].Kind {
-		case a.NodeName:
+		case a.NodeName, a.NodeAttribute, a.NodeSubScript:
 			return p.parseAnnotatedAssignment(start, expr)
 		}
 	}
 
 	if p.current.Type == l.EQUAL || p.current.Type == l.COMMA {
 		switch p.tree.Nodes[expr].Kind {
-		case a.NodeName, a.NodeAttribute, a.NodeTuple, a.NodeList, a.NodeSubScript:
+		case a.NodeName, a.NodeAttribute, a.NodeTuple, a.NodeList, a.NodeSubScript, a.NodeStarArg:
 			return p.parseAssignmentFromFirst(start, expr)
 		}
 	}
@@ -277,8 +284,13 @@ func (p *Parser) parseAugAssignFromFirst(start uint32, expr a.NodeID) a.NodeID {
 func (p *Parser) parseAssignmentFromFirst(start uint32, first a.NodeID) a.NodeID {
 	lastTarget := first
 	targetCount := 1
+	unpacked := false
 	for p.current.Type == l.COMMA {
 		p.advance()
+		unpacked = true
+		if p.current.Type == l.EQUAL {
+			break
+		}
 		t := p.parseExpression(LOWEST)
 		if t == a.NoNode {
 			p.errorCurrent("expected assignment target")
@@ -307,6 +319,10 @@ func (p *Parser) parseAssignmentFromFirst(start uint32, first a.NodeID) a.NodeID
 		return ret
 	}
 	p.advance()
+	unpackCount := 0
+	if unpacked {
+		unpackCount = targetCount
+	}
 
 	value := p.parseExpression(LOWEST)
 	end := p.current.Start
@@ -364,6 +380,7 @@ func (p *Parser) parseAssignmentFromFirst(start uint32, first a.NodeID) a.NodeID
 		}
 	}
 	ret := p.tree.NewNode(a.NodeAssign, start, end)
+	p.tree.Nodes[ret].Data = uint32(unpackCount)
 
 	p.tree.AddChild(ret, value)
 	for i, child := 0, first; i < targetCount; i++ {
@@ -373,3 +390,38 @@ func (p *Parser) parseAssignmentFromFirst(start uint32, first a.NodeID) a.NodeID
 	}
 	return ret
 }
+
+// isAsyncKeyword reports whether the current token is the soft keyword
+// async introducing a def, for or with statement.
+func (p *Parser) isAsyncKeyword() bool {
+	if p.current.Type != l.NAME || p.current.Literal != "async" {
+		return false
+	}
+	switch p.peek.Type {
+	case l.DEF, l.FOR, l.WITH:
+		return true
+	}
+	return false
+}
+
+// parseAsync parses async def, async for and async with, marking the
+// resulting node with NodeFlagAsync.
+func (p *Parser) parseAsync() a.NodeID {
+	startPos := p.current.Start
+	p.advance()
+
+	var ret a.NodeID
+	switch p.current.Type {
+	case l.DEF:
+		ret = p.parseFunc()
+	case l.FOR:
+		ret = p.parseFor()
+	default:
+		ret = p.parseWith()
+	}
+	if ret != a.NoNode {
+		p.tree.Nodes[ret].Data |= a.NodeFlagAsync
+		p.tree.Nodes[ret].Start = startPos
+	}
+	return ret
+}
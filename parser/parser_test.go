@@ -315,6 +315,23 @@ func TestParseFuncPositionalOnlyParams(t *testing.T) {
 	}
 }
 
+func TestParseFuncParameterKindFlags(t *testing.T) {
+	p, tree := parseSource(t, "def f(a, /, b, *, c, d=1, **kw):\n    a\n")
+	requireNoParseErrors(t, p)
+
+	_, args, _, _ := tree.FunctionPartsWithReturn(moduleStmt(t, tree, 0))
+	params := requireChildCount(t, tree, args, 5)
+	if !tree.ParamIsPosOnly(params[0]) || tree.ParamIsPosOnly(params[1]) {
+		t.Fatal("expected only a to be positional-only")
+	}
+	if tree.ParamIsKwOnly(params[1]) || !tree.ParamIsKwOnly(params[2]) || !tree.ParamIsKwOnly(params[3]) {
+		t.Fatal("expected c and d to be keyword-only")
+	}
+	if tree.ParamIsKwOnly(params[4]) || !tree.ParamIsKwArg(params[4]) {
+		t.Fatal("expected kw to be a plain **kwargs parameter")
+	}
+}
+
 func TestParseFuncAnnotationErrors(t *testing.T) {
 	tests := []struct {
 		name string
@@ -401,6 +418,56 @@ func TestParseFuncNonDefaultAfterDefaultError(t *testing.T) {
 	requireParseErrorContains(t, p, "non-default argument follows default argument")
 }
 
+func TestParseInlineSuiteStubs(t *testing.T) {
+	p, tree := parseSource(t, "def f(x: int) -> int: ...\nclass C: pass\ntry: x\nexcept E: ...\n")
+	requireNoParseErrors(t, p)
+
+	fn := moduleStmt(t, tree, 0)
+	requireKind(t, tree, fn, a.NodeFunctionDef)
+	_, _, returns, body := tree.FunctionPartsWithReturn(fn)
+	if returns == a.NoNode {
+		t.Fatal("expected return annotation on inline def")
+	}
+	bodyStmt := requireChildCount(t, tree, body, 1)[0]
+	bodyExpr := requireChildCount(t, tree, bodyStmt, 1)[0]
+	requireKind(t, tree, bodyExpr, a.NodeEllipsis)
+
+	class := moduleStmt(t, tree, 1)
+	requireKind(t, tree, class, a.NodeClassDef)
+	_, _, classBody := tree.ClassParts(class)
+	requireKind(t, tree, requireChildCount(t, tree, classBody, 1)[0], a.NodePass)
+
+	requireKind(t, tree, moduleStmt(t, tree, 2), a.NodeTry)
+}
+
+func TestParseAsyncStatementsAndAwait(t *testing.T) {
+	p, tree := parseSource(t, "@dec\nasync def f():\n    \"\"\"Doc.\"\"\"\n    async for x in y:\n        pass\n    async with z as w:\n        await w\nawait = 1\n")
+	requireNoParseErrors(t, p)
+
+	fn := moduleStmt(t, tree, 0)
+	requireKind(t, tree, fn, a.NodeFunctionDef)
+	if !tree.IsAsync(fn) || len(tree.Decorators(fn)) != 1 {
+		t.Fatal("expected a decorated async def")
+	}
+	if doc, ok := tree.DocString(fn); !ok || doc != "Doc." {
+		t.Fatalf("expected docstring to survive the async flag, got %q", doc)
+	}
+
+	_, _, _, body := tree.FunctionPartsWithReturn(fn)
+	stmts := tree.Children(body)
+	loop, with := stmts[len(stmts)-2], stmts[len(stmts)-1]
+	requireKind(t, tree, loop, a.NodeFor)
+	requireKind(t, tree, with, a.NodeWith)
+	if !tree.IsAsync(loop) || !tree.IsAsync(with) {
+		t.Fatal("expected async for and async with")
+	}
+	_, withBody := tree.WithParts(with)
+	awaitStmt := requireChildCount(t, tree, withBody, 1)[0]
+	requireKind(t, tree, requireChildCount(t, tree, awaitStmt, 1)[0], a.NodeAwait)
+
+	requireKind(t, tree, moduleStmt(t, tree, 1), a.NodeAssign)
+}
+
 func TestParseDecoratedFunctionShape(t *testing.T) {
 	p, tree := parseSource(t, "@dec\n@pkg.wrap(x)\ndef f():\n    y\n")
 	requireNoParseErrors(t, p)
@@ -987,6 +1054,26 @@ func TestParseListComprehensionMultipleClauses(t *testing.T) {
 	}
 }
 
+func TestParseClassKeywordArguments(t *testing.T) {
+	p, tree := parseSource(t, "class C(A, metaclass=M):\n    pass\n")
+	requireNoParseErrors(t, p)
+
+	classNode := moduleStmt(t, tree, 0)
+	classKids := requireChildCount(t, tree, classNode, 3)
+	baseKids := requireChildCount(t, tree, classKids[1], 2)
+	if got := nameText(t, tree, baseKids[0]); got != "A" {
+		t.Fatalf("unexpected first base: got %q", got)
+	}
+	requireKind(t, tree, baseKids[1], a.NodeKeywordArg)
+	kwKids := requireChildCount(t, tree, baseKids[1], 2)
+	if got := nameText(t, tree, kwKids[0]); got != "metaclass" {
+		t.Fatalf("unexpected class keyword: got %q", got)
+	}
+	if got := nameText(t, tree, kwKids[1]); got != "M" {
+		t.Fatalf("unexpected class keyword value: got %q", got)
+	}
+}
+
 func TestParseClassBasesAndDocstring(t *testing.T) {
 	p, tree := parseSource(t, "class C(A, B):\n    \"doc\"\n    x\n")
 	requireNoParseErrors(t, p)
@@ -1309,6 +1396,22 @@ func TestParseAnnotatedAssignmentWithoutValue(t *testing.T) {
 	}
 }
 
+func TestParseAnnotatedAttributeAssignmentShape(t *testing.T) {
+	p, tree := parseSource(t, "self.x: Final = 1\n")
+	requireNoParseErrors(t, p)
+
+	stmt := moduleStmt(t, tree, 0)
+	requireKind(t, tree, stmt, a.NodeAnnAssign)
+	target, annotation, value := tree.AnnAssignParts(stmt)
+	requireKind(t, tree, target, a.NodeAttribute)
+	if got := nameText(t, tree, annotation); got != "Final" {
+		t.Fatalf("unexpected annotation: got %q", got)
+	}
+	if got := numberValue(t, tree, value); got != "1" {
+		t.Fatalf("unexpected annotated assign value: got %q", got)
+	}
+}
+
 func TestParseAnnotatedAssignmentUnionShape(t *testing.T) {
 	p, tree := parseSource(t, "x: int | None = 1\n")
 	requireNoParseErrors(t, p)
@@ -1371,6 +1474,95 @@ func TestParseTupleAssignmentWithTupleValue(t *testing.T) {
 	}
 }
 
+func TestParseStarredAndNestedUnpacking(t *testing.T) {
+	p, tree := parseSource(t, "a, *rest, b = xs\nc, = ys\nd = e = zs\nfor (i, j), *k in ws:\n    pass\nv = [*xs, 1]\n")
+	requireNoParseErrors(t, p)
+
+	unpack := moduleStmt(t, tree, 0)
+	kids := requireChildCount(t, tree, unpack, 4)
+	if tree.UnpackCount(unpack) != 3 {
+		t.Fatalf("expected 3 unpacked targets, got %d", tree.UnpackCount(unpack))
+	}
+	requireKind(t, tree, kids[2], a.NodeStarArg)
+	if got := nameText(t, tree, tree.ChildAt(kids[2], 0)); got != "rest" {
+		t.Fatalf("unexpected starred target: got %q", got)
+	}
+
+	single := moduleStmt(t, tree, 1)
+	requireChildCount(t, tree, single, 2)
+	if tree.UnpackCount(single) != 1 {
+		t.Fatalf("expected trailing comma to unpack one target, got %d", tree.UnpackCount(single))
+	}
+	if chained := moduleStmt(t, tree, 2); tree.UnpackCount(chained) != 0 {
+		t.Fatalf("expected chained assignment not to unpack, got %d", tree.UnpackCount(chained))
+	}
+
+	target := tree.ChildAt(moduleStmt(t, tree, 3), 0)
+	targets := requireChildCount(t, tree, target, 2)
+	requireKind(t, tree, targets[0], a.NodeTuple)
+	requireKind(t, tree, targets[1], a.NodeStarArg)
+
+	list := requireChildCount(t, tree, moduleStmt(t, tree, 4), 2)[0]
+	requireKind(t, tree, requireChildCount(t, tree, list, 2)[0], a.NodeStarArg)
+}
+
+func TestParseMatchStatement(t *testing.T) {
+	src := "match command.split():\n" +
+		"    case [\"go\", direction] if direction:\n" +
+		"        pass\n" +
+		"    case Color.RED | Color.GREEN as c:\n" +
+		"        pass\n" +
+		"    case Point(x=0, y=y) | {\"k\": -1, **rest}:\n" +
+		"        pass\n" +
+		"    case first, *others:\n" +
+		"        pass\n" +
+		"    case _:\n" +
+		"        pass\n" +
+		"match(x)\nmatch = 1\n"
+	p, tree := parseSource(t, src)
+	requireNoParseErrors(t, p)
+
+	match := moduleStmt(t, tree, 0)
+	requireKind(t, tree, match, a.NodeMatch)
+	subject, cases := tree.MatchParts(match)
+	requireKind(t, tree, subject, a.NodeCall)
+	if len(cases) != 5 {
+		t.Fatalf("expected 5 cases, got %d", len(cases))
+	}
+
+	pattern, guard, body := tree.MatchCaseParts(cases[0])
+	requireKind(t, tree, pattern, a.NodeList)
+	requireKind(t, tree, guard, a.NodeName)
+	requireKind(t, tree, body, a.NodeBlock)
+
+	pattern, guard, _ = tree.MatchCaseParts(cases[1])
+	if guard != a.NoNode {
+		t.Fatalf("unexpected guard on second case")
+	}
+	requireKind(t, tree, pattern, a.NodeMatchAs)
+	or := requireChildCount(t, tree, pattern, 2)[0]
+	requireKind(t, tree, or, a.NodeBinOp)
+	requireKind(t, tree, tree.ChildAt(or, 0), a.NodeAttribute)
+
+	pattern, _, _ = tree.MatchCaseParts(cases[2])
+	alternatives := requireChildCount(t, tree, pattern, 2)
+	requireKind(t, tree, alternatives[0], a.NodeCall)
+	requireKind(t, tree, tree.ChildAt(alternatives[0], 1), a.NodeKeywordArg)
+	mapping := requireChildCount(t, tree, alternatives[1], 3)
+	requireKind(t, tree, mapping[2], a.NodeKwStarArg)
+
+	pattern, _, _ = tree.MatchCaseParts(cases[3])
+	requireKind(t, tree, requireChildCount(t, tree, pattern, 2)[1], a.NodeStarArg)
+
+	pattern, _, _ = tree.MatchCaseParts(cases[4])
+	if got := nameText(t, tree, pattern); got != "_" {
+		t.Fatalf("unexpected wildcard pattern: got %q", got)
+	}
+
+	requireKind(t, tree, moduleStmt(t, tree, 1), a.NodeExprStmt)
+	requireKind(t, tree, moduleStmt(t, tree, 2), a.NodeAssign)
+}
+
 func TestParseCallShape(t *testing.T) {
 	p, tree := parseSource(t, "f(x, y)\n")
 	requireNoParseErrors(t, p)
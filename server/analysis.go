@@ -15,7 +15,7 @@ func (s *Server) analyze(doc *Document) {
 
 	snapshot := s.buildModuleSnapshot("", doc.URI, "", doc.Text, doc.LineIndex)
 	s.SetAnalysis(doc.URI, snapshot.Tree, snapshot.Global, snapshot.Defs, snapshot.Symbols, snapshot.AttrSymbols, snapshot.SemErrs)
-	s.publishDiagnostics(doc.URI, toDiagnostics(doc.LineIndex, snapshot.ParseErrs, snapshot.SemErrs))
+	s.publishDiagnostics(doc.URI, toDiagnostics(doc.URI, doc.LineIndex, snapshot.ParseErrs, snapshot.SemErrs))
 }
 
 func (s *Server) analyzeOpenDocumentFast(doc *Document) bool {
@@ -32,13 +32,14 @@ func (s *Server) analyzeOpenDocumentFast(doc *Document) bool {
 	if _, ok := s.LookupModuleByURI(doc.URI); !ok {
 		snapshot := s.buildModuleSnapshot("", doc.URI, "", text, lineIndex)
 		s.SetAnalysis(doc.URI, snapshot.Tree, snapshot.Global, snapshot.Defs, snapshot.Symbols, snapshot.AttrSymbols, snapshot.SemErrs)
-		s.publishDiagnostics(doc.URI, toDiagnostics(lineIndex, snapshot.ParseErrs, snapshot.SemErrs))
+		s.publishDiagnostics(doc.URI, toDiagnostics(doc.URI, lineIndex, snapshot.ParseErrs, snapshot.SemErrs))
 		return false
 	}
 
 	snapshot := s.buildBaseModuleSnapshot("", doc.URI, "", text, lineIndex)
-	s.SetAnalysis(doc.URI, snapshot.Tree, snapshot.Global, snapshot.Defs, snapshot.Symbols, snapshot.AttrSymbols, snapshot.SemErrs)
-	s.publishDiagnostics(doc.URI, toDiagnostics(lineIndex, snapshot.ParseErrs, snapshot.SemErrs))
+	semErrs := s.documentErrors(snapshot)
+	s.SetAnalysis(doc.URI, snapshot.Tree, snapshot.Global, snapshot.Defs, snapshot.Symbols, snapshot.AttrSymbols, semErrs)
+	s.publishDiagnostics(doc.URI, toDiagnostics(doc.URI, lineIndex, snapshot.ParseErrs, semErrs))
 
 	s.scheduleAsync(func() {
 		uri := doc.URI
@@ -59,6 +60,7 @@ func (s *Server) analyzeOpenDocumentFast(doc *Document) bool {
 }
 
 func toDiagnostics(
+	uri lsp.DocumentURI,
 	li *source.LineIndex,
 	parseErrs []parser.Error,
 	semErrs []analyser.SemanticError,
@@ -75,13 +77,39 @@ func toDiagnostics(
 	}
 
 	for _, e := range semErrs {
-		diags = append(diags, lsp.Diagnostic{
+		diag := lsp.Diagnostic{
 			Range:    ToRange(li, e.Span),
-			Severity: lsp.SeverityError,
+			Severity: toLSPSeverity(e.Severity),
 			Message:  e.Msg,
 			Source:   "semantic",
-		})
+		}
+		if e.Unnecessary {
+			diag.Tags = []lsp.DiagnosticTag{lsp.DiagnosticTagUnnecessary}
+		}
+		if e.Suggestion != "" {
+			diag.Data = map[string]any{"suggestion": e.Suggestion}
+		}
+		for _, related := range e.Related {
+			diag.RelatedInformation = append(diag.RelatedInformation, lsp.DiagnosticRelatedInformation{
+				Location: lsp.Location{URI: uri, Range: ToRange(li, related.Span)},
+				Message:  related.Msg,
+			})
+		}
+		diags = append(diags, diag)
 	}
 
 	return diags
 }
+
+func toLSPSeverity(severity analyser.Severity) lsp.Severity {
+	switch severity {
+	case analyser.SeverityWarning:
+		return lsp.SeverityWarning
+	case analyser.SeverityInformation:
+		return lsp.SeverityInformation
+	case analyser.SeverityHint:
+		return lsp.SeverityHint
+	default:
+		return lsp.SeverityError
+	}
+}
@@ -130,13 +130,16 @@ func NewBenchmarkServer() *Server {
 		pythonBuiltinNames:     make(map[string]struct{}),
 		pythonModuleInfoByName: make(map[string]pythonModuleInfo),
 		moduleImportsByURI:     make(map[lsp.DocumentURI][]string),
+		runtimeImportsByURI:    make(map[lsp.DocumentURI][]string),
 		reverseDepsByModule:    make(map[string]map[lsp.DocumentURI]struct{}),
 		buildingModules:        make(map[string]chan struct{}),
 		openModuleCounts:       make(map[lsp.DocumentURI]int),
 		moduleSnapshotsByName:  make(map[string]*ModuleSnapshot),
 		moduleSnapshotsByURI:   make(map[lsp.DocumentURI]*ModuleSnapshot),
+		classSummariesByURI:    make(map[lsp.DocumentURI][]classSummary),
 		snapshotLRU:            newSnapshotLRU(),
 		maxCachedModules:       defaultMaxCachedModules,
+		settings:               defaultSettings(),
 		refIndex:               NewRefIndex(),
 		pythonMethodCache:      make(map[string]pythonMethodInfo),
 		scheduleAsync: func(fn func()) {
@@ -328,6 +328,9 @@ func (s *Server) measureEnvironmentSetup(dir string, verbose bool) *EnvSetupResu
 	if python != "" {
 		result.PythonVersion = getPythonVersion(python)
 	}
+	s.miscMu.Lock()
+	s.target = pythonTarget(result.PythonVersion, "")
+	s.miscMu.Unlock()
 
 	if verbose {
 		log.Printf("[env-setup] Python: %s (%s)", result.PythonExecutable, result.PythonVersion)
@@ -508,7 +511,7 @@ func (s *Server) simulateFirstFileOpen(modules []ModuleFile) *FirstFileResult {
 
 	// Phase 3: Publish Diagnostics (conversion + notification overhead simulation)
 	start = time.Now()
-	_ = toDiagnostics(lineIndex, snapshot.ParseErrs, snapshot.SemErrs)
+	_ = toDiagnostics(doc.URI, lineIndex, snapshot.ParseErrs, snapshot.SemErrs)
 	result.PublishDiagsMs = time.Since(start).Milliseconds()
 
 	// Phase 4: Async Refinement (full analysis - blocking wait for completion)
@@ -268,13 +268,14 @@ func TestDefinition(t *testing.T) {
 				}
 			}
 
-			loc, err := s.Definition(&lsp.DefinitionParams{
+			locs, err := s.Definition(&lsp.DefinitionParams{
 				TextDocument: lsp.TextDocumentIdentifier{URI: uri},
 				Position: lsp.Position{
 					Line:      tt.line,
 					Character: tt.character,
 				},
 			})
+			loc := firstLocation(locs)
 
 			if tt.expectError {
 				if err == nil {
@@ -343,7 +344,8 @@ y = x
 			},
 		}
 
-		loc, err := s.Definition(params)
+		locs, err := s.Definition(params)
+		loc := firstLocation(locs)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
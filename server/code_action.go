@@ -0,0 +1,89 @@
+package server
+
+import (
+	"rahu/analyser"
+	"rahu/jsonrpc"
+	"rahu/lsp"
+)
+
+// CodeAction offers quick fixes replacing a misspelled name or import path
+// with the suggestion attached to its diagnostic. Only fixes that are very
+// likely what was meant are marked preferred, since editors may apply those
+// unprompted.
+func (s *Server) CodeAction(p *lsp.CodeActionParams) ([]lsp.CodeAction, *jsonrpc.Error) {
+	doc := s.Get(p.TextDocument.URI)
+	if doc == nil || doc.LineIndex == nil {
+		return nil, jsonrpc.InvalidParamsError(nil)
+	}
+	if !codeActionKindRequested(p.Context.Only, lsp.CodeActionQuickFix) {
+		return nil, nil
+	}
+
+	var published []lsp.Diagnostic
+	actions := []lsp.CodeAction{}
+	for _, diag := range p.Context.Diagnostics {
+		suggestion := diagnosticSuggestion(diag)
+		if suggestion == "" {
+			// Clients without data support send diagnostics back bare, so
+			// look the suggestion up among the current ones.
+			if published == nil {
+				published = toDiagnostics(doc.URI, doc.LineIndex, nil, doc.SemErrs)
+			}
+			for _, current := range published {
+				if current.Range == diag.Range && current.Message == diag.Message {
+					suggestion = diagnosticSuggestion(current)
+					break
+				}
+			}
+		}
+		if suggestion == "" {
+			continue
+		}
+		actions = append(actions, lsp.CodeAction{
+			Title:       "Change to '" + suggestion + "'",
+			Kind:        lsp.CodeActionQuickFix,
+			Diagnostics: []lsp.Diagnostic{diag},
+			IsPreferred: analyser.ConfidentSuggestion(rangeText(doc, diag.Range), suggestion),
+			Edit: &lsp.WorkspaceEdit{
+				Changes: map[lsp.DocumentURI][]lsp.TextEdit{
+					doc.URI: {{Range: diag.Range, NewText: suggestion}},
+				},
+			},
+		})
+	}
+	return actions, nil
+}
+
+// diagnosticSuggestion returns the replacement toDiagnostics attached to a
+// diagnostic, whether built here or decoded from a client request.
+func diagnosticSuggestion(diag lsp.Diagnostic) string {
+	data, _ := diag.Data.(map[string]any)
+	suggestion, _ := data["suggestion"].(string)
+	return suggestion
+}
+
+// rangeText returns the text of doc within r.
+func rangeText(doc *Document, r lsp.Range) string {
+	doc.mu.RLock()
+	defer doc.mu.RUnlock()
+	start := doc.LineIndex.PositionToOffset(r.Start.Line, r.Start.Character)
+	end := doc.LineIndex.PositionToOffset(r.End.Line, r.End.Character)
+	if start < 0 || end > len(doc.Text) || start > end {
+		return ""
+	}
+	return doc.Text[start:end]
+}
+
+// codeActionKindRequested reports whether kind passes the client's filter,
+// where an empty filter accepts every kind.
+func codeActionKindRequested(only []lsp.CodeActionKind, kind lsp.CodeActionKind) bool {
+	if len(only) == 0 {
+		return true
+	}
+	for _, requested := range only {
+		if requested == kind {
+			return true
+		}
+	}
+	return false
+}
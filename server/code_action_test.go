@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"rahu/lsp"
+)
+
+func TestCodeActionFixesMisspelledImports(t *testing.T) {
+	root := t.TempDir()
+	writeWorkspaceFile(t, filepath.Join(root, "utils.py"), "def load_config():\n    return 1\n")
+	mainPath := filepath.Join(root, "main.py")
+	mainCode := "from utils import laod_config\nimport utlis\n"
+	writeWorkspaceFile(t, mainPath, mainCode)
+
+	s := newWorkspaceServer(t, root)
+	mainURI := pathToURI(mainPath)
+	s.Open(lsp.TextDocumentItem{URI: mainURI, Text: mainCode, Version: 1})
+	s.analyze(s.Get(mainURI))
+
+	doc := s.Get(mainURI)
+	// Round-trip through JSON, as a client sends the diagnostics back.
+	data, err := json.Marshal(toDiagnostics(doc.URI, doc.LineIndex, nil, doc.SemErrs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var diags []lsp.Diagnostic
+	if err := json.Unmarshal(data, &diags); err != nil {
+		t.Fatal(err)
+	}
+
+	actions, rpcErr := s.CodeAction(&lsp.CodeActionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: mainURI},
+		Context:      lsp.CodeActionContext{Diagnostics: diags},
+	})
+	if rpcErr != nil {
+		t.Fatalf("unexpected error: %+v", rpcErr)
+	}
+	got := make(map[string]lsp.TextEdit)
+	for _, action := range actions {
+		if action.Kind != lsp.CodeActionQuickFix || action.Edit == nil || len(action.Edit.Changes[mainURI]) != 1 {
+			t.Fatalf("unexpected code action %+v", action)
+		}
+		got[action.Title] = action.Edit.Changes[mainURI][0]
+	}
+	want := map[string]lsp.TextEdit{
+		"Change to 'load_config'": {
+			Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 18}, End: lsp.Position{Line: 0, Character: 29}},
+			NewText: "load_config",
+		},
+		"Change to 'utils'": {
+			Range:   lsp.Range{Start: lsp.Position{Line: 1, Character: 7}, End: lsp.Position{Line: 1, Character: 12}},
+			NewText: "utils",
+		},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d quick fixes, got %+v", len(want), actions)
+	}
+	for title, edit := range want {
+		if got[title] != edit {
+			t.Fatalf("expected %q to edit %+v, got %+v", title, edit, got[title])
+		}
+	}
+
+	// Diagnostics sent back without their data still get the fix.
+	for i := range diags {
+		diags[i].Data = nil
+	}
+	actions, _ = s.CodeAction(&lsp.CodeActionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: mainURI},
+		Context:      lsp.CodeActionContext{Diagnostics: diags, Only: []lsp.CodeActionKind{lsp.CodeActionQuickFix}},
+	})
+	if len(actions) != len(want) {
+		t.Fatalf("expected quick fixes without diagnostic data, got %+v", actions)
+	}
+}
+
+func TestCodeActionPrefersOnlyCloseSuggestions(t *testing.T) {
+	code := "def load():\n    pass\n\ndef handle(request):\n    return reqeust, lod\n"
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	doc := s.Get(uri)
+	actions, rpcErr := s.CodeAction(&lsp.CodeActionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+		Context:      lsp.CodeActionContext{Diagnostics: toDiagnostics(doc.URI, doc.LineIndex, nil, doc.SemErrs)},
+	})
+	if rpcErr != nil {
+		t.Fatalf("unexpected error: %+v", rpcErr)
+	}
+	preferred := make(map[string]bool)
+	for _, action := range actions {
+		preferred[action.Title] = action.IsPreferred
+	}
+	// A swapped pair of letters in a long name is surely a typo; one missing
+	// letter out of three may not be.
+	want := map[string]bool{"Change to 'request'": true, "Change to 'load'": false}
+	if len(preferred) != len(want) {
+		t.Fatalf("expected %d quick fixes, got %+v", len(want), actions)
+	}
+	for title, isPreferred := range want {
+		if got, ok := preferred[title]; !ok || got != isPreferred {
+			t.Fatalf("expected %q to have IsPreferred %v, got %+v", title, isPreferred, actions)
+		}
+	}
+}
@@ -31,9 +31,20 @@ func toCompletionItemKind(sym *a.Symbol) lsp.CompletionItemKind {
 	case a.SymClass, a.SymType:
 		return lsp.CompletionItemKindClass
 	case a.SymFunction:
+		if sym.Method == a.MethodProperty {
+			return lsp.CompletionItemKindProperty
+		}
+		if sym.Scope != nil && (sym.Scope.Kind == a.ScopeClass || sym.Scope.Kind == a.ScopeMember) {
+			return lsp.CompletionItemKindMethod
+		}
 		return lsp.CompletionItemKindFunction
 	case a.SymAttr, a.SymField:
 		return lsp.CompletionItemKindField
+	case a.SymVariable:
+		if a.IsEnumMember(sym) {
+			return lsp.CompletionItemKindEnumMember
+		}
+		return lsp.CompletionItemKindVariable
 	case a.SymConstant:
 		return lsp.CompletionItemKindConstant
 	default:
@@ -410,25 +421,48 @@ func (s *Server) childModuleItems(container, prefix string) []lsp.CompletionItem
 	return rankAndDedupeCompletions(candidates, false)
 }
 
-func exportCompletionItems(snapshot *ModuleSnapshot, prefix string) []lsp.CompletionItem {
+// exportCompletionItems completes the names a module exports. When the
+// module has an __all__, the names it lists come first and are offered even
+// when they are re-exports the index could not locate.
+func (s *Server) exportCompletionItems(snapshot *ModuleSnapshot, prefix string) []lsp.CompletionItem {
 	if snapshot == nil || snapshot.Exports == nil {
 		return nil
 	}
+	listed := s.listedExports(snapshot.Name, snapshot.Tree, snapshot.Exports)
 	candidates := make([]scoredCompletion, 0, len(snapshot.Exports))
 	for name, sym := range snapshot.Exports {
-		if sym == nil || sym.Kind == a.SymImport || sym.Span.IsEmpty() || !matchesPrefix(prefix, name) {
+		if sym == nil || !matchesPrefix(prefix, name) {
 			continue
 		}
+		public := listed[name] != nil
+		if !public && (sym.Kind == a.SymImport || sym.Span.IsEmpty()) {
+			continue
+		}
+		bonus := 240
+		if listed != nil && !public {
+			bonus = 200
+		}
 		isBuiltin := isBuiltinSymbol(sym)
 		candidates = append(candidates, scoredCompletion{
 			item:      lsp.CompletionItem{Label: name, Kind: toCompletionItemKind(sym), Detail: snapshot.Name},
-			score:     completionScore(name, prefix, 0, 240, isBuiltin),
+			score:     completionScore(name, prefix, 0, bonus, isBuiltin),
 			isBuiltin: isBuiltin,
 		})
 	}
 	return rankAndDedupeCompletions(candidates, false)
 }
 
+// memberCompletionDetail describes an enum member by its Literal type and
+// other members by the given detail.
+func memberCompletionDetail(sym *a.Symbol, detail string) string {
+	if a.IsEnumMember(sym) {
+		if typ := formatHoverType(a.SymbolType(sym)); typ != "" {
+			return typ
+		}
+	}
+	return detail
+}
+
 func memberCompletionItems(scope *a.Scope, prefix string, detail string) []lsp.CompletionItem {
 	if scope == nil {
 		return nil
@@ -440,7 +474,7 @@ func memberCompletionItems(scope *a.Scope, prefix string, detail string) []lsp.C
 		}
 		isBuiltin := isBuiltinSymbol(sym)
 		candidates = append(candidates, scoredCompletion{
-			item:      lsp.CompletionItem{Label: name, Kind: toCompletionItemKind(sym), Detail: detail},
+			item:      lsp.CompletionItem{Label: name, Kind: toCompletionItemKind(sym), Detail: memberCompletionDetail(sym, detail)},
 			score:     completionScore(name, prefix, 0, 230, isBuiltin),
 			isBuiltin: isBuiltin,
 		})
@@ -455,30 +489,23 @@ func classMemberCompletionItems(cls *a.Symbol, prefix string, detail string) []l
 	// Estimate ~10 members per class on average
 	candidates := make([]scoredCompletion, 0, 16)
 	seen := make(map[string]struct{}, 16)
-	var collect func(*a.Symbol)
-	collect = func(sym *a.Symbol) {
-		if sym == nil {
-			return
+	for _, sym := range a.MRO(cls) {
+		if sym.Members == nil {
+			continue
 		}
-		if sym.Members != nil {
-			for name, member := range sym.Members.Symbols {
-				if _, ok := seen[name]; ok || member == nil || !matchesPrefix(prefix, name) {
-					continue
-				}
-				seen[name] = struct{}{}
-				isBuiltin := isBuiltinSymbol(member)
-				candidates = append(candidates, scoredCompletion{
-					item:      lsp.CompletionItem{Label: name, Kind: toCompletionItemKind(member), Detail: detail},
-					score:     completionScore(name, prefix, 0, 230, isBuiltin),
-					isBuiltin: isBuiltin,
-				})
+		for name, member := range sym.Members.Symbols {
+			if _, ok := seen[name]; ok || member == nil || !matchesPrefix(prefix, name) {
+				continue
 			}
-		}
-		for _, base := range sym.Bases {
-			collect(base)
+			seen[name] = struct{}{}
+			isBuiltin := isBuiltinSymbol(member)
+			candidates = append(candidates, scoredCompletion{
+				item:      lsp.CompletionItem{Label: name, Kind: toCompletionItemKind(member), Detail: memberCompletionDetail(member, detail)},
+				score:     completionScore(name, prefix, 0, 230, isBuiltin),
+				isBuiltin: isBuiltin,
+			})
 		}
 	}
-	collect(cls)
 	return rankAndDedupeCompletions(candidates, false)
 }
 
@@ -612,7 +639,7 @@ func (s *Server) moduleMemberCompletions(doc *Document, pos lsp.Position, receiv
 			}
 		}
 		candidates := make([]scoredCompletion, 0, 16)
-		for _, item := range exportCompletionItems(snapshot, memberPrefix) {
+		for _, item := range s.exportCompletionItems(snapshot, memberPrefix) {
 			candidates = append(candidates, scoredCompletion{item: item, score: completionScore(item.Label, memberPrefix, 0, 240, false)})
 		}
 		if snapshot != nil && snapshot.Name != "" {
@@ -657,7 +684,7 @@ func (s *Server) Completion(p *lsp.CompletionParams) ([]lsp.CompletionItem, *jso
 		if !found {
 			return []lsp.CompletionItem{}, nil
 		}
-		return exportCompletionItems(snapshot, prefix), nil
+		return s.exportCompletionItems(snapshot, prefix), nil
 	}
 	if receiver, memberPrefix, ok := dottedAccessAt(doc, p.Position); ok {
 		return s.moduleMemberCompletions(doc, p.Position, receiver, memberPrefix), nil
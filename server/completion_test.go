@@ -41,6 +41,25 @@ func TestCompletionFromImportExports(t *testing.T) {
 	assertCompletionLabel(t, items, "foo")
 }
 
+func TestCompletionFromImportRanksAllFirst(t *testing.T) {
+	root := t.TempDir()
+	writeWorkspaceFile(t, filepath.Join(root, "mod.py"), "fetch_raw = 1\nfetch = 2\n__all__ = []\n__all__.append('fetch_raw')\n")
+
+	s := newWorkspaceServer(t, root)
+	uri := pathToURI(filepath.Join(root, "main.py"))
+	code := "from mod import fe"
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	items, err := s.Completion(&lsp.CompletionParams{TextDocument: lsp.TextDocumentIdentifier{URI: uri}, Position: lsp.Position{Line: 0, Character: len(code)}})
+	if err != nil {
+		t.Fatalf("unexpected completion error: %v", err)
+	}
+	if len(items) != 2 || items[0].Label != "fetch_raw" || items[1].Label != "fetch" {
+		t.Fatalf("expected the name listed in __all__ first, got %+v", items)
+	}
+}
+
 func TestCompletionAliasedModuleMember(t *testing.T) {
 	root := t.TempDir()
 	writeWorkspaceFile(t, filepath.Join(root, "pkg", "mod.py"), "foo = 1\nbar = 2\n")
@@ -186,6 +205,29 @@ func TestCompletionInstanceAttributeMembers(t *testing.T) {
 	assertCompletionLabel(t, items, "method")
 }
 
+func TestCompletionMemberKinds(t *testing.T) {
+	code := "class Foo:\n    @property\n    def size(self) -> int:\n        return 1\n\n    def method(self):\n        pass\n\nx = Foo()\nx.\n"
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	items, err := s.Completion(&lsp.CompletionParams{TextDocument: lsp.TextDocumentIdentifier{URI: uri}, Position: lsp.Position{Line: 9, Character: 2}})
+	if err != nil {
+		t.Fatalf("unexpected completion error: %v", err)
+	}
+	kinds := map[string]lsp.CompletionItemKind{}
+	for _, item := range items {
+		kinds[item.Label] = item.Kind
+	}
+	if kinds["size"] != lsp.CompletionItemKindProperty {
+		t.Fatalf("expected size to complete as a property, got %v", kinds["size"])
+	}
+	if kinds["method"] != lsp.CompletionItemKindMethod {
+		t.Fatalf("expected method to complete as a method, got %v", kinds["method"])
+	}
+}
+
 func TestCompletionClassMembers(t *testing.T) {
 	code := "class Foo:\n    def __init__(self):\n        self.value = 1\n\n    def method(self):\n        pass\n\nFoo.\n"
 	s := New(nil)
@@ -23,3 +23,36 @@ func TestHoverWorksOnDecoratorName(t *testing.T) {
 		t.Fatalf("expected hover on decorator name, got %q", content.Value)
 	}
 }
+
+func TestDecoratedFunctionShowsPostDecorationSignature(t *testing.T) {
+	code := "from typing import Callable, Concatenate, ParamSpec, TypeVar\n\n" +
+		"P = ParamSpec(\"P\")\nR = TypeVar(\"R\")\n\n" +
+		"class Request:\n    pass\n\n" +
+		"def with_request(fn: Callable[Concatenate[Request, P], R]) -> Callable[P, list[R]]: ...\n\n" +
+		"@with_request\ndef handle(request: Request, name: str) -> int:\n    return 1\n\n" +
+		"handle(\n"
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	hov := mustHoverAt(t, s, uri, 11, 5)
+	content, ok := hov.Contents.(lsp.MarkupContent)
+	if !ok {
+		t.Fatalf("expected markup content, got %T", hov.Contents)
+	}
+	if !strings.Contains(content.Value, "handle(name) -> list[int]") {
+		t.Fatalf("expected decorated signature in hover, got %q", content.Value)
+	}
+
+	help, err := s.SignatureHelp(signatureHelpParams(uri, code, 14, 7))
+	if err != nil {
+		t.Fatalf("unexpected signatureHelp error: %v", err)
+	}
+	if len(help.Signatures) != 1 {
+		t.Fatalf("unexpected signatures: %+v", help)
+	}
+	if label := help.Signatures[0].Label; !strings.Contains(label, "handle(name: str) -> list[int]") {
+		t.Fatalf("expected decorated signature label, got %q", label)
+	}
+}
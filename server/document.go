@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -222,15 +223,32 @@ func (s *Server) Initialize(
 		}
 	}
 
+	settings, warnings := parseSettings(p.InitializationOptions, rootPath)
+	for _, warning := range warnings {
+		log.Printf("[settings] %s", warning)
+		s.showWarningMessage(warning)
+	}
+
 	s.miscMu.Lock()
 	s.capabilities = p.Capabilities
 	s.rootURI = rootURI
 	s.rootPath = rootPath
 	s.priorityDir = rootPath // Default priority to workspace root
+	s.settings = settings
 	s.miscMu.Unlock()
 
-	env := discoverPythonEnvCached(rootPath, s)
-	roots := normalizeExternalSearchRoots(rootPath, env.Paths)
+	s.snapshotsMu.Lock()
+	s.maxCachedModules = settings.MaxCachedModules
+	s.snapshotsMu.Unlock()
+
+	env := discoverPythonEnvCached(rootPath, settings.Python.PythonPath, s)
+	target := pythonTarget(env.Version, settings.Python.Platform)
+	s.miscMu.Lock()
+	s.target = target
+	s.miscMu.Unlock()
+	log.Printf("[python-env] Analysing for %s", target)
+	// Extra paths come first so that they take precedence over sys.path.
+	roots := normalizeExternalSearchRoots(rootPath, append(slices.Clone(settings.Python.ExtraPaths), env.Paths...))
 	builtins := make(map[string]struct{}, len(env.Builtins))
 	for _, name := range env.Builtins {
 		if name == "" {
@@ -312,9 +330,11 @@ func (s *Server) Initialize(
 			},
 			DefinitionProvider:      true,
 			ReferencesProvider:      true,
+			ImplementationProvider:  true,
 			RenameProvider:          map[string]any{"prepareProvider": true},
 			DocumentSymbolProvider:  true,
 			WorkspaceSymbolProvider: true,
+			CodeActionProvider:      map[string]any{"codeActionKinds": []lsp.CodeActionKind{lsp.CodeActionQuickFix}},
 		},
 	}, nil
 }
@@ -481,7 +501,7 @@ func (s *Server) reanalyzeOpenDocuments() {
 			// during indexing, the debounce timer already queued a re-analysis.
 			if snapshot, ok := s.getModuleSnapshotByURI(doc.URI); ok {
 				s.applySnapshotToOpenDocument(snapshot)
-				s.publishDiagnostics(doc.URI, toDiagnostics(snapshot.LineIndex, snapshot.ParseErrs, snapshot.SemErrs))
+				s.publishDiagnostics(doc.URI, toDiagnostics(snapshot.URI, snapshot.LineIndex, snapshot.ParseErrs, s.documentErrors(snapshot)))
 				continue
 			}
 		}
@@ -43,6 +43,8 @@ func assignmentSymbols(doc *Document, stmt ast.NodeID, target ast.NodeID) []lsp.
 			out = append(out, assignmentSymbols(doc, stmt, child)...)
 		}
 		return out
+	case ast.NodeStarArg:
+		return assignmentSymbols(doc, stmt, doc.Tree.ChildAt(target, 0))
 	default:
 		return nil
 	}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rahu/lsp"
+)
+
+func TestEnumMembersCompleteWithLiteralTypes(t *testing.T) {
+	code := "from enum import Enum, auto\n\n" +
+		"class Color(Enum):\n    RED = 1\n    GREEN = auto()\n\n" +
+		"value = Color.RED.value\nColor.\n"
+	root := t.TempDir()
+	path := filepath.Join(root, "main.py")
+	writeWorkspaceFile(t, path, code)
+	s := newWorkspaceServer(t, root)
+	uri := pathToURI(path)
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	items, err := s.Completion(&lsp.CompletionParams{TextDocument: lsp.TextDocumentIdentifier{URI: uri}, Position: lsp.Position{Line: 7, Character: 6}})
+	if err != nil {
+		t.Fatalf("unexpected completion error: %v", err)
+	}
+	members := map[string]lsp.CompletionItem{}
+	for _, item := range items {
+		members[item.Label] = item
+	}
+	for name, detail := range map[string]string{"RED": "Literal[Color.RED]", "GREEN": "Literal[Color.GREEN]"} {
+		item, ok := members[name]
+		if !ok {
+			t.Fatalf("expected %s in completions, got %+v", name, items)
+		}
+		if item.Kind != lsp.CompletionItemKindEnumMember || item.Detail != detail {
+			t.Fatalf("expected %s to be an enum member with detail %q, got %+v", name, detail, item)
+		}
+	}
+
+	hov := mustHoverAt(t, s, uri, 6, 1)
+	content, ok := hov.Contents.(lsp.MarkupContent)
+	if !ok {
+		t.Fatalf("expected markup content, got %T", hov.Contents)
+	}
+	if !strings.Contains(content.Value, "int") {
+		t.Fatalf("expected Color.RED.value to be int, got %q", content.Value)
+	}
+}
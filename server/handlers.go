@@ -47,15 +47,36 @@ func (s *Server) hoverTarget(sym *a.Symbol, current *Document) (lsp.DocumentURI,
 }
 
 func formatHoverType(t *a.Type) string {
+	if t != nil && t.AliasRef != nil {
+		// A type alias referring to itself is shown by name.
+		return t.AliasRef.Name
+	}
 	if a.IsUnknownType(t) {
 		return ""
 	}
 	switch t.Kind {
 	case a.TypeInstance:
+		if t.Symbol != nil && t.Literal != nil {
+			return "Literal[" + t.Symbol.Name + "." + t.Literal.Name + "]"
+		}
+		if t.Symbol != nil && len(t.Args) > 0 {
+			parts := make([]string, 0, len(t.Args))
+			for _, arg := range t.Args {
+				formatted := formatHoverType(arg)
+				if formatted == "" {
+					formatted = "unknown"
+				}
+				parts = append(parts, formatted)
+			}
+			return t.Symbol.Name + "[" + strings.Join(parts, ", ") + "]"
+		}
 		if t.Symbol != nil {
 			return t.Symbol.Name
 		}
 	case a.TypeBuiltin:
+		if t.Symbol != nil && t.Symbol.Name == "NoneType" {
+			return "None"
+		}
 		if t.Symbol != nil {
 			return t.Symbol.Name
 		}
@@ -73,6 +94,12 @@ func formatHoverType(t *a.Type) string {
 		}
 		return "list"
 	case a.TypeTuple:
+		if t.Elem != nil {
+			if elem := formatHoverType(t.Elem); elem != "" {
+				return "tuple[" + elem + ", ...]"
+			}
+			return "tuple"
+		}
 		if len(t.Items) == 0 {
 			return "tuple"
 		}
@@ -100,20 +127,66 @@ func formatHoverType(t *a.Type) string {
 			return "set[" + elem + "]"
 		}
 		return "set"
+	case a.TypeVariable:
+		if t.Symbol != nil {
+			return t.Symbol.Name
+		}
+	case a.TypeCallable:
+		return formatCallableType(t)
 	case a.TypeUnion:
 		parts := make([]string, 0, len(t.Union))
+		// Enum members share a single Literal[...] in place of the first.
+		literals, literalAt := []string(nil), -1
 		for _, arm := range t.Union {
+			if arm.Kind == a.TypeInstance && arm.Symbol != nil && arm.Literal != nil {
+				if literalAt < 0 {
+					literalAt = len(parts)
+					parts = append(parts, "")
+				}
+				literals = append(literals, arm.Symbol.Name+"."+arm.Literal.Name)
+				continue
+			}
 			formatted := formatHoverType(arm)
 			if formatted == "" {
 				continue
 			}
 			parts = append(parts, formatted)
 		}
+		if literalAt >= 0 {
+			parts[literalAt] = "Literal[" + strings.Join(literals, ", ") + "]"
+		}
 		return strings.Join(parts, " | ")
 	}
 	return ""
 }
 
+// formatCallableType renders a callable type the way it is annotated.
+func formatCallableType(t *a.Type) string {
+	returns := formatHoverType(t.Returns)
+	if returns == "" {
+		returns = "unknown"
+	}
+	if t.Params == nil {
+		return "Callable[..., " + returns + "]"
+	}
+	params := make([]string, 0, len(t.Params))
+	for _, param := range t.Params {
+		formatted := formatHoverType(a.SymbolType(param))
+		if formatted == "" {
+			formatted = "unknown"
+		}
+		params = append(params, formatted)
+	}
+	if t.Spec == nil {
+		return "Callable[[" + strings.Join(params, ", ") + "], " + returns + "]"
+	}
+	spec := formatHoverType(t.Spec)
+	if len(params) == 0 {
+		return "Callable[" + spec + ", " + returns + "]"
+	}
+	return "Callable[Concatenate[" + strings.Join(params, ", ") + ", " + spec + "], " + returns + "]"
+}
+
 func (s *Server) hoverForSymbol(doc *Document, sym *a.Symbol) *lsp.Hover {
 	var kind string
 	switch sym.Kind {
@@ -123,12 +196,17 @@ func (s *Server) hoverForSymbol(doc *Document, sym *a.Symbol) *lsp.Hover {
 		kind = "parameter"
 	case a.SymFunction:
 		kind = "function"
+		if sym.Method == a.MethodProperty {
+			kind = "property"
+		}
 	case a.SymClass:
 		kind = "class"
 	case a.SymModule:
 		kind = "module"
 	case a.SymBuiltin:
 		kind = "builtin"
+	case a.SymConstant:
+		kind = "constant"
 	case a.SymType:
 		kind = "type"
 	case a.SymAttr:
@@ -139,7 +217,14 @@ func (s *Server) hoverForSymbol(doc *Document, sym *a.Symbol) *lsp.Hover {
 
 	var builder strings.Builder
 	typeText := ""
-	if sym.Kind == a.SymVariable || sym.Kind == a.SymParameter || sym.Kind == a.SymAttr || sym.Kind == a.SymField {
+	isProperty := sym.Kind == a.SymFunction && sym.Method == a.MethodProperty
+	isConstant := sym.Kind == a.SymConstant && !isBuiltinSymbol(sym)
+	hasType := sym.Kind == a.SymVariable || sym.Kind == a.SymParameter || sym.Kind == a.SymAttr || sym.Kind == a.SymField || isConstant || isProperty
+	if sym.TypeAlias != nil {
+		kind = "type alias"
+		hasType = false
+	}
+	if hasType {
 		typeText = formatHoverType(a.SymbolType(sym))
 	}
 	builder.WriteString("```python\n")
@@ -150,7 +235,10 @@ func (s *Server) hoverForSymbol(doc *Document, sym *a.Symbol) *lsp.Hover {
 		builder.WriteString(": ")
 		builder.WriteString(typeText)
 	}
-	if sym.DefaultValue != "" {
+	if expansion := formatHoverType(sym.TypeAlias); expansion != "" {
+		builder.WriteString(" = ")
+		builder.WriteString(expansion)
+	} else if sym.DefaultValue != "" {
 		builder.WriteString(" = ")
 		builder.WriteString(sym.DefaultValue)
 	}
@@ -166,21 +254,19 @@ func (s *Server) hoverForSymbol(doc *Document, sym *a.Symbol) *lsp.Hover {
 		builder.WriteString(sym.DocString)
 	}
 
-	if sym.Kind == a.SymFunction && sym.Inner != nil {
+	if sym.Kind == a.SymFunction && sym.Inner != nil && !isProperty {
 		params := []string{}
-		for _, p := range sym.Inner.Symbols {
-			if p.Kind == a.SymParameter {
-				paramStr := p.Name
-				if p.IsKwArg {
-					paramStr = "**" + paramStr
-				} else if p.IsVarArg {
-					paramStr = "*" + paramStr
-				}
-				if p.DefaultValue != "" {
-					paramStr += "=" + p.DefaultValue
-				}
-				params = append(params, paramStr)
+		for _, p := range a.OrderedParams(sym) {
+			paramStr := p.Name
+			if p.IsKwArg {
+				paramStr = "**" + paramStr
+			} else if p.IsVarArg {
+				paramStr = "*" + paramStr
 			}
+			if p.DefaultValue != "" {
+				paramStr += "=" + p.DefaultValue
+			}
+			params = append(params, paramStr)
 		}
 		name := sym.Name
 
@@ -191,10 +277,21 @@ func (s *Server) hoverForSymbol(doc *Document, sym *a.Symbol) *lsp.Hover {
 
 		builder.Reset()
 		builder.WriteString("```python\n")
+		switch sym.Method {
+		case a.MethodClassMethod:
+			builder.WriteString("@classmethod\n")
+		case a.MethodStaticMethod:
+			builder.WriteString("@staticmethod\n")
+		}
 		builder.WriteString(name)
 		builder.WriteString("(")
 		builder.WriteString(strings.Join(params, ", "))
-		builder.WriteString(")\n")
+		builder.WriteString(")")
+		if returns := formatHoverType(sym.Returns); returns != "" {
+			builder.WriteString(" -> ")
+			builder.WriteString(returns)
+		}
+		builder.WriteString("\n")
 
 		if sym.DocString != "" {
 
@@ -206,7 +303,7 @@ func (s *Server) hoverForSymbol(doc *Document, sym *a.Symbol) *lsp.Hover {
 
 	}
 
-	if (sym.Kind == a.SymVariable || sym.Kind == a.SymParameter || sym.Kind == a.SymAttr || sym.Kind == a.SymField) && typeText != "" {
+	if hasType && typeText != "" {
 		builder.Reset()
 		builder.WriteString("```\n")
 		builder.WriteString(kind)
@@ -270,75 +367,43 @@ func (s *Server) enhanceHoverForInheritedMethod(sym *a.Symbol) string {
 		return ""
 	}
 
-	// Walk full MRO to find method origin
-	mro := walkFullMRO(owner)
-	for _, base := range mro {
-		if base == nil || base.Members == nil {
+	// The first class in the MRO defining the method is its origin
+	for _, base := range a.MRO(owner) {
+		if !a.DefinesMember(base, sym.Name) {
 			continue
 		}
+		if !isSyntheticSymbol(base) {
+			return ""
+		}
 
-		// Check if this base class has the method
-		if _, hasMethod := base.Members.Symbols[sym.Name]; hasMethod {
-			// Check if this is a synthetic class
-			if !isSyntheticSymbol(base) {
-				continue
-			}
+		// Extract module and class name from the synthetic URI
+		moduleName, className := extractModuleAndClassFromSynthetic(base.URI, base.Name)
+		if moduleName == "" || className == "" {
+			return ""
+		}
 
-			// Extract module and class name from the synthetic URI
-			moduleName, className := extractModuleAndClassFromSynthetic(base.URI, base.Name)
-			if moduleName == "" || className == "" {
-				continue
-			}
+		// Try to get method info from cache or lazy introspection
+		if info, ok := s.getMethodInfo(moduleName, className, sym.Name); ok {
+			var builder strings.Builder
+			builder.WriteString("```python\n")
+			builder.WriteString(className)
+			builder.WriteString(".")
+			builder.WriteString(sym.Name)
+			builder.WriteString(info.Signature)
+			builder.WriteString("\n```")
 
-			// Try to get method info from cache or lazy introspection
-			if info, ok := s.getMethodInfo(moduleName, className, sym.Name); ok {
-				var builder strings.Builder
-				builder.WriteString("```python\n")
-				builder.WriteString(className)
-				builder.WriteString(".")
-				builder.WriteString(sym.Name)
-				builder.WriteString(info.Signature)
-				builder.WriteString("\n```")
-
-				if info.Docstring != "" {
-					builder.WriteString("\n\n")
-					builder.WriteString(info.Docstring)
-				}
-				return builder.String()
+			if info.Docstring != "" {
+				builder.WriteString("\n\n")
+				builder.WriteString(info.Docstring)
 			}
+			return builder.String()
 		}
+		return ""
 	}
 
 	return ""
 }
 
-// walkFullMRO walks the complete Method Resolution Order of a class.
-// Returns a slice of class symbols in MRO order (including the class itself).
-func walkFullMRO(cls *a.Symbol) []*a.Symbol {
-	if cls == nil {
-		return nil
-	}
-
-	var result []*a.Symbol
-	seen := make(map[*a.Symbol]bool)
-
-	var walk func(*a.Symbol)
-	walk = func(c *a.Symbol) {
-		if c == nil || seen[c] {
-			return
-		}
-		seen[c] = true
-		result = append(result, c)
-
-		for _, base := range c.Bases {
-			walk(base)
-		}
-	}
-
-	walk(cls)
-	return result
-}
-
 // isSyntheticSymbol checks if a symbol comes from a builtin/frozen module.
 func isSyntheticSymbol(sym *a.Symbol) bool {
 	if sym == nil || sym.URI == "" {
@@ -394,33 +459,24 @@ func (s *Server) importModuleSymbolAtOffset(doc *Document, offset int) *a.Symbol
 	if doc == nil || doc.Tree == nil {
 		return nil
 	}
-	for stmt := doc.Tree.Node(doc.Tree.Root).FirstChild; stmt != ast.NoNode; stmt = doc.Tree.Node(stmt).NextSibling {
-		if !l.Contains(doc.Tree.RangeOf(stmt), offset) {
-			continue
+	var found *a.Symbol
+	a.WalkActiveImports(doc.Tree, s.analysisTarget(), func(stmt ast.NodeID, _ bool) {
+		if found == nil && l.Contains(doc.Tree.RangeOf(stmt), offset) {
+			found = s.importModuleSymbolInStmt(doc, stmt, offset)
 		}
-		switch doc.Tree.Node(stmt).Kind {
-		case ast.NodeImport:
-			for alias := doc.Tree.Node(stmt).FirstChild; alias != ast.NoNode; alias = doc.Tree.Node(alias).NextSibling {
-				target, _ := doc.Tree.AliasParts(alias)
-				if target == ast.NoNode || !l.Contains(doc.Tree.RangeOf(target), offset) {
-					continue
-				}
-				moduleName, ok := moduleNameFromExpr(doc.Tree, target)
-				if !ok {
-					continue
-				}
-				snapshot, ok := s.analyzeModuleByName(moduleName)
-				if !ok {
-					continue
-				}
-				return &a.Symbol{Name: moduleName, Kind: a.SymModule, URI: snapshot.URI, Span: moduleDefSpan(snapshot)}
-			}
-		case ast.NodeFromImport:
-			module, _ := doc.Tree.FromImportParts(stmt)
-			if module == ast.NoNode || !l.Contains(doc.Tree.RangeOf(module), offset) {
+	})
+	return found
+}
+
+func (s *Server) importModuleSymbolInStmt(doc *Document, stmt ast.NodeID, offset int) *a.Symbol {
+	switch doc.Tree.Node(stmt).Kind {
+	case ast.NodeImport:
+		for alias := doc.Tree.Node(stmt).FirstChild; alias != ast.NoNode; alias = doc.Tree.Node(alias).NextSibling {
+			target, _ := doc.Tree.AliasParts(alias)
+			if target == ast.NoNode || !l.Contains(doc.Tree.RangeOf(target), offset) {
 				continue
 			}
-			moduleName, ok := s.resolveImportModuleName(doc.URI, doc.Tree, module, doc.Tree.Node(stmt).Data)
+			moduleName, ok := moduleNameFromExpr(doc.Tree, target)
 			if !ok {
 				continue
 			}
@@ -430,6 +486,20 @@ func (s *Server) importModuleSymbolAtOffset(doc *Document, offset int) *a.Symbol
 			}
 			return &a.Symbol{Name: moduleName, Kind: a.SymModule, URI: snapshot.URI, Span: moduleDefSpan(snapshot)}
 		}
+	case ast.NodeFromImport:
+		module, _ := doc.Tree.FromImportParts(stmt)
+		if module == ast.NoNode || !l.Contains(doc.Tree.RangeOf(module), offset) {
+			return nil
+		}
+		moduleName, ok := s.resolveImportModuleName(doc.URI, doc.Tree, module, doc.Tree.Node(stmt).Data)
+		if !ok {
+			return nil
+		}
+		snapshot, ok := s.analyzeModuleByName(moduleName)
+		if !ok {
+			return nil
+		}
+		return &a.Symbol{Name: moduleName, Kind: a.SymModule, URI: snapshot.URI, Span: moduleDefSpan(snapshot)}
 	}
 	return nil
 }
@@ -533,7 +603,7 @@ func (s *Server) publishDiagnostics(uri lsp.DocumentURI, diags []lsp.Diagnostic)
 	_ = s.conn.Notify("textDocument/publishDiagnostics", params)
 }
 
-func (s *Server) Definition(p *lsp.DefinitionParams) (*lsp.Location, *jsonrpc.Error) {
+func (s *Server) Definition(p *lsp.DefinitionParams) ([]lsp.Location, *jsonrpc.Error) {
 	doc := s.Get(p.TextDocument.URI)
 	if doc == nil {
 		return nil, jsonrpc.InvalidParamsError(nil)
@@ -559,15 +629,19 @@ func (s *Server) Definition(p *lsp.DefinitionParams) (*lsp.Location, *jsonrpc.Er
 			return nil, nil
 		}
 
+		if locs := s.attributeAssignmentLocations(doc.URI, sym); len(locs) > 1 {
+			return locs, nil
+		}
+
 		if sym.Kind != a.SymBuiltin &&
-			sym.Kind != a.SymConstant &&
+			!(sym.Kind == a.SymConstant && isBuiltinSymbol(sym)) &&
 			sym.Kind != a.SymType &&
 			!sym.Span.IsEmpty() {
 
-			return &lsp.Location{
+			return []lsp.Location{{
 				URI:   uri,
 				Range: ToRange(li, sym.Span),
-			}, nil
+			}}, nil
 		}
 	}
 
@@ -581,12 +655,32 @@ func (s *Server) Definition(p *lsp.DefinitionParams) (*lsp.Location, *jsonrpc.Er
 		if isSyntheticURI(uri) {
 			return nil, nil
 		}
-		return &lsp.Location{URI: uri, Range: ToRange(li, sym.Span)}, nil
+		return []lsp.Location{{URI: uri, Range: ToRange(li, sym.Span)}}, nil
 	}
 
 	return nil, jsonrpc.InvalidParamsError(nil)
 }
 
+// attributeAssignmentLocations lists every self.<attr> assignment of an
+// instance attribute, including those made in base classes.
+func (s *Server) attributeAssignmentLocations(docURI lsp.DocumentURI, sym *a.Symbol) []lsp.Location {
+	var locs []lsp.Location
+	for _, attr := range a.InheritedAttrs(sym) {
+		uri := docURI
+		if attr.URI != "" {
+			uri = attr.URI
+		}
+		li := s.lineIndexForURI(uri)
+		if li == nil || isSyntheticURI(uri) {
+			continue
+		}
+		for _, span := range attr.Assignments {
+			locs = append(locs, lsp.Location{URI: uri, Range: ToRange(li, span)})
+		}
+	}
+	return locs
+}
+
 func (s *Server) scheduleAnalysis(uri lsp.DocumentURI) {
 	s.miscMu.Lock()
 	if t, ok := s.debounce[uri]; ok {
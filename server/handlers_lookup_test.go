@@ -1,6 +1,7 @@
 package server
 
 import (
+	"strings"
 	"testing"
 
 	"rahu/lsp"
@@ -26,13 +27,14 @@ f.value
 	offset := li.PositionToOffset(5, 2)
 	line, char := li.OffsetToPosition(offset)
 
-	loc, err := s.Definition(&lsp.DefinitionParams{
+	locs, err := s.Definition(&lsp.DefinitionParams{
 		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
 		Position: lsp.Position{
 			Line:      line,
 			Character: char,
 		},
 	})
+	loc := firstLocation(locs)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -42,6 +44,41 @@ f.value
 	}
 }
 
+func TestDefinitionAttributeListsEveryAssignment(t *testing.T) {
+	code := `class Base:
+    def __init__(self):
+        self.value = 1
+
+class Foo(Base):
+    def show(self):
+        return self.value
+
+    def reset(self):
+        self.value = 2
+
+Foo().value
+`
+
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	locs, err := s.Definition(&lsp.DefinitionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+		Position:     lsp.Position{Line: 11, Character: 7},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(locs) != 2 {
+		t.Fatalf("expected both assignment sites, got %+v", locs)
+	}
+	if locs[0].Range.Start.Line != 9 || locs[1].Range.Start.Line != 2 {
+		t.Fatalf("expected Foo's assignment before Base's, got %+v", locs)
+	}
+}
+
 func TestHoverAttributeLookup(t *testing.T) {
 	code := `class Foo:
     def __init__(self):
@@ -81,3 +118,31 @@ f.value
 		t.Fatalf("expected hover range at 2:13, got %d:%d", hov.Range.Start.Line, hov.Range.Start.Character)
 	}
 }
+
+func TestHoverTypeAliasShowsExpansion(t *testing.T) {
+	code := `JSON = dict[str, "JSON"] | list["JSON"] | None
+
+def load(doc: JSON):
+    return doc
+`
+
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	for _, pos := range []lsp.Position{{Line: 0, Character: 1}, {Line: 2, Character: 15}} {
+		hov, err := s.Hover(&lsp.HoverParams{
+			TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+			Position:     pos,
+		})
+		if err != nil || hov == nil {
+			t.Fatalf("expected hover at %+v, got %v", pos, err)
+		}
+		content, _ := hov.Contents.(lsp.MarkupContent)
+		want := "type alias(JSON = dict[str, JSON] | list[JSON] | None)"
+		if !strings.Contains(content.Value, want) {
+			t.Fatalf("expected hover at %+v to contain %q, got %q", pos, want, content.Value)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"slices"
+	"sort"
+
+	a "rahu/analyser"
+	"rahu/jsonrpc"
+	"rahu/lsp"
+)
+
+// implementationCandidate is a class that may implement a protocol, with
+// the document its symbols without a URI belong to.
+type implementationCandidate struct {
+	cls *a.Symbol
+	uri lsp.DocumentURI
+}
+
+// Implementation lists the classes that structurally satisfy the protocol
+// under the cursor. On a protocol member it lists each class's definition of
+// that member instead.
+func (s *Server) Implementation(p *lsp.ImplementationParams) ([]lsp.Location, *jsonrpc.Error) {
+	if err := s.WaitForIndexing(); err != nil {
+		return []lsp.Location{}, nil
+	}
+
+	doc := s.Get(p.TextDocument.URI)
+	if doc == nil {
+		return nil, jsonrpc.InvalidParamsError(nil)
+	}
+
+	doc.mu.RLock()
+	offset := doc.LineIndex.PositionToOffset(p.Position.Line, p.Position.Character)
+	sym, _, _ := symbolAtOffset(doc, offset)
+	doc.mu.RUnlock()
+
+	protocol, member := protocolTarget(sym)
+	if protocol == nil {
+		return []lsp.Location{}, nil
+	}
+
+	results := []lsp.Location{}
+	seen := make(map[lsp.Location]bool)
+	for _, candidate := range s.implementationCandidates(protocol) {
+		if candidate.cls.Protocol || !a.ImplementsProtocol(candidate.cls, protocol) {
+			continue
+		}
+		target, uri := candidate.cls, candidate.uri
+		if member != "" {
+			target = implementingMember(candidate.cls, member)
+			if target == nil {
+				continue
+			}
+		}
+		if target.URI != "" {
+			uri = target.URI
+		}
+		li := s.lineIndexForURI(uri)
+		if li == nil || target.Span.IsEmpty() || isSyntheticURI(uri) {
+			continue
+		}
+		loc := lsp.Location{URI: uri, Range: ToRange(li, target.Span)}
+		if seen[loc] {
+			continue
+		}
+		seen[loc] = true
+		results = append(results, loc)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].URI != results[j].URI {
+			return results[i].URI < results[j].URI
+		}
+		if results[i].Range.Start.Line != results[j].Range.Start.Line {
+			return results[i].Range.Start.Line < results[j].Range.Start.Line
+		}
+		return results[i].Range.Start.Character < results[j].Range.Start.Character
+	})
+
+	return results, nil
+}
+
+// protocolTarget returns the protocol a symbol denotes or is a member of,
+// along with the member name in the latter case.
+func protocolTarget(sym *a.Symbol) (*a.Symbol, string) {
+	if sym == nil {
+		return nil, ""
+	}
+	if sym.Kind == a.SymClass {
+		if sym.Protocol {
+			return sym, ""
+		}
+		return nil, ""
+	}
+	if owner := a.OwnerClass(sym); owner != nil && owner.Protocol {
+		return owner, sym.Name
+	}
+	return nil, ""
+}
+
+// implementingMember returns the definition of member that instances of
+// cls use, or nil when it is only inherited from a protocol.
+func implementingMember(cls *a.Symbol, member string) *a.Symbol {
+	for _, provider := range a.MRO(cls) {
+		if !a.DefinesMember(provider, member) {
+			continue
+		}
+		if provider.Protocol {
+			return nil
+		}
+		sym, _ := a.LookupMemberOnType(a.InstanceType(provider), member)
+		return sym
+	}
+	return nil
+}
+
+// classSummary is what implementationCandidates needs to know about a
+// top-level class of a workspace module whose snapshot may have been evicted
+// from the cache: the classes of its MRO and the members it has through them.
+type classSummary struct {
+	ancestors map[string]bool
+	members   map[string]bool
+	dynamic   bool
+}
+
+// summarizeClasses summarizes the classes a module exports.
+func summarizeClasses(exports map[string]*a.Symbol) []classSummary {
+	var summaries []classSummary
+	for _, sym := range exports {
+		if sym == nil || sym.Kind != a.SymClass {
+			continue
+		}
+		summary := classSummary{
+			ancestors: make(map[string]bool),
+			members:   make(map[string]bool),
+			dynamic:   sym.DynamicMembers,
+		}
+		for _, cls := range a.MRO(sym) {
+			summary.ancestors[cls.Name] = true
+			for _, scope := range []*a.Scope{cls.Inner, cls.Attrs, cls.Members} {
+				if scope == nil {
+					continue
+				}
+				for name := range scope.Symbols {
+					summary.members[name] = true
+				}
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// mayImplement reports whether the class could satisfy protocol, either
+// nominally or by having every member it lists.
+func (c classSummary) mayImplement(protocol *a.Symbol, members []string) bool {
+	if c.ancestors[protocol.Name] {
+		return true
+	}
+	if c.dynamic || len(members) == 0 {
+		return false
+	}
+	for _, name := range members {
+		if !c.members[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// implementationCandidates returns the top-level classes of the open
+// documents and of the workspace modules that may implement protocol.
+// Cached snapshots are used as they are; an evicted module is only analysed
+// again when the summary of its classes says one of them may implement
+// protocol, and a module without a summary is analysed to build one.
+func (s *Server) implementationCandidates(protocol *a.Symbol) []implementationCandidate {
+	var candidates []implementationCandidate
+
+	s.docsMu.RLock()
+	docs := make([]*Document, 0, len(s.docs))
+	for _, doc := range s.docs {
+		docs = append(docs, doc)
+	}
+	s.docsMu.RUnlock()
+
+	for _, doc := range docs {
+		doc.mu.RLock()
+		if doc.Global != nil {
+			for _, sym := range doc.Global.Symbols {
+				if sym != nil && sym.Kind == a.SymClass {
+					candidates = append(candidates, implementationCandidate{cls: sym, uri: doc.URI})
+				}
+			}
+		}
+		doc.mu.RUnlock()
+	}
+
+	s.indexMu.RLock()
+	mods := make([]ModuleFile, 0, len(s.modulesByName))
+	for _, mod := range s.modulesByName {
+		mods = append(mods, mod)
+	}
+	s.indexMu.RUnlock()
+
+	members := a.ProtocolMembers(protocol)
+	for _, mod := range mods {
+		s.snapshotsMu.RLock()
+		snapshot := s.moduleSnapshotsByURI[mod.URI]
+		summaries, summarized := s.classSummariesByURI[mod.URI]
+		s.snapshotsMu.RUnlock()
+
+		if snapshot == nil {
+			// A module that was never analysed has no summary yet; analysing
+			// it records one.
+			if summarized && !slices.ContainsFunc(summaries, func(c classSummary) bool { return c.mayImplement(protocol, members) }) {
+				continue
+			}
+			var ok bool
+			if snapshot, ok = s.analyzeModuleFile(mod); !ok || snapshot == nil {
+				continue
+			}
+		}
+		for _, sym := range snapshot.Exports {
+			if sym != nil && sym.Kind == a.SymClass {
+				candidates = append(candidates, implementationCandidate{cls: sym, uri: snapshot.URI})
+			}
+		}
+	}
+	return candidates
+}
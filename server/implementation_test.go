@@ -0,0 +1,129 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"rahu/lsp"
+)
+
+func implementationParams(uri lsp.DocumentURI, line, character int) *lsp.ImplementationParams {
+	return &lsp.ImplementationParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+		Position:     lsp.Position{Line: line, Character: character},
+	}
+}
+
+func TestImplementationListsStructuralProtocolClasses(t *testing.T) {
+	code := "from typing import Protocol\n\n" +
+		"class Runner(Protocol):\n    def run(self) -> int: ...\n\n" +
+		"class Job:\n    def run(self) -> int:\n        return 1\n\n" +
+		"class Other:\n    def stop(self) -> None: ...\n\n" +
+		"class Task(Runner):\n    def run(self) -> int:\n        return 2\n\n" +
+		"class Repeat:\n    def run(self, times: int) -> int:\n        return times\n"
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	locs, err := s.Implementation(implementationParams(uri, 2, 7))
+	if err != nil {
+		t.Fatalf("unexpected implementation error: %v", err)
+	}
+	if len(locs) != 2 || locs[0].Range.Start.Line != 5 || locs[1].Range.Start.Line != 12 {
+		t.Fatalf("expected Job and Task, got %+v", locs)
+	}
+
+	locs, err = s.Implementation(implementationParams(uri, 3, 8))
+	if err != nil {
+		t.Fatalf("unexpected implementation error: %v", err)
+	}
+	if len(locs) != 2 || locs[0].Range.Start.Line != 6 || locs[1].Range.Start.Line != 13 {
+		t.Fatalf("expected Job.run and Task.run, got %+v", locs)
+	}
+}
+
+func TestImplementationFindsWorkspaceClasses(t *testing.T) {
+	root := t.TempDir()
+	protoPath := filepath.Join(root, "proto.py")
+	implPath := filepath.Join(root, "impl.py")
+	protoCode := "from typing import Protocol\n\nclass Closer(Protocol):\n    def close(self) -> None: ...\n"
+	implCode := "class File:\n    def close(self) -> None:\n        pass\n"
+	writeWorkspaceFile(t, protoPath, protoCode)
+	writeWorkspaceFile(t, implPath, implCode)
+
+	s := newWorkspaceServer(t, root)
+	protoURI := pathToURI(protoPath)
+	s.Open(lsp.TextDocumentItem{URI: protoURI, Text: protoCode, Version: 1})
+	s.analyze(s.Get(protoURI))
+
+	locs, err := s.Implementation(implementationParams(protoURI, 3, 8))
+	if err != nil {
+		t.Fatalf("unexpected implementation error: %v", err)
+	}
+	if len(locs) != 1 || locs[0].URI != pathToURI(implPath) || locs[0].Range.Start.Line != 1 {
+		t.Fatalf("expected File.close in impl.py, got %+v", locs)
+	}
+}
+
+func TestImplementationOnlyReanalysesPossibleImplementations(t *testing.T) {
+	root := t.TempDir()
+	protoPath := filepath.Join(root, "proto.py")
+	implPath := filepath.Join(root, "impl.py")
+	protoCode := "from typing import Protocol\n\nclass Closer(Protocol):\n    def close(self) -> None: ...\n"
+	writeWorkspaceFile(t, protoPath, protoCode)
+	writeWorkspaceFile(t, implPath, "class File:\n    def close(self) -> None:\n        pass\n")
+	writeWorkspaceFile(t, filepath.Join(root, "other.py"), "class Reader:\n    def read(self) -> str:\n        return ''\n")
+
+	s := newWorkspaceServer(t, root)
+	protoURI := pathToURI(protoPath)
+	s.Open(lsp.TextDocumentItem{URI: protoURI, Text: protoCode, Version: 1})
+	s.analyze(s.Get(protoURI))
+
+	// Evict everything but the open protocol module.
+	s.snapshotsMu.Lock()
+	s.maxCachedModules = 1
+	s.snapshotsMu.Unlock()
+	s.enforceSnapshotLRULimit()
+
+	locs, err := s.Implementation(implementationParams(protoURI, 2, 7))
+	if err != nil {
+		t.Fatalf("unexpected implementation error: %v", err)
+	}
+	if len(locs) != 1 || locs[0].URI != pathToURI(implPath) {
+		t.Fatalf("expected File in impl.py, got %+v", locs)
+	}
+	if _, ok := s.getModuleSnapshotByName("other"); ok {
+		t.Fatal("expected other.py, which cannot implement Closer, not to be analysed again")
+	}
+}
+
+func TestImplementationAnalysesModulesWithoutSummaries(t *testing.T) {
+	root := t.TempDir()
+	protoPath := filepath.Join(root, "proto.py")
+	implPath := filepath.Join(root, "impl.py")
+	protoCode := "from typing import Protocol\n\nclass Closer(Protocol):\n    def close(self) -> None: ...\n"
+	writeWorkspaceFile(t, protoPath, protoCode)
+	writeWorkspaceFile(t, implPath, "class File:\n    def close(self) -> None:\n        pass\n")
+
+	s := newWorkspaceServer(t, root)
+	protoURI := pathToURI(protoPath)
+	s.Open(lsp.TextDocumentItem{URI: protoURI, Text: protoCode, Version: 1})
+	s.analyze(s.Get(protoURI))
+
+	// Forget impl.py as if it had never been analysed.
+	implURI := pathToURI(implPath)
+	s.snapshotsMu.Lock()
+	delete(s.moduleSnapshotsByURI, implURI)
+	delete(s.moduleSnapshotsByName, "impl")
+	delete(s.classSummariesByURI, implURI)
+	s.snapshotsMu.Unlock()
+
+	locs, err := s.Implementation(implementationParams(protoURI, 2, 7))
+	if err != nil {
+		t.Fatalf("unexpected implementation error: %v", err)
+	}
+	if len(locs) != 1 || locs[0].URI != implURI {
+		t.Fatalf("expected File in impl.py, got %+v", locs)
+	}
+}
@@ -0,0 +1,346 @@
+package server
+
+import (
+	"slices"
+	"strings"
+
+	"rahu/analyser"
+	"rahu/jsonrpc"
+	"rahu/lsp"
+	"rahu/parser/ast"
+)
+
+// ImportGraphModule is a workspace module and the workspace modules it
+// imports at runtime.
+type ImportGraphModule struct {
+	Name    string          `json:"name"`
+	URI     lsp.DocumentURI `json:"uri"`
+	Imports []string        `json:"imports"`
+}
+
+// ImportGraph is the result of the rahu/importGraph request: the runtime
+// import graph of the workspace and its cycles, each listing the modules of
+// one strongly connected component.
+type ImportGraph struct {
+	Modules []ImportGraphModule `json:"modules"`
+	Cycles  [][]string          `json:"cycles"`
+}
+
+// importTarget is a module a module-level statement imports at runtime,
+// with the span naming it.
+type importTarget struct {
+	module string
+	span   ast.Range
+}
+
+// importGraph is the runtime import graph between workspace modules.
+// component maps each module on a cycle to the index of its cycle.
+type importGraph struct {
+	uris      map[string]lsp.DocumentURI
+	edges     map[string][]string
+	cycles    [][]string
+	component map[string]int
+}
+
+// ImportGraph returns the runtime import graph of the workspace with its
+// import cycles.
+func (s *Server) ImportGraph(_ *struct{}) (*ImportGraph, *jsonrpc.Error) {
+	if err := s.WaitForIndexing(); err != nil {
+		return &ImportGraph{Modules: []ImportGraphModule{}, Cycles: [][]string{}}, nil
+	}
+
+	graph := s.importGraph()
+	result := &ImportGraph{
+		Modules: make([]ImportGraphModule, 0, len(graph.uris)),
+		Cycles:  graph.cycles,
+	}
+	for name, uri := range graph.uris {
+		result.Modules = append(result.Modules, ImportGraphModule{
+			Name:    name,
+			URI:     uri,
+			Imports: append([]string{}, graph.edges[name]...),
+		})
+	}
+	slices.SortFunc(result.Modules, func(a, b ImportGraphModule) int { return strings.Compare(a.Name, b.Name) })
+	if result.Cycles == nil {
+		result.Cycles = [][]string{}
+	}
+	return result, nil
+}
+
+// runtimeImportTargets lists the modules tree imports when it runs,
+// including imports in try and with blocks: imports under TYPE_CHECKING and
+// inside functions are left out, as they cannot take part in an import
+// cycle. `from package import name` also imports the
+// submodule package.name when the workspace has one.
+func (s *Server) runtimeImportTargets(tree *ast.AST, importerURI lsp.DocumentURI) []importTarget {
+	if tree == nil || tree.Root == ast.NoNode {
+		return nil
+	}
+
+	var targets []importTarget
+	analyser.WalkRuntimeImports(tree, s.analysisTarget(), func(stmt ast.NodeID, typeChecking bool) {
+		if typeChecking {
+			return
+		}
+		switch tree.Node(stmt).Kind {
+		case ast.NodeImport:
+			for alias := tree.Node(stmt).FirstChild; alias != ast.NoNode; alias = tree.Node(alias).NextSibling {
+				target, _ := tree.AliasParts(alias)
+				if name, ok := moduleNameFromExpr(tree, target); ok {
+					targets = append(targets, importTarget{module: name, span: tree.RangeOf(target)})
+				}
+			}
+		case ast.NodeFromImport:
+			module, aliases := tree.FromImportParts(stmt)
+			name, ok := s.resolveImportModuleName(importerURI, tree, module, tree.Node(stmt).Data)
+			if !ok {
+				return
+			}
+			span := tree.RangeOf(stmt)
+			if module != ast.NoNode {
+				span = tree.RangeOf(module)
+			}
+			targets = append(targets, importTarget{module: name, span: span})
+			for _, alias := range aliases {
+				target, _ := tree.AliasParts(alias)
+				attr, ok := tree.NameText(target)
+				if !ok || attr == "*" {
+					continue
+				}
+				if _, ok := s.lookupWorkspaceModule(name + "." + attr); ok {
+					targets = append(targets, importTarget{module: name + "." + attr, span: tree.RangeOf(target)})
+				}
+			}
+		}
+	})
+	return targets
+}
+
+// runtimeImports returns the distinct module names of runtimeImportTargets.
+func (s *Server) runtimeImports(tree *ast.AST, importerURI lsp.DocumentURI) []string {
+	var names []string
+	for _, target := range s.runtimeImportTargets(tree, importerURI) {
+		if !slices.Contains(names, target.module) {
+			names = append(names, target.module)
+		}
+	}
+	return names
+}
+
+func (s *Server) lookupWorkspaceModule(name string) (ModuleFile, bool) {
+	s.indexMu.RLock()
+	defer s.indexMu.RUnlock()
+	mod, ok := s.modulesByName[name]
+	return mod, ok
+}
+
+// importGraph builds the runtime import graph between workspace modules and
+// finds its cycles.
+func (s *Server) importGraph() *importGraph {
+	s.indexMu.RLock()
+	names := make(map[lsp.DocumentURI]string, len(s.modulesByURI))
+	graph := &importGraph{
+		uris:      make(map[string]lsp.DocumentURI, len(s.modulesByName)),
+		edges:     make(map[string][]string),
+		component: make(map[string]int),
+	}
+	for name, mod := range s.modulesByName {
+		graph.uris[name] = mod.URI
+		names[mod.URI] = name
+	}
+	s.indexMu.RUnlock()
+
+	s.depsMu.RLock()
+	for uri, imports := range s.runtimeImportsByURI {
+		from, ok := names[uri]
+		if !ok {
+			continue
+		}
+		for _, to := range imports {
+			if _, ok := graph.uris[to]; ok && !slices.Contains(graph.edges[from], to) {
+				graph.edges[from] = append(graph.edges[from], to)
+			}
+		}
+	}
+	s.depsMu.RUnlock()
+
+	for _, imports := range graph.edges {
+		slices.Sort(imports)
+	}
+	for _, scc := range stronglyConnectedComponents(graph.uris, graph.edges) {
+		if len(scc) == 1 && !slices.Contains(graph.edges[scc[0]], scc[0]) {
+			continue
+		}
+		for _, name := range scc {
+			graph.component[name] = len(graph.cycles)
+		}
+		graph.cycles = append(graph.cycles, scc)
+	}
+	return graph
+}
+
+// stronglyConnectedComponents runs Tarjan's algorithm over the graph, visiting
+// modules in name order so the result is stable. Each component is sorted,
+// and components are ordered by their first module.
+func stronglyConnectedComponents(nodes map[string]lsp.DocumentURI, edges map[string][]string) [][]string {
+	order := make([]string, 0, len(nodes))
+	for name := range nodes {
+		order = append(order, name)
+	}
+	slices.Sort(order)
+
+	index := make(map[string]int, len(order))
+	lowlink := make(map[string]int, len(order))
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var connect func(name string)
+	connect = func(name string) {
+		index[name] = len(index)
+		lowlink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, next := range edges[name] {
+			if _, seen := index[next]; !seen {
+				connect(next)
+				lowlink[name] = min(lowlink[name], lowlink[next])
+			} else if onStack[next] {
+				lowlink[name] = min(lowlink[name], index[next])
+			}
+		}
+
+		if lowlink[name] != index[name] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == name {
+				break
+			}
+		}
+		slices.Sort(component)
+		components = append(components, component)
+	}
+
+	for _, name := range order {
+		if _, seen := index[name]; !seen {
+			connect(name)
+		}
+	}
+	slices.SortFunc(components, func(a, b []string) int { return strings.Compare(a[0], b[0]) })
+	return components
+}
+
+// cyclePath returns the shortest import path from one module back to
+// another within their cycle, both ends included.
+func (g *importGraph) cyclePath(from, to string) []string {
+	cycle := g.component[from]
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if name == to {
+			path := []string{name}
+			for name != from {
+				name = prev[name]
+				path = append(path, name)
+			}
+			slices.Reverse(path)
+			return path
+		}
+		for _, next := range g.edges[name] {
+			if _, seen := prev[next]; seen {
+				continue
+			}
+			if c, ok := g.component[next]; !ok || c != cycle {
+				continue
+			}
+			prev[next] = name
+			queue = append(queue, next)
+		}
+	}
+	return nil
+}
+
+// importCycleErrors warns about each import in tree that closes an import
+// cycle, spelling out the cycle from the importing module back to itself.
+func (s *Server) importCycleErrors(tree *ast.AST, uri lsp.DocumentURI) []analyser.SemanticError {
+	mod, ok := s.LookupModuleByURI(uri)
+	if !ok || !s.currentSettings().Diagnostics.ImportCycles {
+		return nil
+	}
+	graph := s.importGraph()
+	cycle, ok := graph.component[mod.Name]
+	if !ok {
+		return nil
+	}
+
+	var out []analyser.SemanticError
+	for _, target := range s.runtimeImportTargets(tree, uri) {
+		if c, ok := graph.component[target.module]; !ok || c != cycle {
+			continue
+		}
+		var path []string
+		if target.module == mod.Name {
+			path = []string{mod.Name, mod.Name}
+		} else if back := graph.cyclePath(target.module, mod.Name); back != nil {
+			path = append([]string{mod.Name}, back...)
+		} else {
+			continue
+		}
+		out = append(out, analyser.SemanticError{
+			Span:     target.span,
+			Msg:      "import cycle: " + strings.Join(path, " -> "),
+			Severity: analyser.SeverityWarning,
+		})
+	}
+	return out
+}
+
+// documentErrors returns the errors to show for a snapshot in its open
+// document: the snapshot's own plus the import cycles it is part of, which
+// depend on the rest of the workspace.
+func (s *Server) documentErrors(snapshot *ModuleSnapshot) []analyser.SemanticError {
+	cycleErrs := s.importCycleErrors(snapshot.Tree, snapshot.URI)
+	if len(cycleErrs) == 0 {
+		return snapshot.SemErrs
+	}
+	return append(slices.Clip(snapshot.SemErrs), cycleErrs...)
+}
+
+// importCycleMembers returns the modules sharing an import cycle with name.
+func (s *Server) importCycleMembers(name string) []string {
+	graph := s.importGraph()
+	cycle, ok := graph.component[name]
+	if !ok {
+		return nil
+	}
+	return graph.cycles[cycle]
+}
+
+// refreshImportCycles re-applies the snapshots of open modules whose import
+// cycles may have changed with the imports of the module at uri, given the
+// cycle it was on before.
+func (s *Server) refreshImportCycles(uri lsp.DocumentURI, name string, before []string) {
+	members := append(slices.Clone(before), s.importCycleMembers(name)...)
+	slices.Sort(members)
+	for _, member := range slices.Compact(members) {
+		mod, ok := s.lookupWorkspaceModule(member)
+		if !ok || mod.URI == uri || s.Get(mod.URI) == nil {
+			continue
+		}
+		if snapshot, ok := s.getModuleSnapshotByURI(mod.URI); ok {
+			s.applySnapshotToOpenDocument(snapshot)
+		} else if snapshot, ok := s.rebuildModuleSnapshotOnly(mod.URI); ok {
+			s.applySnapshotToOpenDocument(snapshot)
+		}
+	}
+}
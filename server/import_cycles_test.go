@@ -0,0 +1,129 @@
+package server
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"rahu/analyser"
+	"rahu/lsp"
+)
+
+func importCycleErrs(doc *Document) []analyser.SemanticError {
+	var errs []analyser.SemanticError
+	for _, err := range doc.SemErrs {
+		if strings.HasPrefix(err.Msg, "import cycle: ") {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func TestImportCycleDiagnosticsListFullPath(t *testing.T) {
+	root := t.TempDir()
+	aPath := filepath.Join(root, "a.py")
+	cPath := filepath.Join(root, "c.py")
+	aCode := "import b\nimport d\n"
+	cCode := "import a\nx = 1\n"
+	writeWorkspaceFile(t, aPath, aCode)
+	writeWorkspaceFile(t, filepath.Join(root, "b.py"), "from c import x\n")
+	writeWorkspaceFile(t, cPath, cCode)
+	writeWorkspaceFile(t, filepath.Join(root, "d.py"), "from typing import TYPE_CHECKING\nif TYPE_CHECKING:\n    import a\n\ndef load():\n    import a\n")
+
+	s := newWorkspaceServer(t, root)
+	aURI := pathToURI(aPath)
+	cURI := pathToURI(cPath)
+	s.Open(lsp.TextDocumentItem{URI: aURI, Text: aCode, Version: 1})
+	s.Open(lsp.TextDocumentItem{URI: cURI, Text: cCode, Version: 1})
+	s.analyze(s.Get(aURI))
+	s.analyze(s.Get(cURI))
+
+	assertSemanticDiagnostic(t, s.Get(aURI), "import cycle: a -> b -> c -> a", 0, 7)
+	assertSemanticDiagnostic(t, s.Get(cURI), "import cycle: c -> a -> b -> c", 0, 7)
+	if errs := importCycleErrs(s.Get(aURI)); len(errs) != 1 {
+		t.Fatalf("expected only `import b` to report the cycle, got %+v", errs)
+	}
+
+	// Breaking the cycle in c clears the warning in a.
+	s.Update(cURI, "x = 1\n", 2)
+	s.analyze(s.Get(cURI))
+	if errs := importCycleErrs(s.Get(aURI)); len(errs) != 0 {
+		t.Fatalf("expected the cycle to clear in a.py, got %+v", errs)
+	}
+
+	s.Update(cURI, cCode, 3)
+	s.analyze(s.Get(cURI))
+	assertSemanticDiagnostic(t, s.Get(aURI), "import cycle: a -> b -> c -> a", 0, 7)
+}
+
+func TestImportCycleThroughRelativeSubmoduleImport(t *testing.T) {
+	root := t.TempDir()
+	xPath := filepath.Join(root, "pkg", "x.py")
+	xCode := "from . import y\n"
+	writeWorkspaceFile(t, filepath.Join(root, "pkg", "__init__.py"), "")
+	writeWorkspaceFile(t, xPath, xCode)
+	writeWorkspaceFile(t, filepath.Join(root, "pkg", "y.py"), "from pkg import x\n")
+
+	s := newWorkspaceServer(t, root)
+	xURI := pathToURI(xPath)
+	s.Open(lsp.TextDocumentItem{URI: xURI, Text: xCode, Version: 1})
+	s.analyze(s.Get(xURI))
+
+	assertSemanticDiagnostic(t, s.Get(xURI), "import cycle: pkg.x -> pkg.y -> pkg.x", 0, 14)
+}
+
+func TestImportCycleThroughTryAndWithBlocks(t *testing.T) {
+	root := t.TempDir()
+	bPath := filepath.Join(root, "b.py")
+	bCode := "try:\n    import a\nexcept ImportError:\n    a = None\n"
+	writeWorkspaceFile(t, filepath.Join(root, "a.py"), "import b\n")
+	writeWorkspaceFile(t, bPath, bCode)
+	writeWorkspaceFile(t, filepath.Join(root, "c.py"), "import contextlib\n\nwith contextlib.suppress(ImportError):\n    import d\n")
+	writeWorkspaceFile(t, filepath.Join(root, "d.py"), "try:\n    pass\nfinally:\n    import c\n")
+
+	s := newWorkspaceServer(t, root)
+	bURI := pathToURI(bPath)
+	s.Open(lsp.TextDocumentItem{URI: bURI, Text: bCode, Version: 1})
+	s.analyze(s.Get(bURI))
+	assertSemanticDiagnostic(t, s.Get(bURI), "import cycle: b -> a -> b", 1, 11)
+
+	graph, rpcErr := s.ImportGraph(nil)
+	if rpcErr != nil {
+		t.Fatalf("unexpected error: %+v", rpcErr)
+	}
+	wantCycles := [][]string{{"a", "b"}, {"c", "d"}}
+	if !slices.EqualFunc(graph.Cycles, wantCycles, slices.Equal) {
+		t.Fatalf("expected cycles %v, got %v", wantCycles, graph.Cycles)
+	}
+}
+
+func TestImportGraphReportsCycles(t *testing.T) {
+	root := t.TempDir()
+	writeWorkspaceFile(t, filepath.Join(root, "a.py"), "import b\nimport d\n")
+	writeWorkspaceFile(t, filepath.Join(root, "b.py"), "import a\nimport os\n")
+	writeWorkspaceFile(t, filepath.Join(root, "c.py"), "import c\n")
+	writeWorkspaceFile(t, filepath.Join(root, "d.py"), "from typing import TYPE_CHECKING\nif TYPE_CHECKING:\n    import a\n")
+
+	s := newWorkspaceServer(t, root)
+	graph, rpcErr := s.ImportGraph(nil)
+	if rpcErr != nil {
+		t.Fatalf("unexpected error: %+v", rpcErr)
+	}
+
+	imports := make(map[string][]string)
+	for _, mod := range graph.Modules {
+		imports[mod.Name] = mod.Imports
+	}
+	want := map[string][]string{"a": {"b", "d"}, "b": {"a"}, "c": {"c"}, "d": {}}
+	for name, deps := range want {
+		if got, ok := imports[name]; !ok || !slices.Equal(got, deps) {
+			t.Fatalf("expected %s to import %v, got %v", name, deps, graph.Modules)
+		}
+	}
+
+	wantCycles := [][]string{{"a", "b"}, {"c"}}
+	if !slices.EqualFunc(graph.Cycles, wantCycles, slices.Equal) {
+		t.Fatalf("expected cycles %v, got %v", wantCycles, graph.Cycles)
+	}
+}
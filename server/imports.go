@@ -182,6 +182,22 @@ func cloneImportedSymbol(local, target *analyser.Symbol) {
 	local.InstanceOf = target.InstanceOf
 	local.Inferred = target.Inferred
 	local.Returns = target.Returns
+	local.Params = target.Params
+	local.Dataclass = target.Dataclass
+	local.Enum = target.Enum
+	local.DataclassTransform = target.DataclassTransform
+	local.Protocol = target.Protocol
+	local.RuntimeCheckable = target.RuntimeCheckable
+	local.Final = target.Final
+	local.Abstract = target.Abstract
+	local.ABC = target.ABC
+	local.Metaclass = target.Metaclass
+	local.ClassVar = target.ClassVar
+	local.Overloads = target.Overloads
+	local.ReturnsSelf = target.ReturnsSelf
+	local.TypeAlias = target.TypeAlias
+	local.Decorated = target.Decorated
+	local.DynamicMembers = target.DynamicMembers
 	if target.Scope != nil {
 		local.Scope = target.Scope
 	}
@@ -205,21 +221,56 @@ func isStarImportAlias(tree *ast.AST, alias ast.NodeID) bool {
 	return ok && name == "*"
 }
 
-func reResolveSnapshot(snapshot *ModuleSnapshot) {
+// resolveModule resolves a module for the target interpreter and adds the
+// diagnostics checks enables: hints for the names it never reads, and
+// warnings for redefinitions, shadowed builtins and names missing from
+// __all__. Imports in a package's __init__ count as re-exports, and its
+// __all__ may list submodules.
+func resolveModule(tree *ast.AST, global *analyser.Scope, uri lsp.DocumentURI, target analyser.Target, checks DiagnosticSettings) (*analyser.Resolver, []analyser.SemanticError) {
+	resolver, semErrs := analyser.ResolveForTarget(tree, global, target)
+	packageInit := strings.HasSuffix(string(uri), "/__init__.py") || strings.HasSuffix(string(uri), "/__init__.pyi")
+	if checks.UnusedNames {
+		semErrs = append(semErrs, resolver.UnusedDiagnostics(packageInit)...)
+	}
+	if checks.Redefinitions {
+		semErrs = append(semErrs, resolver.RedefinitionDiagnostics()...)
+	}
+	if checks.UndefinedAll {
+		var isSubmodule func(string) bool
+		if path, ok := uriToPath(uri); ok && packageInit {
+			isSubmodule = func(name string) bool { return packageHasSubmodule(filepath.Dir(path), name) }
+		}
+		semErrs = append(semErrs, resolver.UndefinedAllDiagnostics(isSubmodule)...)
+	}
+	return resolver, semErrs
+}
+
+// packageHasSubmodule reports whether the package in dir has a module or
+// subpackage called name.
+func packageHasSubmodule(dir, name string) bool {
+	for _, candidate := range []string{name + ".py", name + ".pyi", name} {
+		if _, err := os.Stat(filepath.Join(dir, candidate)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func reResolveSnapshot(snapshot *ModuleSnapshot, target analyser.Target, checks DiagnosticSettings) {
 	if snapshot == nil || snapshot.Tree == nil || snapshot.Global == nil {
 		return
 	}
-	resolver, semErrs := analyser.Resolve(snapshot.Tree, snapshot.Global)
+	resolver, semErrs := resolveModule(snapshot.Tree, snapshot.Global, snapshot.URI, target, checks)
 	snapshot.Symbols = resolver.Resolved
 	snapshot.AttrSymbols = resolver.ResolvedAttr
 	snapshot.SemErrs = semErrs
 }
 
-func starImportExports(snapshot *ModuleSnapshot) map[string]*analyser.Symbol {
+func (s *Server) starImportExports(snapshot *ModuleSnapshot) map[string]*analyser.Symbol {
 	if snapshot == nil || snapshot.Exports == nil {
 		return nil
 	}
-	if explicit := explicitStarImportExports(snapshot); len(explicit) != 0 {
+	if explicit := s.explicitStarImportExports(snapshot); len(explicit) != 0 {
 		return explicit
 	}
 	exports := make(map[string]*analyser.Symbol)
@@ -232,74 +283,56 @@ func starImportExports(snapshot *ModuleSnapshot) map[string]*analyser.Symbol {
 	return exports
 }
 
-func explicitStarImportExports(snapshot *ModuleSnapshot) map[string]*analyser.Symbol {
+func (s *Server) explicitStarImportExports(snapshot *ModuleSnapshot) map[string]*analyser.Symbol {
 	if snapshot == nil || snapshot.Tree == nil || snapshot.Exports == nil {
 		return nil
 	}
-	names := staticAllNames(snapshot.Tree)
+	return s.listedExports(snapshot.Name, snapshot.Tree, snapshot.Exports)
+}
+
+// listedExports returns the exports of the named module that its __all__
+// lists, or nil when it lists none.
+func (s *Server) listedExports(module string, tree *ast.AST, exports map[string]*analyser.Symbol) map[string]*analyser.Symbol {
+	names := s.moduleAllNames(module, tree, exports, nil)
 	if len(names) == 0 {
 		return nil
 	}
-	exports := make(map[string]*analyser.Symbol, len(names))
+	listed := make(map[string]*analyser.Symbol, len(names))
 	for _, name := range names {
-		sym, ok := snapshot.Exports[name]
+		sym, ok := exports[name]
 		if !ok || sym == nil {
 			continue
 		}
-		exports[name] = sym
+		listed[name] = sym
 	}
-	return exports
+	return listed
 }
 
-func staticAllNames(tree *ast.AST) []string {
-	if tree == nil || tree.Root == ast.NoNode {
+// moduleAllNames evaluates the __all__ of the named module, following
+// `mod.__all__` into the analysed module mod is bound to, or the submodule
+// mod of a package, which `from . import mod` binds. seen holds the modules
+// being evaluated, to stop at import cycles.
+func (s *Server) moduleAllNames(name string, tree *ast.AST, exports map[string]*analyser.Symbol, seen map[string]bool) []string {
+	if seen[name] {
 		return nil
 	}
-	var names []string
-	for stmt := tree.Node(tree.Root).FirstChild; stmt != ast.NoNode; stmt = tree.Node(stmt).NextSibling {
-		switch tree.Node(stmt).Kind {
-		case ast.NodeAssign:
-			value := tree.Node(stmt).FirstChild
-			for target := tree.Node(value).NextSibling; target != ast.NoNode; target = tree.Node(target).NextSibling {
-				name, ok := tree.NameText(target)
-				if !ok || name != "__all__" {
-					continue
-				}
-				if explicit := stringSequenceLiteralValues(tree, value); len(explicit) != 0 {
-					names = explicit
-				}
-			}
-		case ast.NodeAnnAssign:
-			target, _, value := tree.AnnAssignParts(stmt)
-			name, ok := tree.NameText(target)
-			if !ok || name != "__all__" || value == ast.NoNode {
-				continue
-			}
-			if explicit := stringSequenceLiteralValues(tree, value); len(explicit) != 0 {
-				names = explicit
-			}
-		}
+	if seen == nil {
+		seen = make(map[string]bool)
 	}
-	return names
-}
-
-func stringSequenceLiteralValues(tree *ast.AST, id ast.NodeID) []string {
-	if tree == nil || id == ast.NoNode {
-		return nil
-	}
-	kind := tree.Node(id).Kind
-	if kind != ast.NodeList && kind != ast.NodeTuple {
-		return nil
-	}
-	values := make([]string, 0, tree.ChildCount(id))
-	for child := tree.Node(id).FirstChild; child != ast.NoNode; child = tree.Node(child).NextSibling {
-		text, ok := tree.StringText(child)
-		if !ok {
+	seen[name] = true
+	return analyser.AllNames(tree, s.analysisTarget(), func(local string) []string {
+		var snapshot *ModuleSnapshot
+		if sym := exports[local]; sym != nil && sym.Kind == analyser.SymModule && sym.URI != "" {
+			snapshot, _ = s.getModuleSnapshotByURI(sym.URI)
+		}
+		if snapshot == nil {
+			snapshot, _ = s.getModuleSnapshotByName(name + "." + local)
+		}
+		if snapshot == nil || snapshot.Tree == nil {
 			return nil
 		}
-		values = append(values, text)
-	}
-	return values
+		return s.moduleAllNames(snapshot.Name, snapshot.Tree, snapshot.Exports, seen)
+	})
 }
 
 func extractExports(global *analyser.Scope) map[string]*analyser.Symbol {
@@ -348,8 +381,9 @@ func (s *Server) augmentExportsFromInterpreter(snapshot *ModuleSnapshot) map[str
 func (s *Server) buildBaseModuleSnapshot(name string, uri lsp.DocumentURI, path, text string, lineIndex *source.LineIndex) *ModuleSnapshot {
 	p := parser.New(text)
 	tree := p.Parse()
-	global, defs := analyser.BuildScopes(tree, text)
-	resolver, semErrs := analyser.Resolve(tree, global)
+	target := s.analysisTarget()
+	global, defs := analyser.BuildScopesForTarget(tree, text, target)
+	resolver, semErrs := resolveModule(tree, global, uri, target, s.currentSettings().Diagnostics)
 	stampSymbolURIs(uri, defs, resolver.Resolved, resolver.ResolvedAttr)
 
 	snapshot := &ModuleSnapshot{
@@ -366,7 +400,7 @@ func (s *Server) buildBaseModuleSnapshot(name string, uri lsp.DocumentURI, path,
 		SemErrs:     semErrs,
 		Global:      global,
 	}
-	snapshot.Imports = s.extractImportsForModule(tree, uri)
+	snapshot.Imports, snapshot.TypeCheckingImports = s.extractImportsForModule(tree, uri)
 	snapshot.Exports = extractExports(snapshot.Global)
 	snapshot.Exports = s.augmentExportsFromInterpreter(snapshot)
 	snapshot.ExportHash = computeExportHash(snapshot.Exports)
@@ -377,16 +411,18 @@ func (s *Server) buildBaseModuleSnapshot(name string, uri lsp.DocumentURI, path,
 func (s *Server) buildStartupModuleBase(name string, uri lsp.DocumentURI, path, text string, lineIndex *source.LineIndex) *StartupModuleBase {
 	p := parser.New(text)
 	tree := p.Parse()
+	imports, typeCheckingImports := s.extractImportsForModule(tree, uri)
 	return &StartupModuleBase{
-		Name:      name,
-		URI:       uri,
-		Path:      path,
-		Text:      text,
-		TextHash:  computeTextHash(text),
-		LineIndex: lineIndex,
-		Tree:      tree,
-		ParseErrs: p.Errors(),
-		Imports:   s.extractImportsForModule(tree, uri),
+		Name:                name,
+		URI:                 uri,
+		Path:                path,
+		Text:                text,
+		TextHash:            computeTextHash(text),
+		LineIndex:           lineIndex,
+		Tree:                tree,
+		ParseErrs:           p.Errors(),
+		Imports:             imports,
+		TypeCheckingImports: typeCheckingImports,
 	}
 }
 
@@ -466,14 +502,15 @@ func (s *Server) buildImportSurfaceFromBaseWithLookup(base *StartupModuleBase, l
 	if base == nil {
 		return nil
 	}
-	global, defs := analyser.BuildScopes(base.Tree, base.Text)
-	resolver, _ := analyser.Resolve(base.Tree, global)
+	target := s.analysisTarget()
+	global, defs := analyser.BuildScopesForTarget(base.Tree, base.Text, target)
+	resolver, _ := analyser.ResolveForTarget(base.Tree, global, target)
 	stampSymbolURIs(base.URI, defs, resolver.Resolved, resolver.ResolvedAttr)
 
 	if lookup != nil {
 		_ = s.bindWorkspaceImportsWithSurfaceLookup(base.Tree, global, defs, base.URI, lookup)
 		tmp := &ModuleSnapshot{Tree: base.Tree, Global: global}
-		reResolveSnapshot(tmp)
+		reResolveSnapshot(tmp, target, DiagnosticSettings{})
 	}
 
 	tmp := &ModuleSnapshot{
@@ -501,8 +538,9 @@ func (s *Server) buildFinalSnapshotFromBase(base *StartupModuleBase, lookup modu
 	if base == nil {
 		return nil
 	}
-	global, defs := analyser.BuildScopes(base.Tree, base.Text)
-	resolver, semErrs := analyser.Resolve(base.Tree, global)
+	target := s.analysisTarget()
+	global, defs := analyser.BuildScopesForTarget(base.Tree, base.Text, target)
+	resolver, semErrs := resolveModule(base.Tree, global, base.URI, target, s.currentSettings().Diagnostics)
 	stampSymbolURIs(base.URI, defs, resolver.Resolved, resolver.ResolvedAttr)
 
 	snapshot := &ModuleSnapshot{
@@ -519,10 +557,12 @@ func (s *Server) buildFinalSnapshotFromBase(base *StartupModuleBase, lookup modu
 		SemErrs:     semErrs,
 		Global:      global,
 		Imports:     append([]string(nil), base.Imports...),
+
+		TypeCheckingImports: append([]string(nil), base.TypeCheckingImports...),
 	}
 
 	importErrs := s.bindWorkspaceImportsWithSurfaceLookup(snapshot.Tree, snapshot.Global, snapshot.Defs, snapshot.URI, lookup)
-	reResolveSnapshot(snapshot)
+	reResolveSnapshot(snapshot, s.analysisTarget(), s.currentSettings().Diagnostics)
 	snapshot.SemErrs = append(snapshot.SemErrs, importErrs...)
 	snapshot.Exports = extractExports(snapshot.Global)
 	snapshot.Exports = s.augmentExportsFromInterpreter(snapshot)
@@ -573,14 +613,33 @@ func writeSymbolSignature(h hash.Hash64, sym *analyser.Symbol, visitedSymbols ma
 	writeHashByte(h, 0)
 	writeHashInt(h, int(sym.Kind))
 	writeHashByte(h, 0)
+	if sym.Final {
+		writeHashString(h, "final")
+	}
+	if sym.ClassVar {
+		writeHashString(h, "classvar")
+	}
+	if sym.Abstract || sym.ABC {
+		writeHashString(h, "abstract")
+	}
+	writeHashByte(h, 0)
 
 	switch sym.Kind {
 	case analyser.SymFunction:
 		writeFunctionSignature(h, sym, visitedSymbols, visitedTypes)
+		for _, overload := range sym.Overloads {
+			if overload != sym {
+				writeFunctionSignature(h, overload, visitedSymbols, visitedTypes)
+			}
+		}
 	case analyser.SymClass:
 		writeClassSignature(h, sym, visitedSymbols, visitedTypes)
 	default:
 		writeTypeSignature(h, sym.Inferred, visitedSymbols, visitedTypes)
+		if sym.TypeAlias != nil {
+			writeHashString(h, "alias")
+			writeTypeSignature(h, sym.TypeAlias, visitedSymbols, visitedTypes)
+		}
 	}
 }
 
@@ -598,33 +657,54 @@ func writeFunctionSignature(h hash.Hash64, sym *analyser.Symbol, visitedSymbols
 		name  string
 		start uint32
 		kind  analyser.SymbolKind
+		flags int
 		def   string
 		typ   *analyser.Type
 	}
 
 	params := make([]paramSig, 0, len(sym.Inner.Symbols))
-	for _, inner := range sym.Inner.Symbols {
-		if inner == nil || inner.Kind != analyser.SymParameter {
-			continue
+	if sym.Params != nil {
+		// Synthesized signatures carry their own parameter order.
+		for _, param := range sym.Params {
+			params = append(params, paramSig{
+				name:  param.Name,
+				kind:  param.Kind,
+				flags: paramKindFlags(param),
+				def:   param.DefaultValue,
+				typ:   param.Inferred,
+			})
 		}
-		params = append(params, paramSig{
-			name:  inner.Name,
-			start: inner.Span.Start,
-			kind:  inner.Kind,
-			def:   inner.DefaultValue,
-			typ:   inner.Inferred,
+	} else {
+		for _, inner := range sym.Inner.Symbols {
+			if inner == nil || inner.Kind != analyser.SymParameter {
+				continue
+			}
+			params = append(params, paramSig{
+				name:  inner.Name,
+				start: inner.Span.Start,
+				kind:  inner.Kind,
+				flags: paramKindFlags(inner),
+				def:   inner.DefaultValue,
+				typ:   inner.Inferred,
+			})
+		}
+
+		sort.Slice(params, func(i, j int) bool {
+			if params[i].start != params[j].start {
+				return params[i].start < params[j].start
+			}
+			return params[i].name < params[j].name
 		})
 	}
 
-	sort.Slice(params, func(i, j int) bool {
-		if params[i].start != params[j].start {
-			return params[i].start < params[j].start
-		}
-		return params[i].name < params[j].name
-	})
-
 	writeHashString(h, "fn")
 	writeHashByte(h, 0)
+	writeHashInt(h, int(sym.Method))
+	writeHashByte(h, 0)
+	if sym.Decorated {
+		writeHashString(h, "decorated")
+	}
+	writeHashByte(h, 0)
 	writeHashInt(h, len(params))
 	writeHashByte(h, 0)
 	for _, param := range params {
@@ -632,6 +712,8 @@ func writeFunctionSignature(h hash.Hash64, sym *analyser.Symbol, visitedSymbols
 		writeHashByte(h, 0)
 		writeHashInt(h, int(param.kind))
 		writeHashByte(h, 0)
+		writeHashInt(h, param.flags)
+		writeHashByte(h, 0)
 		writeHashString(h, param.def)
 		writeHashByte(h, 0)
 		writeTypeSignature(h, param.typ, visitedSymbols, visitedTypes)
@@ -640,9 +722,37 @@ func writeFunctionSignature(h hash.Hash64, sym *analyser.Symbol, visitedSymbols
 	writeTypeSignature(h, sym.Returns, visitedSymbols, visitedTypes)
 }
 
+// paramKindFlags packs how a parameter is passed, so moving it behind * or /
+// changes the signature hash.
+func paramKindFlags(param *analyser.Symbol) int {
+	flags := 0
+	for i, set := range []bool{param.IsVarArg, param.IsKwArg, param.IsKwOnly, param.IsPosOnly} {
+		if set {
+			flags |= 1 << i
+		}
+	}
+	return flags
+}
+
 func writeClassSignature(h hash.Hash64, sym *analyser.Symbol, visitedSymbols map[analyser.SymbolID]struct{}, visitedTypes map[*analyser.Type]struct{}) {
 	writeHashString(h, "class")
 	writeHashByte(h, 0)
+	if sym.Protocol {
+		writeHashString(h, "protocol")
+		if sym.RuntimeCheckable {
+			writeHashString(h, "runtime")
+		}
+	}
+	writeHashByte(h, 0)
+	if sym.Enum != nil {
+		writeHashString(h, "enum")
+		for i, member := range sym.Enum.Members {
+			writeHashByte(h, 0)
+			writeHashString(h, member.Name)
+			writeTypeSignature(h, sym.Enum.Values[i], visitedSymbols, visitedTypes)
+		}
+	}
+	writeHashByte(h, 0)
 	writeHashInt(h, len(sym.Bases))
 	for _, base := range sym.Bases {
 		writeHashByte(h, 0)
@@ -652,6 +762,10 @@ func writeClassSignature(h hash.Hash64, sym *analyser.Symbol, visitedSymbols map
 		}
 		writeHashString(h, base.Name)
 	}
+	writeHashByte(h, 0)
+	if sym.Metaclass != nil {
+		writeHashString(h, sym.Metaclass.Name)
+	}
 	writeScopeSignature(h, sym.Attrs, visitedSymbols, visitedTypes)
 	writeScopeSignature(h, sym.Members, visitedSymbols, visitedTypes)
 }
@@ -696,6 +810,12 @@ func writeTypeSignature(h hash.Hash64, typ *analyser.Type, visitedSymbols map[an
 	if typ.Symbol != nil {
 		writeHashString(h, typ.Symbol.Name)
 	}
+	if typ.Literal != nil {
+		writeHashString(h, "."+typ.Literal.Name)
+	}
+	if typ.AliasRef != nil {
+		writeHashString(h, "="+typ.AliasRef.Name)
+	}
 	writeHashByte(h, 0)
 	for _, union := range typ.Union {
 		writeTypeSignature(h, union, visitedSymbols, visitedTypes)
@@ -708,6 +828,20 @@ func writeTypeSignature(h hash.Hash64, typ *analyser.Type, visitedSymbols map[an
 		writeHashByte(h, 3)
 	}
 	writeTypeSignature(h, typ.Key, visitedSymbols, visitedTypes)
+	for _, arg := range typ.Args {
+		writeHashByte(h, 4)
+		writeTypeSignature(h, arg, visitedSymbols, visitedTypes)
+	}
+	if typ.Kind == analyser.TypeCallable {
+		writeHashInt(h, len(typ.Params))
+		for _, param := range typ.Params {
+			writeHashByte(h, 5)
+			writeHashString(h, param.Name)
+			writeTypeSignature(h, analyser.SymbolType(param), visitedSymbols, visitedTypes)
+		}
+		writeTypeSignature(h, typ.Spec, visitedSymbols, visitedTypes)
+		writeTypeSignature(h, typ.Returns, visitedSymbols, visitedTypes)
+	}
 }
 
 func writeHashString(h hash.Hash64, s string) {
@@ -722,37 +856,52 @@ func writeHashInt(h hash.Hash64, n int) {
 	writeHashString(h, strconv.Itoa(n))
 }
 
-func (s *Server) extractImportsForModule(tree *ast.AST, importerURI lsp.DocumentURI) []string {
+// extractImportsForModule lists the modules tree imports. typeCheckingOnly
+// holds the subset that is only imported under TYPE_CHECKING: those count as
+// dependencies for analysis but are never imported at runtime.
+func (s *Server) extractImportsForModule(tree *ast.AST, importerURI lsp.DocumentURI) (imports, typeCheckingOnly []string) {
 	if tree == nil || tree.Root == ast.NoNode {
-		return nil
+		return nil, nil
 	}
 
 	deps := make([]string, 0, 4)
-	add := func(name string) {
-		if name == "" || slices.Contains(deps, name) {
+	runtime := make(map[string]bool, 4)
+	add := func(name string, typeChecking bool) {
+		if name == "" {
+			return
+		}
+		if !typeChecking {
+			runtime[name] = true
+		}
+		if slices.Contains(deps, name) {
 			return
 		}
 		deps = append(deps, name)
 	}
 
-	for stmt := tree.Node(tree.Root).FirstChild; stmt != ast.NoNode; stmt = tree.Node(stmt).NextSibling {
+	analyser.WalkActiveImports(tree, s.analysisTarget(), func(stmt ast.NodeID, typeChecking bool) {
 		switch tree.Node(stmt).Kind {
 		case ast.NodeImport:
 			for alias := tree.Node(stmt).FirstChild; alias != ast.NoNode; alias = tree.Node(alias).NextSibling {
 				target, _ := tree.AliasParts(alias)
 				if name, ok := moduleNameFromExpr(tree, target); ok {
-					add(name)
+					add(name, typeChecking)
 				}
 			}
 		case ast.NodeFromImport:
 			module, _ := tree.FromImportParts(stmt)
 			if name, ok := s.resolveImportModuleName(importerURI, tree, module, tree.Node(stmt).Data); ok {
-				add(name)
+				add(name, typeChecking)
 			}
 		}
-	}
+	})
 
-	return deps
+	for _, name := range deps {
+		if !runtime[name] {
+			typeCheckingOnly = append(typeCheckingOnly, name)
+		}
+	}
+	return deps, typeCheckingOnly
 }
 
 func (s *Server) buildModuleSnapshot(name string, uri lsp.DocumentURI, path, text string, lineIndex *source.LineIndex) *ModuleSnapshot {
@@ -767,13 +916,15 @@ func (s *Server) buildModuleSnapshot(name string, uri lsp.DocumentURI, path, tex
 		s.moduleSnapshotsByURI[uri] = &partial
 		s.snapshotsMu.Unlock()
 
+		runtimeImports := s.runtimeImports(partial.Tree, uri)
 		s.depsMu.Lock()
 		s.moduleImportsByURI[uri] = append([]string(nil), partial.Imports...)
+		s.runtimeImportsByURI[uri] = runtimeImports
 		s.depsMu.Unlock()
 	}
 
 	importErrs := s.bindWorkspaceImports(snapshot.Tree, snapshot.Global, snapshot.Defs, uri)
-	reResolveSnapshot(snapshot)
+	reResolveSnapshot(snapshot, s.analysisTarget(), s.currentSettings().Diagnostics)
 	snapshot.SemErrs = append(snapshot.SemErrs, importErrs...)
 	snapshot.Exports = extractExports(snapshot.Global)
 	snapshot.Exports = s.augmentExportsFromInterpreter(snapshot)
@@ -901,14 +1052,18 @@ func (s *Server) publishModuleSnapshot(mod ModuleFile, snapshot *ModuleSnapshot)
 		return
 	}
 
+	summaries := summarizeClasses(snapshot.Exports)
 	s.snapshotsMu.Lock()
 	s.moduleSnapshotsByName[mod.Name] = snapshot
 	s.moduleSnapshotsByURI[mod.URI] = snapshot
+	s.classSummariesByURI[mod.URI] = summaries
 	s.snapshotLRU.touch(mod.URI, mod.Name)
 	s.snapshotsMu.Unlock()
 
+	runtimeImports := s.runtimeImports(snapshot.Tree, mod.URI)
 	s.depsMu.Lock()
 	s.moduleImportsByURI[mod.URI] = append([]string(nil), snapshot.Imports...)
+	s.runtimeImportsByURI[mod.URI] = runtimeImports
 	s.depsMu.Unlock()
 }
 
@@ -1004,14 +1159,13 @@ func (s *Server) getModuleSnapshotByName(name string) (*ModuleSnapshot, bool) {
 }
 
 func (s *Server) enforceSnapshotLRULimit() {
-	if s.maxCachedModules <= 0 {
-		return
-	}
-
+	// Open and in-flight modules stay resident; once every remaining
+	// snapshot is one of them, the cache stays over its limit.
+	pinned := 0
 	for {
 		s.snapshotsMu.Lock()
 		resident := len(s.moduleSnapshotsByURI)
-		if resident <= s.maxCachedModules {
+		if s.maxCachedModules <= 0 || resident <= s.maxCachedModules || pinned >= resident {
 			s.snapshotsMu.Unlock()
 			return
 		}
@@ -1024,6 +1178,7 @@ func (s *Server) enforceSnapshotLRULimit() {
 		if s.openModuleCounts[candidate.uri] > 0 || s.buildingModules[candidate.name] != nil {
 			s.snapshotLRU.touch(candidate.uri, candidate.name)
 			s.snapshotsMu.Unlock()
+			pinned++
 			continue
 		}
 
@@ -1069,18 +1224,44 @@ func lookupSnapshotByNameFromMap(snapshots map[string]*ModuleSnapshot, name stri
 	return snapshot, snapshot != nil
 }
 
-func unresolvedModuleError(span ast.Range, name string) analyser.SemanticError {
-	return analyser.SemanticError{
+func unresolvedModuleError(span ast.Range, name string, candidates []string) analyser.SemanticError {
+	return analyser.SuggestName(analyser.SemanticError{
 		Span: span,
 		Msg:  "unresolved module: " + name,
+	}, name, candidates)
+}
+
+// unresolvedImportError reports the missing module of an import statement,
+// whose span covers the dotted target even when only its first segment,
+// moduleName, is looked up.
+func unresolvedImportError(tree *ast.AST, target ast.NodeID, fullName, moduleName string, candidates []string) analyser.SemanticError {
+	err := unresolvedModuleError(tree.RangeOf(target), moduleName, candidates)
+	if err.Suggestion != "" {
+		err.Suggestion += strings.TrimPrefix(fullName, moduleName)
+	}
+	return err
+}
+
+// unresolvedFromModuleError reports the missing module of a from-import.
+// Relative module names get no suggestion, as the source spells them
+// differently from the resolved name.
+func (s *Server) unresolvedFromModuleError(tree *ast.AST, stmt, module ast.NodeID, moduleName string) analyser.SemanticError {
+	var candidates []string
+	if tree.Node(stmt).Data == 0 {
+		candidates = s.indexedModuleNames()
 	}
+	return unresolvedModuleError(fromImportModuleSpan(tree, stmt, module), moduleName, candidates)
 }
 
-func missingImportNameError(span ast.Range, moduleName, name string) analyser.SemanticError {
-	return analyser.SemanticError{
+func missingImportNameError(span ast.Range, moduleName, name string, exports map[string]*analyser.Symbol) analyser.SemanticError {
+	candidates := make([]string, 0, len(exports))
+	for export := range exports {
+		candidates = append(candidates, export)
+	}
+	return analyser.SuggestName(analyser.SemanticError{
 		Span: span,
 		Msg:  "cannot import name '" + name + "' from '" + moduleName + "'",
-	}
+	}, name, candidates)
 }
 
 func (s *Server) bindWorkspaceImports(tree *ast.AST, global *analyser.Scope, defs map[ast.NodeID]*analyser.Symbol, importerURI lsp.DocumentURI) []analyser.SemanticError {
@@ -1094,14 +1275,14 @@ func (s *Server) bindWorkspaceImportsWithLookup(tree *ast.AST, global *analyser.
 
 	var errs []analyser.SemanticError
 
-	for stmt := tree.Node(tree.Root).FirstChild; stmt != ast.NoNode; stmt = tree.Node(stmt).NextSibling {
+	analyser.WalkActiveImports(tree, s.analysisTarget(), func(stmt ast.NodeID, _ bool) {
 		switch tree.Node(stmt).Kind {
 		case ast.NodeImport:
 			errs = append(errs, s.bindImportStmtWithLookup(tree, stmt, defs, lookup)...)
 		case ast.NodeFromImport:
 			errs = append(errs, s.bindFromImportStmtWithLookup(tree, stmt, global, defs, importerURI, lookup)...)
 		}
-	}
+	})
 
 	return errs
 }
@@ -1113,7 +1294,7 @@ func (s *Server) bindWorkspaceImportsWithSurfaceLookup(tree *ast.AST, global *an
 
 	var errs []analyser.SemanticError
 
-	for stmt := tree.Node(tree.Root).FirstChild; stmt != ast.NoNode; stmt = tree.Node(stmt).NextSibling {
+	analyser.WalkActiveImports(tree, s.analysisTarget(), func(stmt ast.NodeID, _ bool) {
 		switch tree.Node(stmt).Kind {
 		case ast.NodeImport:
 			err := s.bindImportStmtWithSurfaceLookup(tree, stmt, defs, lookup)
@@ -1122,7 +1303,7 @@ func (s *Server) bindWorkspaceImportsWithSurfaceLookup(tree *ast.AST, global *an
 			err := s.bindFromImportStmtWithSurfaceLookup(tree, stmt, global, defs, importerURI, lookup)
 			errs = append(errs, err...)
 		}
-	}
+	})
 
 	return errs
 }
@@ -1156,7 +1337,7 @@ func (s *Server) bindImportStmtWithLookup(tree *ast.AST, stmt ast.NodeID, defs m
 
 		snapshot, ok := lookup(moduleToBind)
 		if !ok {
-			errs = append(errs, unresolvedModuleError(tree.RangeOf(target), moduleToBind))
+			errs = append(errs, unresolvedImportError(tree, target, fullName, moduleToBind, s.indexedModuleNames()))
 			continue
 		}
 
@@ -1205,7 +1386,7 @@ func (s *Server) bindImportStmtWithSurfaceLookup(tree *ast.AST, stmt ast.NodeID,
 
 		surface, ok := s.lookupImportSurface(moduleToBind, lookup)
 		if !ok {
-			errs = append(errs, unresolvedModuleError(tree.RangeOf(target), moduleToBind))
+			errs = append(errs, unresolvedImportError(tree, target, fullName, moduleToBind, s.indexedModuleNames()))
 			continue
 		}
 
@@ -1326,14 +1507,14 @@ func (s *Server) bindFromImportStmtWithLookup(tree *ast.AST, stmt ast.NodeID, gl
 
 	snapshot, ok := lookup(moduleName)
 	if !ok {
-		return []analyser.SemanticError{unresolvedModuleError(fromImportModuleSpan(tree, stmt, module), moduleName)}
+		return []analyser.SemanticError{s.unresolvedFromModuleError(tree, stmt, module, moduleName)}
 	}
 
 	var errs []analyser.SemanticError
 
 	for _, alias := range aliases {
 		if isStarImportAlias(tree, alias) {
-			for name, remote := range starImportExports(snapshot) {
+			for name, remote := range s.starImportExports(snapshot) {
 				if global == nil || remote == nil {
 					continue
 				}
@@ -1373,7 +1554,7 @@ func (s *Server) bindFromImportStmtWithLookup(tree *ast.AST, stmt ast.NodeID, gl
 		submoduleName := moduleName + "." + name
 		submodule, ok := lookup(submoduleName)
 		if !ok || submodule == nil {
-			errs = append(errs, missingImportNameError(tree.RangeOf(target), moduleName, name))
+			errs = append(errs, missingImportNameError(tree.RangeOf(target), moduleName, name, snapshot.Exports))
 			continue
 		}
 
@@ -1400,13 +1581,14 @@ func (s *Server) bindFromImportStmtWithSurfaceLookup(tree *ast.AST, stmt ast.Nod
 
 	surface, ok := s.lookupImportSurface(moduleName, lookup)
 	if !ok {
-		return []analyser.SemanticError{unresolvedModuleError(fromImportModuleSpan(tree, stmt, module), moduleName)}
+		return []analyser.SemanticError{s.unresolvedFromModuleError(tree, stmt, module, moduleName)}
 	}
 
 	var errs []analyser.SemanticError
 
 	for _, alias := range aliases {
 		if isStarImportAlias(tree, alias) {
+			listed := s.listedExports(surface.Name, surface.Tree, surface.Exports)
 			for name, remote := range surface.Exports {
 				if global == nil || remote == nil {
 					continue
@@ -1414,7 +1596,10 @@ func (s *Server) bindFromImportStmtWithSurfaceLookup(tree *ast.AST, stmt ast.Nod
 				if _, exists := global.LookupLocal(name); exists {
 					continue
 				}
-				if strings.HasPrefix(name, "_") {
+				if listed != nil && listed[name] == nil {
+					continue
+				}
+				if listed == nil && strings.HasPrefix(name, "_") {
 					continue
 				}
 				local := cloneSymbolForImport(remote)
@@ -1450,7 +1635,7 @@ func (s *Server) bindFromImportStmtWithSurfaceLookup(tree *ast.AST, stmt ast.Nod
 		submoduleName := moduleName + "." + name
 		submodule, ok := s.lookupImportSurface(submoduleName, lookup)
 		if !ok || submodule == nil {
-			errs = append(errs, missingImportNameError(tree.RangeOf(target), moduleName, name))
+			errs = append(errs, missingImportNameError(tree.RangeOf(target), moduleName, name, surface.Exports))
 			continue
 		}
 
@@ -3,6 +3,7 @@ package server
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -296,13 +297,14 @@ func TestDefinitionImportPackageWithoutInitReturnsNil(t *testing.T) {
 	s.Open(lsp.TextDocumentItem{URI: mainURI, Text: mainCode, Version: 1})
 	s.analyze(s.Get(mainURI))
 
-	loc, err := s.Definition(&lsp.DefinitionParams{
+	locs, err := s.Definition(&lsp.DefinitionParams{
 		TextDocument: lsp.TextDocumentIdentifier{URI: mainURI},
 		Position: lsp.Position{
 			Line:      1,
 			Character: 0,
 		},
 	})
+	loc := firstLocation(locs)
 	if err == nil {
 		t.Fatal("expected no definition result")
 	}
@@ -491,6 +493,57 @@ func TestHoverShowsInferredInstanceType(t *testing.T) {
 	}
 }
 
+func TestHoverShowsPropertyType(t *testing.T) {
+	code := "class Foo:\n    @property\n    def size(self) -> int:\n        return 1\n\nFoo().size\n"
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	hov := mustHoverAt(t, s, uri, 5, 7)
+	content, ok := hov.Contents.(lsp.MarkupContent)
+	if !ok {
+		t.Fatalf("expected markup content, got %T", hov.Contents)
+	}
+	if !strings.Contains(content.Value, "property(size: int") {
+		t.Fatalf("expected property type in hover, got %q", content.Value)
+	}
+}
+
+func TestHoverUsesC3MethodResolutionOrder(t *testing.T) {
+	code := "class A:\n    def greet(self) -> int:\n        return 1\n\nclass B(A):\n    pass\n\nclass C(A):\n    def greet(self) -> str:\n        return \"c\"\n\nclass D(B, C):\n    pass\n\nD().greet()\n"
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	hov := mustHoverAt(t, s, uri, 14, 6)
+	content, ok := hov.Contents.(lsp.MarkupContent)
+	if !ok {
+		t.Fatalf("expected markup content, got %T", hov.Contents)
+	}
+	if !strings.Contains(content.Value, "test.py:9") {
+		t.Fatalf("expected C.greet in hover, got %q", content.Value)
+	}
+}
+
+func TestHoverShowsLoopTargetFromIteratorProtocol(t *testing.T) {
+	code := "from typing import Iterator\n\nclass Row:\n    pass\n\ndef rows() -> Iterator[Row]: ...\n\nfor row in rows():\n    row\n"
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	hov := mustHoverAt(t, s, uri, 8, 5)
+	content, ok := hov.Contents.(lsp.MarkupContent)
+	if !ok {
+		t.Fatalf("expected markup content, got %T", hov.Contents)
+	}
+	if !strings.Contains(content.Value, "variable(row: Row") {
+		t.Fatalf("expected loop target type in hover, got %q", content.Value)
+	}
+}
+
 func TestHoverShowsInferredBuiltinType(t *testing.T) {
 	code := "n = 1\nn\n"
 	s := New(nil)
@@ -795,10 +848,11 @@ func TestImportBuiltinModuleSysResolves(t *testing.T) {
 	}
 
 	// Builtin modules have no source file, so goto-def should return nil
-	loc, err := s.Definition(&lsp.DefinitionParams{
+	locs, err := s.Definition(&lsp.DefinitionParams{
 		TextDocument: lsp.TextDocumentIdentifier{URI: mainURI},
 		Position:     lsp.Position{Line: 1, Character: 0},
 	})
+	loc := firstLocation(locs)
 	if err != nil {
 		t.Fatalf("unexpected definition error: %v", err)
 	}
@@ -825,10 +879,11 @@ func TestFromImportBuiltinModuleMemberSysPathResolves(t *testing.T) {
 	}
 
 	// Builtin module members have no source file, so goto-def should return nil
-	loc, err := s.Definition(&lsp.DefinitionParams{
+	locs, err := s.Definition(&lsp.DefinitionParams{
 		TextDocument: lsp.TextDocumentIdentifier{URI: mainURI},
 		Position:     lsp.Position{Line: 1, Character: 0},
 	})
+	loc := firstLocation(locs)
 	if err != nil {
 		t.Fatalf("unexpected definition error: %v", err)
 	}
@@ -1197,6 +1252,52 @@ func TestStarImportFallsBackWhenAllIsNotStatic(t *testing.T) {
 	}
 }
 
+func TestStarImportFollowsDynamicAll(t *testing.T) {
+	root := t.TempDir()
+	writeWorkspaceFile(t, filepath.Join(root, "pkg", "core.py"), "array = 1\nhidden = 2\n__all__ = ['array']\n")
+	writeWorkspaceFile(t, filepath.Join(root, "pkg", "__init__.py"),
+		"from . import core\nfrom .core import array\n__all__ = ['version']\n__all__ += core.__all__\nversion = 1\nextra = 2\n")
+	mainPath := filepath.Join(root, "main.py")
+	mainCode := "from pkg import *\narray\nversion\nextra\n"
+	writeWorkspaceFile(t, mainPath, mainCode)
+
+	s := newWorkspaceServer(t, root)
+	mainURI := pathToURI(mainPath)
+	s.Open(lsp.TextDocumentItem{URI: mainURI, Text: mainCode, Version: 1})
+	s.analyze(s.Get(mainURI))
+
+	assertSemanticDiagnostic(t, s.Get(mainURI), "undefined name: extra", 3, 0)
+	for _, err := range s.Get(mainURI).SemErrs {
+		if strings.HasPrefix(err.Msg, "undefined name: array") || strings.HasPrefix(err.Msg, "undefined name: version") {
+			t.Fatalf("expected names listed by the computed __all__ to be imported, got %+v", err)
+		}
+	}
+}
+
+func TestUndefinedAllEntriesSkipSubmodules(t *testing.T) {
+	root := t.TempDir()
+	writeWorkspaceFile(t, filepath.Join(root, "pkg", "sub.py"), "x = 1\n")
+	initPath := filepath.Join(root, "pkg", "__init__.py")
+	initCode := "__all__ = ['helper', 'sub', 'missing']\n\ndef helper():\n    pass\n"
+	writeWorkspaceFile(t, initPath, initCode)
+
+	s := newWorkspaceServer(t, root)
+	initURI := pathToURI(initPath)
+	s.Open(lsp.TextDocumentItem{URI: initURI, Text: initCode, Version: 1})
+	s.analyze(s.Get(initURI))
+
+	var undefined []string
+	for _, err := range s.Get(initURI).SemErrs {
+		if strings.HasPrefix(err.Msg, "undefined name in __all__") {
+			undefined = append(undefined, err.Msg)
+		}
+	}
+	if !slices.Equal(undefined, []string{"undefined name in __all__: missing"}) {
+		t.Fatalf("expected only missing to be reported, got %v", undefined)
+	}
+	assertSemanticDiagnostic(t, s.Get(initURI), "undefined name in __all__: missing", 0, 29)
+}
+
 func TestStarImportFromExternalModuleBindsExportedNames(t *testing.T) {
 	root := t.TempDir()
 	extRoot := filepath.Join(t.TempDir(), "site-packages")
@@ -1448,10 +1549,11 @@ func TestDidOpenFastAnalysisLeavesWorkspaceImportUnrefinedUntilAsyncPass(t *test
 		TextDocument: lsp.TextDocumentItem{URI: mainURI, Text: mainCode, Version: 1},
 	})
 
-	loc, err := s.Definition(&lsp.DefinitionParams{
+	locs, err := s.Definition(&lsp.DefinitionParams{
 		TextDocument: lsp.TextDocumentIdentifier{URI: mainURI},
 		Position:     lsp.Position{Line: 1, Character: 0},
 	})
+	loc := firstLocation(locs)
 	if err != nil {
 		t.Fatalf("unexpected definition error before async refinement: %v", err)
 	}
@@ -1480,10 +1582,11 @@ func TestDidOpenEventuallyRefinesWorkspaceImports(t *testing.T) {
 		TextDocument: lsp.TextDocumentItem{URI: mainURI, Text: mainCode, Version: 1},
 	})
 
-	loc, err := s.Definition(&lsp.DefinitionParams{
+	locs, err := s.Definition(&lsp.DefinitionParams{
 		TextDocument: lsp.TextDocumentIdentifier{URI: mainURI},
 		Position:     lsp.Position{Line: 1, Character: 0},
 	})
+	loc := firstLocation(locs)
 	if err != nil {
 		t.Fatalf("unexpected definition error after refinement: %v", err)
 	}
@@ -1714,6 +1817,30 @@ func TestRefreshModuleAndDependentsRebuildsDependentsWhenExportsChange(t *testin
 	}
 }
 
+func TestRefreshModuleAndDependentsRefreshesDiagnosticsWhenParameterKindsChange(t *testing.T) {
+	root := t.TempDir()
+	aPath := filepath.Join(root, "a.py")
+	bPath := filepath.Join(root, "b.py")
+	bCode := "from a import f\n\nf(1, 2)\n"
+	writeWorkspaceFile(t, aPath, "def f(x, y): ...\n")
+	writeWorkspaceFile(t, bPath, bCode)
+
+	s := newWorkspaceServer(t, root)
+	bURI := pathToURI(bPath)
+	s.Open(lsp.TextDocumentItem{URI: bURI, Text: bCode, Version: 1})
+	s.analyze(s.Get(bURI))
+	if errs := s.Get(bURI).SemErrs; len(errs) != 0 {
+		t.Fatalf("unexpected initial diagnostics: %+v", errs)
+	}
+
+	writeWorkspaceFile(t, aPath, "def f(x, *, y): ...\n")
+	s.refreshModuleAndDependents(pathToURI(aPath))
+
+	doc := s.Get(bURI)
+	assertSemanticDiagnostic(t, doc, "too many positional arguments for f(): expected 1, got 2", 2, 5)
+	assertSemanticDiagnostic(t, doc, "missing required keyword-only argument 'y' for f()", 2, 0)
+}
+
 func newWorkspaceServer(t *testing.T, root string) *Server {
 	t.Helper()
 
@@ -1754,13 +1881,22 @@ func writeWorkspaceFile(t *testing.T, path, content string) {
 	}
 }
 
+// firstLocation returns the primary definition target, or nil when there is none.
+func firstLocation(locs []lsp.Location) *lsp.Location {
+	if len(locs) == 0 {
+		return nil
+	}
+	return &locs[0]
+}
+
 func mustDefinitionAt(t *testing.T, s *Server, uri lsp.DocumentURI, _ string, line, char int) *lsp.Location {
 	t.Helper()
 
-	loc, err := s.Definition(&lsp.DefinitionParams{
+	locs, err := s.Definition(&lsp.DefinitionParams{
 		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
 		Position:     lsp.Position{Line: line, Character: char},
 	})
+	loc := firstLocation(locs)
 	if err != nil {
 		t.Fatalf("unexpected definition error: %v", err)
 	}
@@ -1832,3 +1968,186 @@ func assertClassBase(t *testing.T, doc *Document, className, baseName string) {
 	}
 	t.Fatalf("expected %s to inherit from %s, got %+v", className, baseName, classSym.Bases)
 }
+
+func TestImportedClassUnknownAttributeWarning(t *testing.T) {
+	root := t.TempDir()
+	writeWorkspaceFile(t, filepath.Join(root, "shapes.py"), "class Circle:\n    def __init__(self):\n        self.radius = 1\n")
+	mainPath := filepath.Join(root, "main.py")
+	mainCode := "from shapes import Circle\nCircle().raduis\n"
+	writeWorkspaceFile(t, mainPath, mainCode)
+
+	s := newWorkspaceServer(t, root)
+	mainURI := pathToURI(mainPath)
+	s.Open(lsp.TextDocumentItem{URI: mainURI, Text: mainCode, Version: 1})
+	s.analyze(s.Get(mainURI))
+
+	doc := s.Get(mainURI)
+	diags := toDiagnostics(doc.URI, doc.LineIndex, nil, doc.SemErrs)
+	for _, diag := range diags {
+		if diag.Message == "'Circle' object has no attribute 'raduis'; did you mean 'radius'?" {
+			if diag.Severity != lsp.SeverityWarning {
+				t.Fatalf("expected a warning, got %+v", diag)
+			}
+			return
+		}
+	}
+	t.Fatalf("expected unknown attribute warning, got %+v", diags)
+}
+
+func TestTypeCheckingImportsBindWithoutRuntimeDependency(t *testing.T) {
+	root := t.TempDir()
+	writeWorkspaceFile(t, filepath.Join(root, "models.py"), "class User:\n    name = \"x\"\n")
+	mainPath := filepath.Join(root, "main.py")
+	mainCode := "from typing import TYPE_CHECKING\nif TYPE_CHECKING:\n    from models import User\nelse:\n    User = None\n\ndef greet(user: User):\n    return user.name\n"
+	writeWorkspaceFile(t, mainPath, mainCode)
+
+	s := newWorkspaceServer(t, root)
+	mainURI := pathToURI(mainPath)
+	s.Open(lsp.TextDocumentItem{URI: mainURI, Text: mainCode, Version: 1})
+	s.analyze(s.Get(mainURI))
+
+	doc := s.Get(mainURI)
+	user, ok := doc.Global.LookupLocal("User")
+	if !ok || user.Kind != analyser.SymClass {
+		t.Fatalf("expected User to bind to the class imported under TYPE_CHECKING, got %+v", user)
+	}
+
+	snapshot, ok := s.getModuleSnapshotByURI(mainURI)
+	if !ok {
+		t.Fatal("expected a module snapshot for main.py")
+	}
+	if !slices.Contains(snapshot.Imports, "models") {
+		t.Fatalf("expected models to stay a dependency, got %v", snapshot.Imports)
+	}
+	if !slices.Equal(snapshot.TypeCheckingImports, []string{"models"}) {
+		t.Fatalf("expected models to be a TYPE_CHECKING-only import, got %v", snapshot.TypeCheckingImports)
+	}
+
+	for _, diag := range toDiagnostics(doc.URI, doc.LineIndex, nil, doc.SemErrs) {
+		if diag.Message == "code is inactive during type checking" {
+			if diag.Range.Start.Line != 4 || !slices.Equal(diag.Tags, []lsp.DiagnosticTag{lsp.DiagnosticTagUnnecessary}) {
+				t.Fatalf("expected the else branch to be faded as unnecessary, got %+v", diag)
+			}
+			return
+		}
+	}
+	t.Fatalf("expected an inactive-code hint for the else branch, got %+v", doc.SemErrs)
+}
+
+func TestUnusedImportHintsSkipPackageReexports(t *testing.T) {
+	root := t.TempDir()
+	writeWorkspaceFile(t, filepath.Join(root, "pkg", "models.py"), "class User:\n    pass\n")
+	initPath := filepath.Join(root, "pkg", "__init__.py")
+	initCode := "from pkg.models import User\n"
+	writeWorkspaceFile(t, initPath, initCode)
+	mainPath := filepath.Join(root, "main.py")
+	mainCode := "import os\nfrom pkg import User\n"
+	writeWorkspaceFile(t, mainPath, mainCode)
+
+	s := newWorkspaceServer(t, root)
+	initURI, mainURI := pathToURI(initPath), pathToURI(mainPath)
+	for uri, code := range map[lsp.DocumentURI]string{initURI: initCode, mainURI: mainCode} {
+		s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+		s.analyze(s.Get(uri))
+	}
+
+	initDoc := s.Get(initURI)
+	for _, diag := range toDiagnostics(initDoc.URI, initDoc.LineIndex, nil, initDoc.SemErrs) {
+		if strings.HasPrefix(diag.Message, "unused import") {
+			t.Fatalf("expected __init__.py imports to count as re-exports, got %+v", diag)
+		}
+	}
+
+	mainDoc := s.Get(mainURI)
+	var unused []string
+	for _, diag := range toDiagnostics(mainDoc.URI, mainDoc.LineIndex, nil, mainDoc.SemErrs) {
+		if !strings.HasPrefix(diag.Message, "unused import") {
+			continue
+		}
+		if diag.Severity != lsp.SeverityHint || !slices.Equal(diag.Tags, []lsp.DiagnosticTag{lsp.DiagnosticTagUnnecessary}) {
+			t.Fatalf("expected an unnecessary hint, got %+v", diag)
+		}
+		unused = append(unused, diag.Message)
+	}
+	if !slices.Equal(unused, []string{"unused import: os", "unused import: User"}) {
+		t.Fatalf("expected os and User to be reported unused, got %v", unused)
+	}
+}
+
+func TestRedefinitionWarningPointsAtEarlierDefinition(t *testing.T) {
+	root := t.TempDir()
+	mainPath := filepath.Join(root, "main.py")
+	mainCode := "def handler():\n    return 1\n\ndef handler(list):\n    return list\n"
+	writeWorkspaceFile(t, mainPath, mainCode)
+
+	s := newWorkspaceServer(t, root)
+	mainURI := pathToURI(mainPath)
+	s.Open(lsp.TextDocumentItem{URI: mainURI, Text: mainCode, Version: 1})
+	s.analyze(s.Get(mainURI))
+
+	doc := s.Get(mainURI)
+	var redefinition, shadowing *lsp.Diagnostic
+	diags := toDiagnostics(doc.URI, doc.LineIndex, nil, doc.SemErrs)
+	for i := range diags {
+		switch diags[i].Message {
+		case "redefinition of unused name: handler":
+			redefinition = &diags[i]
+		case "parameter list shadows a builtin":
+			shadowing = &diags[i]
+		}
+	}
+	if redefinition == nil || shadowing == nil {
+		t.Fatalf("expected redefinition and shadowing diagnostics, got %+v", diags)
+	}
+	if redefinition.Severity != lsp.SeverityWarning || redefinition.Range.Start.Line != 3 {
+		t.Fatalf("unexpected redefinition warning %+v", redefinition)
+	}
+	want := []lsp.DiagnosticRelatedInformation{{
+		Location: lsp.Location{URI: mainURI, Range: lsp.Range{
+			Start: lsp.Position{Line: 0, Character: 4},
+			End:   lsp.Position{Line: 0, Character: 11},
+		}},
+		Message: "previous definition of handler",
+	}}
+	if !slices.Equal(redefinition.RelatedInformation, want) {
+		t.Fatalf("expected related information %+v, got %+v", want, redefinition.RelatedInformation)
+	}
+	if shadowing.Severity != lsp.SeverityInformation {
+		t.Fatalf("expected an informational diagnostic, got %+v", shadowing)
+	}
+}
+
+func TestABCSubclassesReportAbstractInstantiation(t *testing.T) {
+	root := t.TempDir()
+	mainPath := filepath.Join(root, "main.py")
+	mainCode := "import abc\nfrom abc import ABC, ABCMeta, abstractmethod\n\nclass Plugin(ABC):\n    @abstractmethod\n    def run(self): ...\n\nclass Task(abc.ABC):\n    @abc.abstractmethod\n    def run(self): ...\n\nclass Job(metaclass=ABCMeta):\n    @abstractmethod\n    def run(self): ...\n\nPlugin()\nTask()\nJob()\n"
+	writeWorkspaceFile(t, mainPath, mainCode)
+	mainURI := pathToURI(mainPath)
+
+	withABC := newWorkspaceServer(t, root)
+	// Without typeshed or an interpreter, abc cannot be loaded and its names
+	// are recognised by how they were imported.
+	withoutABC := newWorkspaceServerWithExternalRoots(t, root)
+	withoutABC.indexMu.Lock()
+	withoutABC.typeshedLoader = nil
+	withoutABC.pythonExecutable = ""
+	withoutABC.indexMu.Unlock()
+	withoutABC.snapshotsMu.Lock()
+	delete(withoutABC.moduleSnapshotsByName, "abc")
+	withoutABC.snapshotsMu.Unlock()
+
+	for _, s := range []*Server{withABC, withoutABC} {
+		s.Open(lsp.TextDocumentItem{URI: mainURI, Text: mainCode, Version: 1})
+		s.analyze(s.Get(mainURI))
+		doc := s.Get(mainURI)
+
+		assertSemanticDiagnostic(t, doc, "cannot instantiate abstract class Plugin with abstract methods: run", 15, 0)
+		assertSemanticDiagnostic(t, doc, "cannot instantiate abstract class Task with abstract methods: run", 16, 0)
+		assertSemanticDiagnostic(t, doc, "cannot instantiate abstract class Job with abstract methods: run", 17, 0)
+		for _, err := range doc.SemErrs {
+			if strings.Contains(err.Msg, "base class") || strings.Contains(err.Msg, "is not a class") {
+				t.Fatalf("unexpected diagnostic %q", err.Msg)
+			}
+		}
+	}
+}
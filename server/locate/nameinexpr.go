@@ -46,7 +46,7 @@ func locateInExpr(tree *ast.AST, expr ast.NodeID, pos int, mode locateMode) Resu
 	case ast.NodeFStringExpr:
 		return locateInExpr(tree, tree.ChildAt(expr, 0), pos, mode)
 
-	case ast.NodeTuple, ast.NodeList, ast.NodeBooleanOp, ast.NodeCall:
+	case ast.NodeTuple, ast.NodeList, ast.NodeBooleanOp, ast.NodeCall, ast.NodeMatchAs:
 		for child := tree.Nodes[expr].FirstChild; child != ast.NoNode; child = tree.Nodes[child].NextSibling {
 			if res := locateInExpr(tree, child, pos, mode); res.Kind != NoResult {
 				return res
@@ -112,7 +112,7 @@ func locateInExpr(tree *ast.AST, expr ast.NodeID, pos int, mode locateMode) Resu
 			}
 		}
 
-	case ast.NodeUnaryOp:
+	case ast.NodeUnaryOp, ast.NodeAwait:
 		return locateInExpr(tree, tree.Nodes[expr].FirstChild, pos, mode)
 
 	case ast.NodeAttribute:
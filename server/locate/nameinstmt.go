@@ -218,6 +218,26 @@ func locateInStmt(tree *ast.AST, stmt ast.NodeID, pos int, mode locateMode) Resu
 			}
 		}
 
+	case ast.NodeMatch:
+		subject, cases := tree.MatchParts(stmt)
+		if res := locateInExpr(tree, subject, pos, mode); res.Kind != NoResult {
+			return res
+		}
+		for _, clause := range cases {
+			pattern, guard, body := tree.MatchCaseParts(clause)
+			if res := locateInExpr(tree, pattern, pos, mode); res.Kind != NoResult {
+				return res
+			}
+			if res := locateInExpr(tree, guard, pos, mode); res.Kind != NoResult {
+				return res
+			}
+			for inner := tree.Nodes[body].FirstChild; inner != ast.NoNode; inner = tree.Nodes[inner].NextSibling {
+				if res := locateInStmt(tree, inner, pos, mode); res.Kind != NoResult {
+					return res
+				}
+			}
+		}
+
 	case ast.NodeExprStmt, ast.NodeReturn:
 		return locateInExpr(tree, tree.Nodes[stmt].FirstChild, pos, mode)
 
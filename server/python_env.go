@@ -15,6 +15,9 @@ type pythonEnvInfo struct {
 	Executable string
 	Paths      []string `json:"path"`
 	Builtins   []string `json:"builtins"`
+	// Version is the interpreter's `--version`, e.g. "3.12.1", or empty
+	// when it could not be run.
+	Version string `json:"-"`
 }
 
 // pythonEnvCache is the on-disk cache format
@@ -41,21 +44,33 @@ type typeshedCacheData struct {
 // Cache expiration period (7 days)
 const cacheExpirationDays = 7
 
-// discoverPythonEnvCached attempts to load from cache before doing full discovery
-func discoverPythonEnvCached(rootPath string, server *Server) pythonEnvInfo {
+// discoverPythonEnvCached attempts to load from cache before doing full discovery.
+// pythonPath is the interpreter configured by the client, if any.
+func discoverPythonEnvCached(rootPath, pythonPath string, server *Server) pythonEnvInfo {
 	// Skip caching if no root path (single file mode)
 	if rootPath == "" {
-		return discoverPythonEnv(rootPath)
+		var env pythonEnvInfo
+		if pythonPath != "" {
+			env = discoverPythonEnvWithPaths(rootPath, pythonPath)
+		} else {
+			env = discoverPythonEnv(rootPath)
+		}
+		env.Version = getPythonVersion(env.Executable)
+		return env
 	}
 
 	// First, discover the Python executable (this is fast, just file checks)
-	python := discoverPythonExecutable(rootPath)
+	python := pythonPath
+	if python == "" {
+		python = discoverPythonExecutable(rootPath)
+	}
 	if python == "" {
 		return pythonEnvInfo{}
 	}
 
 	// Get Python version for cache file naming
-	pyVersion := getPythonVersion(python)
+	version := getPythonVersion(python)
+	pyVersion := version
 	if pyVersion == "" {
 		// Fallback to using executable path as identifier
 		pyVersion = "unknown"
@@ -69,6 +84,7 @@ func discoverPythonEnvCached(rootPath string, server *Server) pythonEnvInfo {
 				Executable: cache.Executable,
 				Paths:      cache.Paths,
 				Builtins:   cache.Builtins,
+				Version:    version,
 			}
 		}
 		log.Printf("[python-env] Cache invalid or expired, re-discovering...")
@@ -76,6 +92,7 @@ func discoverPythonEnvCached(rootPath string, server *Server) pythonEnvInfo {
 
 	// Cache miss or invalid - do full discovery (slow path)
 	env := discoverPythonEnvWithPaths(rootPath, python)
+	env.Version = version
 
 	// Save to cache for next time
 	if env.Executable != "" {
@@ -229,6 +246,12 @@ func validatePythonEnvCache(cache *pythonEnvCache, currentPython, currentVersion
 		return false
 	}
 
+	// Check the cache was built for this interpreter, which may be configured
+	if cache.Executable != currentPython {
+		log.Printf("[python-env] Cache executable mismatch: cached=%s, current=%s", cache.Executable, currentPython)
+		return false
+	}
+
 	// Check 7-day expiration
 	if isCacheExpired(cache.CachedAt, cacheExpirationDays) {
 		log.Printf("[python-env] Cache expired (older than %d days)", cacheExpirationDays)
@@ -8,6 +8,7 @@ import (
 	"rahu/jsonrpc"
 	"rahu/lsp"
 	ast "rahu/parser/ast"
+	"rahu/source"
 )
 
 type renameTarget struct {
@@ -151,10 +152,58 @@ func (s *Server) Rename(p *lsp.RenameParams) (*lsp.WorkspaceEdit, *jsonrpc.Error
 	if len(changes) == 0 {
 		return nil, jsonrpc.InvalidParamsError(nil)
 	}
+	if !target.isAttr && target.sym.Scope != nil && target.sym.Scope.Kind == a.ScopeGlobal {
+		s.renameAllEntries(target, p.NewName, changes)
+	}
 
 	return &lsp.WorkspaceEdit{Changes: changes}, nil
 }
 
+// renameAllEntries adds edits for the strings naming a renamed module-level
+// symbol in the __all__ of the modules that define or re-export it. Only the
+// modules already edited are searched, since a re-export is a reference.
+func (s *Server) renameAllEntries(target *renameTarget, newName string, changes map[lsp.DocumentURI][]lsp.TextEdit) {
+	key, ok := symbolRefKey(target.sym)
+	if !ok {
+		return
+	}
+	for uri := range changes {
+		tree, global, li := s.moduleTreeForURI(uri)
+		if tree == nil || global == nil || li == nil {
+			continue
+		}
+		bound, ok := symbolRefKey(global.Symbols[target.name])
+		if !ok || bound != key {
+			continue
+		}
+		entries, _ := a.AllEntries(tree, s.analysisTarget(), nil)
+		for _, entry := range entries {
+			if entry.Node == ast.NoNode || entry.Name != target.name {
+				continue
+			}
+			span := a.StringContentSpan(tree, entry.Node)
+			if span == tree.RangeOf(entry.Node) {
+				continue
+			}
+			changes[uri] = append(changes[uri], lsp.TextEdit{Range: ToRange(li, span), NewText: newName})
+		}
+	}
+}
+
+// moduleTreeForURI returns the syntax tree, module scope and line index of
+// an open document or an analysed module.
+func (s *Server) moduleTreeForURI(uri lsp.DocumentURI) (*ast.AST, *a.Scope, *source.LineIndex) {
+	if doc := s.Get(uri); doc != nil {
+		doc.mu.RLock()
+		defer doc.mu.RUnlock()
+		return doc.Tree, doc.Global, doc.LineIndex
+	}
+	if snapshot, ok := s.getModuleSnapshotByURI(uri); ok {
+		return snapshot.Tree, snapshot.Global, snapshot.LineIndex
+	}
+	return nil, nil, nil
+}
+
 func (s *Server) PrepareRename(p *lsp.PrepareRenameParams) (*lsp.PrepareRenameResult, *jsonrpc.Error) {
 	doc := s.Get(p.TextDocument.URI)
 	target, targetErr := renameTargetAt(doc, p.Position)
@@ -2,6 +2,7 @@ package server
 
 import (
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"rahu/lsp"
@@ -47,6 +48,38 @@ func TestRenameCrossFileExportedFunction(t *testing.T) {
 	}
 }
 
+func TestRenameUpdatesAllEntries(t *testing.T) {
+	root := t.TempDir()
+	modPath := filepath.Join(root, "mod.py")
+	apiPath := filepath.Join(root, "api.py")
+	modCode := "__all__ = ['foo']\n\ndef foo():\n    pass\n"
+	apiCode := "from mod import foo\n__all__ = [\"foo\"]\n"
+	writeWorkspaceFile(t, modPath, modCode)
+	writeWorkspaceFile(t, apiPath, apiCode)
+
+	s := newWorkspaceServer(t, root)
+	modURI := pathToURI(modPath)
+	s.Open(lsp.TextDocumentItem{URI: modURI, Text: modCode, Version: 1})
+	s.analyze(s.Get(modURI))
+
+	edit, err := s.Rename(renameParams(modURI, modCode, 2, 4, "renamed"))
+	if err != nil {
+		t.Fatalf("unexpected rename error: %v", err)
+	}
+	allEdit := lsp.TextEdit{
+		Range:   lsp.Range{Start: lsp.Position{Line: 0, Character: 12}, End: lsp.Position{Line: 0, Character: 15}},
+		NewText: "renamed",
+	}
+	if len(edit.Changes[modURI]) != 2 || !slices.Contains(edit.Changes[modURI], allEdit) {
+		t.Fatalf("expected the __all__ entry of mod to be renamed, got %+v", edit.Changes[modURI])
+	}
+	apiURI := pathToURI(apiPath)
+	allEdit.Range.Start.Line, allEdit.Range.End.Line = 1, 1
+	if len(edit.Changes[apiURI]) != 2 || !slices.Contains(edit.Changes[apiURI], allEdit) {
+		t.Fatalf("expected the re-export in api's __all__ to be renamed, got %+v", edit.Changes[apiURI])
+	}
+}
+
 func TestRenameAliasImportDoesNotRenameSource(t *testing.T) {
 	root := t.TempDir()
 	modPath := filepath.Join(root, "mod.py")
@@ -96,6 +96,9 @@ func semanticTokenTypeForSymbol(sym *a.Symbol) (int, bool) {
 	case a.SymClass:
 		return semanticTokenClass, true
 	case a.SymFunction:
+		if sym.Method == a.MethodProperty {
+			return semanticTokenProperty, true
+		}
 		return semanticTokenFunction, true
 	case a.SymParameter:
 		return semanticTokenParameter, true
@@ -142,7 +145,7 @@ func semanticTokenModifiersForSymbol(sym *a.Symbol, declaration bool) int {
 	if sym == nil {
 		return mods
 	}
-	if sym.Kind == a.SymConstant {
+	if sym.Kind == a.SymConstant || (sym.Final && sym.Kind != a.SymFunction) {
 		mods |= semanticModifierReadonly
 	}
 	if sym.Scope != nil && sym.Scope.Kind == a.ScopeBuiltin {
@@ -186,7 +189,7 @@ func appendSemanticSymbolToken(entries *[]semanticTokenEntry, seen map[semanticT
 	if !ok {
 		return
 	}
-	if sym.Kind == a.SymFunction && (isAttr || isMethodDeclarationNode(doc.Tree, nodeID)) {
+	if sym.Kind == a.SymFunction && sym.Method != a.MethodProperty && (isAttr || isMethodDeclarationNode(doc.Tree, nodeID)) {
 		tokenType = semanticTokenMethod
 	}
 	r := semanticTokenRangeForNode(doc.Tree, nodeID, isAttr)
@@ -55,6 +55,22 @@ func TestSemanticTokensPropertyAndVariable(t *testing.T) {
 	assertSemanticToken(t, decoded, 5, 4, 5, "property")
 }
 
+func TestSemanticTokensDecoratedProperty(t *testing.T) {
+	code := "class Foo:\n    @property\n    def size(self) -> int:\n        return 1\n\nFoo().size\n"
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	tokens, err := s.SemanticTokensFull(&lsp.SemanticTokensParams{TextDocument: lsp.TextDocumentIdentifier{URI: uri}})
+	if err != nil {
+		t.Fatalf("unexpected semantic tokens error: %v", err)
+	}
+	decoded := decodeSemanticTokens(tokens)
+	assertSemanticToken(t, decoded, 2, 8, 4, "property")
+	assertSemanticToken(t, decoded, 5, 6, 4, "property")
+}
+
 func TestSemanticTokensImportedResolvedSymbolUsesResolvedKind(t *testing.T) {
 	root := t.TempDir()
 	modPath := filepath.Join(root, "mod.py")
@@ -81,6 +97,25 @@ func TestSemanticTokensImportedResolvedSymbolUsesResolvedKind(t *testing.T) {
 	assertSemanticToken(t, decoded, 1, 0, 3, "function")
 }
 
+func TestSemanticTokensMarkFinalNamesReadonly(t *testing.T) {
+	code := "MAX: Final = 3\ncount = MAX\nclass Conf:\n    def __init__(self):\n        self.path: Final = \"x\"\nConf().path\n"
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	tokens, err := s.SemanticTokensFull(&lsp.SemanticTokensParams{TextDocument: lsp.TextDocumentIdentifier{URI: uri}})
+	if err != nil {
+		t.Fatalf("unexpected semantic tokens error: %v", err)
+	}
+	decoded := decodeSemanticTokens(tokens)
+	assertSemanticTokenReadonly(t, decoded, 0, 0, 3, true)
+	assertSemanticTokenReadonly(t, decoded, 1, 0, 5, false)
+	assertSemanticTokenReadonly(t, decoded, 1, 8, 3, true)
+	assertSemanticTokenReadonly(t, decoded, 4, 13, 4, true)
+	assertSemanticTokenReadonly(t, decoded, 5, 7, 4, true)
+}
+
 func TestSemanticTokensIncludePassKeyword(t *testing.T) {
 	code := "if flag:\n    pass\n"
 	s := New(nil)
@@ -220,3 +255,16 @@ func assertSemanticToken(t *testing.T, tokens []decodedSemanticToken, line, star
 	}
 	t.Fatalf("expected semantic token %s at %d:%d len %d, got %+v", tokenType, line, start, length, tokens)
 }
+
+func assertSemanticTokenReadonly(t *testing.T, tokens []decodedSemanticToken, line, start, length int, readonly bool) {
+	t.Helper()
+	for _, token := range tokens {
+		if token.line == line && token.start == start && token.length == length {
+			if got := token.modifiers&semanticModifierReadonly != 0; got != readonly {
+				t.Fatalf("expected readonly=%v for token at %d:%d, got modifiers %b", readonly, line, start, token.modifiers)
+			}
+			return
+		}
+	}
+	t.Fatalf("expected semantic token at %d:%d len %d, got %+v", line, start, length, tokens)
+}
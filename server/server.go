@@ -43,10 +43,14 @@ type Server struct {
 	openModuleCounts      map[lsp.DocumentURI]int
 	snapshotLRU           *snapshotLRU
 	maxCachedModules      int
+	// classSummariesByURI outlives eviction so that evicted modules can be
+	// ruled out without analysing them again.
+	classSummariesByURI map[lsp.DocumentURI][]classSummary
 
 	// Dependencies lock - protects import/dependency graph
 	depsMu              sync.RWMutex
 	moduleImportsByURI  map[lsp.DocumentURI][]string
+	runtimeImportsByURI map[lsp.DocumentURI][]string
 	reverseDepsByModule map[string]map[lsp.DocumentURI]struct{}
 
 	// Misc lock - protects low-contention miscellaneous state
@@ -57,6 +61,8 @@ type Server struct {
 	rootPath                 string
 	priorityDir              string
 	workspaceIndexedNotified bool
+	settings                 Settings
+	target                   analyser.Target
 	indexingCtx              context.Context
 	indexingCancel           context.CancelFunc
 	indexingDone             chan struct{}
@@ -116,6 +122,8 @@ type StartupModuleBase struct {
 	Tree      *ast.AST
 	ParseErrs []parser.Error
 	Imports   []string
+	// TypeCheckingImports are the Imports only reached under TYPE_CHECKING.
+	TypeCheckingImports []string
 }
 
 type ModuleImportSurface struct {
@@ -145,6 +153,9 @@ type ModuleSnapshot struct {
 	ExportHash  uint64
 	Imports     []string
 	TextHash    uint64
+	// TypeCheckingImports are the Imports only reached under TYPE_CHECKING;
+	// they matter for typing but are never imported at runtime.
+	TypeCheckingImports []string
 }
 
 func New(conn *jsonrpc.Conn) *Server {
@@ -159,13 +170,16 @@ func New(conn *jsonrpc.Conn) *Server {
 		pythonBuiltinNames:     make(map[string]struct{}),
 		pythonModuleInfoByName: make(map[string]pythonModuleInfo),
 		moduleImportsByURI:     make(map[lsp.DocumentURI][]string),
+		runtimeImportsByURI:    make(map[lsp.DocumentURI][]string),
 		reverseDepsByModule:    make(map[string]map[lsp.DocumentURI]struct{}),
 		buildingModules:        make(map[string]chan struct{}),
 		openModuleCounts:       make(map[lsp.DocumentURI]int),
 		moduleSnapshotsByName:  make(map[string]*ModuleSnapshot),
 		moduleSnapshotsByURI:   make(map[lsp.DocumentURI]*ModuleSnapshot),
+		classSummariesByURI:    make(map[lsp.DocumentURI][]classSummary),
 		snapshotLRU:            newSnapshotLRU(),
 		maxCachedModules:       defaultMaxCachedModules,
+		settings:               defaultSettings(),
 		refIndex:               NewRefIndex(),
 		pythonMethodCache:      make(map[string]pythonMethodInfo),
 		scheduleAsync: func(fn func()) {
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"rahu/analyser"
+)
+
+// Settings are the options a client passes as initializationOptions.
+type Settings struct {
+	Python PythonSettings `json:"python"`
+	// Exclude lists globs of workspace paths, relative to the root, that are
+	// not indexed. A glob without a slash matches a file or directory name
+	// anywhere, and ** matches any number of directories.
+	Exclude []string `json:"exclude"`
+	// MaxCachedModules bounds the analysed modules kept in memory.
+	MaxCachedModules int                `json:"maxCachedModules"`
+	Diagnostics      DiagnosticSettings `json:"diagnostics"`
+}
+
+// PythonSettings select the interpreter and where imports are searched
+// besides its sys.path. Platform is the sys.platform value that conditions
+// are evaluated against, e.g. "win32"; it defaults to the host's.
+type PythonSettings struct {
+	PythonPath string   `json:"pythonPath"`
+	ExtraPaths []string `json:"extraPaths"`
+	Platform   string   `json:"platform"`
+}
+
+// DiagnosticSettings turn the optional diagnostics on and off.
+type DiagnosticSettings struct {
+	UnusedNames   bool `json:"unusedNames"`
+	Redefinitions bool `json:"redefinitions"`
+	UndefinedAll  bool `json:"undefinedAll"`
+	ImportCycles  bool `json:"importCycles"`
+}
+
+func defaultSettings() Settings {
+	return Settings{
+		MaxCachedModules: defaultMaxCachedModules,
+		Diagnostics: DiagnosticSettings{
+			UnusedNames:   true,
+			Redefinitions: true,
+			UndefinedAll:  true,
+			ImportCycles:  true,
+		},
+	}
+}
+
+// parseSettings decodes initializationOptions over the defaults, resolving
+// paths against the workspace root. Each option is decoded on its own, so an
+// invalid value falls back to its default, with a warning, without losing
+// the others.
+func parseSettings(raw json.RawMessage, rootPath string) (Settings, []string) {
+	settings := defaultSettings()
+	if len(raw) == 0 || string(raw) == "null" {
+		return settings, nil
+	}
+	var options map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &options); err != nil {
+		return settings, []string{fmt.Sprintf("Ignoring initializationOptions: %v", err)}
+	}
+
+	var warnings []string
+	var python, diagnostics map[string]json.RawMessage
+	decodeSetting(options, "python", "python", &python, &warnings)
+	decodeSetting(python, "pythonPath", "python.pythonPath", &settings.Python.PythonPath, &warnings)
+	decodeSetting(python, "extraPaths", "python.extraPaths", &settings.Python.ExtraPaths, &warnings)
+	decodeSetting(python, "platform", "python.platform", &settings.Python.Platform, &warnings)
+	decodeSetting(options, "exclude", "exclude", &settings.Exclude, &warnings)
+	decodeSetting(options, "maxCachedModules", "maxCachedModules", &settings.MaxCachedModules, &warnings)
+	decodeSetting(options, "diagnostics", "diagnostics", &diagnostics, &warnings)
+	decodeSetting(diagnostics, "unusedNames", "diagnostics.unusedNames", &settings.Diagnostics.UnusedNames, &warnings)
+	decodeSetting(diagnostics, "redefinitions", "diagnostics.redefinitions", &settings.Diagnostics.Redefinitions, &warnings)
+	decodeSetting(diagnostics, "undefinedAll", "diagnostics.undefinedAll", &settings.Diagnostics.UndefinedAll, &warnings)
+	decodeSetting(diagnostics, "importCycles", "diagnostics.importCycles", &settings.Diagnostics.ImportCycles, &warnings)
+	warnUnknownSettings(options, "", &warnings, "python", "exclude", "maxCachedModules", "diagnostics")
+	warnUnknownSettings(python, "python.", &warnings, "pythonPath", "extraPaths", "platform")
+	warnUnknownSettings(diagnostics, "diagnostics.", &warnings, "unusedNames", "redefinitions", "undefinedAll", "importCycles")
+
+	if settings.Python.PythonPath != "" {
+		python, err := resolvePythonPath(settings.Python.PythonPath, rootPath)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Ignoring python.pythonPath %q: %v", settings.Python.PythonPath, err))
+		}
+		settings.Python.PythonPath = python
+	}
+
+	extraPaths := settings.Python.ExtraPaths[:0]
+	for _, p := range settings.Python.ExtraPaths {
+		dir := resolveSettingsPath(p, rootPath)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			warnings = append(warnings, fmt.Sprintf("Ignoring python.extraPaths entry %q: not a directory", p))
+			continue
+		}
+		extraPaths = append(extraPaths, dir)
+	}
+	settings.Python.ExtraPaths = extraPaths
+
+	exclude := settings.Exclude[:0]
+	for _, original := range settings.Exclude {
+		pattern := strings.Trim(filepath.ToSlash(original), "/")
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			warnings = append(warnings, fmt.Sprintf("Ignoring exclude pattern %q: malformed glob", original))
+			continue
+		}
+		exclude = append(exclude, pattern)
+	}
+	settings.Exclude = exclude
+
+	if settings.MaxCachedModules < 1 {
+		warnings = append(warnings, fmt.Sprintf("Ignoring maxCachedModules %d: must be at least 1, using %d", settings.MaxCachedModules, defaultMaxCachedModules))
+		settings.MaxCachedModules = defaultMaxCachedModules
+	}
+	return settings, warnings
+}
+
+// decodeSetting decodes the option key of fields into dst, leaving dst at
+// its default and adding a warning for name when the value is malformed.
+func decodeSetting[T any](fields map[string]json.RawMessage, key, name string, dst *T, warnings *[]string) {
+	raw, ok := fields[key]
+	if !ok || string(raw) == "null" {
+		return
+	}
+	var value T
+	if err := json.Unmarshal(raw, &value); err != nil {
+		*warnings = append(*warnings, fmt.Sprintf("Ignoring %s: %v", name, err))
+		return
+	}
+	*dst = value
+}
+
+// warnUnknownSettings adds a warning for each option of fields that is not
+// one of known, naming it with prefix, in sorted order.
+func warnUnknownSettings(fields map[string]json.RawMessage, prefix string, warnings *[]string, known ...string) {
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		if !slices.Contains(known, key) {
+			*warnings = append(*warnings, fmt.Sprintf("Ignoring unknown option %s%s", prefix, key))
+		}
+	}
+}
+
+// resolvePythonPath returns the interpreter a pythonPath setting names: a
+// path, relative to the workspace root, or a command looked up on PATH.
+func resolvePythonPath(python, rootPath string) (string, error) {
+	if !strings.ContainsRune(python, '/') && !strings.ContainsRune(python, filepath.Separator) {
+		return exec.LookPath(python)
+	}
+	python = resolveSettingsPath(python, rootPath)
+	info, err := os.Stat(python)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", python)
+	}
+	return python, nil
+}
+
+func resolveSettingsPath(p, rootPath string) string {
+	if home, err := os.UserHomeDir(); err == nil && (p == "~" || strings.HasPrefix(p, "~/")) {
+		p = filepath.Join(home, p[1:])
+	}
+	if !filepath.IsAbs(p) && rootPath != "" {
+		p = filepath.Join(rootPath, p)
+	}
+	return filepath.Clean(p)
+}
+
+// isExcluded reports whether the workspace path rel, relative to the root,
+// matches one of the exclude globs.
+func isExcluded(exclude []string, rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range exclude {
+		if !strings.Contains(pattern, "/") {
+			pattern = "**/" + pattern
+		}
+		if matchGlobSegments(strings.Split(pattern, "/"), strings.Split(rel, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcludedPath reports whether path, inside the workspace at rootPath,
+// matches one of the exclude globs. The root itself is never excluded.
+func isExcludedPath(rootPath string, exclude []string, path string) bool {
+	if len(exclude) == 0 {
+		return false
+	}
+	rel, err := filepath.Rel(rootPath, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	return isExcluded(exclude, rel)
+}
+
+// matchGlobSegments matches path segments against glob segments, where a **
+// segment stands for any number of segments.
+func matchGlobSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlobSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchGlobSegments(pattern[1:], segments[1:])
+}
+
+// currentSettings returns the settings the client initialized the server
+// with.
+func (s *Server) currentSettings() Settings {
+	s.miscMu.Lock()
+	defer s.miscMu.Unlock()
+	return s.settings
+}
+
+// analysisTarget returns the interpreter that modules are analysed for.
+func (s *Server) analysisTarget() analyser.Target {
+	s.miscMu.Lock()
+	defer s.miscMu.Unlock()
+	return s.target
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"rahu/analyser"
+	"rahu/lsp"
+)
+
+func TestParseSettingsDropsInvalidValuesWithWarnings(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "libs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, warnings := parseSettings(nil, root)
+	if len(warnings) != 0 || !slices.Equal([]string(nil), settings.Exclude) || settings.MaxCachedModules != defaultMaxCachedModules || !settings.Diagnostics.UnusedNames {
+		t.Fatalf("expected defaults without options, got %+v %v", settings, warnings)
+	}
+
+	raw := json.RawMessage(`{
+		"python": {"pythonPath": "missing/python", "extraPaths": ["libs", "nowhere"]},
+		"exclude": ["generated/**", "[bad/"],
+		"maxCachedModules": 0,
+		"diagnostics": {"importCycles": false}
+	}`)
+	settings, warnings = parseSettings(raw, root)
+	if len(warnings) != 4 {
+		t.Fatalf("expected four warnings, got %q", warnings)
+	}
+	for i, prefix := range []string{"Ignoring python.pythonPath", "Ignoring python.extraPaths entry \"nowhere\"", "Ignoring exclude pattern \"[bad/\"", "Ignoring maxCachedModules 0"} {
+		if !strings.HasPrefix(warnings[i], prefix) {
+			t.Fatalf("expected warning %d to start with %q, got %q", i, prefix, warnings[i])
+		}
+	}
+	if settings.Python.PythonPath != "" || !slices.Equal(settings.Python.ExtraPaths, []string{filepath.Join(root, "libs")}) {
+		t.Fatalf("unexpected python settings %+v", settings.Python)
+	}
+	if !slices.Equal(settings.Exclude, []string{"generated/**"}) || settings.MaxCachedModules != defaultMaxCachedModules {
+		t.Fatalf("unexpected settings %+v", settings)
+	}
+	if settings.Diagnostics.ImportCycles || !settings.Diagnostics.Redefinitions {
+		t.Fatalf("expected only import cycles to be disabled, got %+v", settings.Diagnostics)
+	}
+
+	settings, warnings = parseSettings(json.RawMessage(`{
+		"python": {"extraPaths": "libs", "platform": "win32", "pythonpath": "python3"},
+		"exclude": ["generated"],
+		"maxCachedModules": "many",
+		"diagnostics": {"unusedNames": "no", "redefinitions": false, "unused": true},
+		"excludes": ["build"]
+	}`), root)
+	if len(warnings) != 6 {
+		t.Fatalf("expected six warnings, got %q", warnings)
+	}
+	for i, prefix := range []string{
+		"Ignoring python.extraPaths:", "Ignoring maxCachedModules:", "Ignoring diagnostics.unusedNames:",
+		"Ignoring unknown option excludes", "Ignoring unknown option python.pythonpath", "Ignoring unknown option diagnostics.unused",
+	} {
+		if !strings.HasPrefix(warnings[i], prefix) {
+			t.Fatalf("expected warning %d to start with %q, got %q", i, prefix, warnings[i])
+		}
+	}
+	if settings.Python.Platform != "win32" || len(settings.Python.ExtraPaths) != 0 || !slices.Equal(settings.Exclude, []string{"generated"}) {
+		t.Fatalf("expected the valid options to be kept, got %+v", settings)
+	}
+	if settings.MaxCachedModules != defaultMaxCachedModules || !settings.Diagnostics.UnusedNames || settings.Diagnostics.Redefinitions {
+		t.Fatalf("expected only the malformed values to fall back, got %+v", settings)
+	}
+
+	if _, warnings := parseSettings(json.RawMessage(`["python"]`), root); len(warnings) != 1 {
+		t.Fatalf("expected malformed options to be reported, got %q", warnings)
+	}
+}
+
+func TestIsExcludedMatchesGlobs(t *testing.T) {
+	tests := []struct {
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{"generated", "generated", true},
+		{"generated", "pkg/generated", true},
+		{"*_pb2.py", "api/service_pb2.py", true},
+		{"pkg/generated", "pkg/generated", true},
+		{"pkg/generated", "other/pkg/generated", false},
+		{"**/migrations/*.py", "app/db/migrations/0001.py", true},
+		{"tests/**", "tests", true},
+		{"tests/**", "src/tests", false},
+	}
+	for _, tt := range tests {
+		if got := isExcluded([]string{tt.pattern}, tt.rel); got != tt.want {
+			t.Errorf("isExcluded(%q, %q) = %v, want %v", tt.pattern, tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestInitializationOptionsConfigureIndexingAndDiagnostics(t *testing.T) {
+	root := t.TempDir()
+	extra := t.TempDir()
+	writeWorkspaceFile(t, filepath.Join(extra, "extlib.py"), "VALUE = 1\n")
+	writeWorkspaceFile(t, filepath.Join(root, "generated", "models.py"), "x = 1\n")
+	writeWorkspaceFile(t, filepath.Join(root, "helpers.py"), "def helper(): ...\n")
+	mainPath := filepath.Join(root, "main.py")
+	mainCode := "import extlib\nimport helpers\n\ndef run(list):\n    return extlib.VALUE\n"
+	writeWorkspaceFile(t, mainPath, mainCode)
+
+	s := New(nil)
+	rootURI := pathToURI(root)
+	options, err := json.Marshal(map[string]any{
+		"python":           map[string]any{"extraPaths": []string{extra}},
+		"exclude":          []string{"generated"},
+		"maxCachedModules": 8,
+		"diagnostics":      map[string]any{"unusedNames": false, "redefinitions": false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, rpcErr := s.Initialize(&lsp.InitializeParams{RootURI: &rootURI, InitializationOptions: options}); rpcErr != nil {
+		t.Fatalf("initialize failed: %v", rpcErr)
+	}
+	s.Initialized(nil)
+	if err := s.WaitForIndexing(); err != nil {
+		t.Fatalf("indexing failed: %v", err)
+	}
+
+	if s.maxCachedModules != 8 {
+		t.Fatalf("expected the module cache limit to be 8, got %d", s.maxCachedModules)
+	}
+	if _, ok := s.LookupModule("generated.models"); ok {
+		t.Fatal("expected the excluded directory not to be indexed")
+	}
+	if len(s.externalSearchRoots) == 0 || s.externalSearchRoots[0] != extra {
+		t.Fatalf("expected %s to be searched first, got %v", extra, s.externalSearchRoots)
+	}
+
+	mainURI := pathToURI(mainPath)
+	s.Open(lsp.TextDocumentItem{URI: mainURI, Text: mainCode, Version: 1})
+	s.analyze(s.Get(mainURI))
+	if errs := s.Get(mainURI).SemErrs; len(errs) != 0 {
+		t.Fatalf("expected no diagnostics with unused names and redefinitions disabled, got %+v", errs)
+	}
+}
+
+func TestAnalysisTargetFollowsInterpreterVersionAndPlatformSetting(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stand-in interpreter is a shell script")
+	}
+	root := t.TempDir()
+	python := filepath.Join(t.TempDir(), "python")
+	writeWorkspaceFile(t, python, "#!/bin/sh\nif [ \"$1\" = --version ]; then echo 'Python 3.12.1'; else echo '{\"path\": [], \"builtins\": []}'; fi\n")
+	if err := os.Chmod(python, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mainPath := filepath.Join(root, "main.py")
+	mainCode := "import sys\n\nif sys.version_info >= (3, 11):\n    def load(): ...\n\nif sys.platform == \"win32\":\n    def register(): ...\nelse:\n    def register(): ...\n\nload()\nregister()\n"
+	writeWorkspaceFile(t, mainPath, mainCode)
+
+	s := New(nil)
+	rootURI := pathToURI(root)
+	options, err := json.Marshal(map[string]any{
+		"python": map[string]any{"pythonPath": python, "platform": "win32"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, rpcErr := s.Initialize(&lsp.InitializeParams{RootURI: &rootURI, InitializationOptions: options}); rpcErr != nil {
+		t.Fatalf("initialize failed: %v", rpcErr)
+	}
+	s.Initialized(nil)
+	if err := s.WaitForIndexing(); err != nil {
+		t.Fatalf("indexing failed: %v", err)
+	}
+
+	if target := s.analysisTarget(); target != (analyser.Target{Major: 3, Minor: 12, Platform: "win32"}) {
+		t.Fatalf("expected to analyse for Python 3.12 on win32, got %s", target)
+	}
+
+	mainURI := pathToURI(mainPath)
+	s.Open(lsp.TextDocumentItem{URI: mainURI, Text: mainCode, Version: 1})
+	s.analyze(s.Get(mainURI))
+	assertSemanticDiagnostic(t, s.Get(mainURI), "code is inactive for Python 3.12 on win32", 8, 4)
+	for _, err := range s.Get(mainURI).SemErrs {
+		if strings.HasPrefix(err.Msg, "undefined name") {
+			t.Fatalf("unexpected diagnostic %q", err.Msg)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package server
 
 import (
-	"sort"
 	"strings"
 
 	a "rahu/analyser"
@@ -52,13 +51,15 @@ func callArgNodes(tree *ast.AST, callID ast.NodeID) []ast.NodeID {
 	return children[1:]
 }
 
-func callableSymbolAtCall(doc *Document, callID ast.NodeID) *a.Symbol {
+// callableSymbolAtCall returns the function invoked by a call. Class calls
+// resolve to the class's __init__, and the class is returned alongside it.
+func callableSymbolAtCall(doc *Document, callID ast.NodeID) (*a.Symbol, *a.Symbol) {
 	if doc == nil || doc.Tree == nil || callID == ast.NoNode {
-		return nil
+		return nil, nil
 	}
 	callee := callCalleeNode(doc.Tree, callID)
 	if callee == ast.NoNode {
-		return nil
+		return nil, nil
 	}
 
 	var sym *a.Symbol
@@ -71,35 +72,56 @@ func callableSymbolAtCall(doc *Document, callID ast.NodeID) *a.Symbol {
 	case ast.NodeAttribute:
 		sym = doc.AttrSymbols[callee]
 	}
-	if sym == nil || sym.Kind != a.SymFunction {
-		return nil
+	if sym == nil {
+		return nil, nil
 	}
-	return sym
-}
-
-func orderedParams(sym *a.Symbol) []*a.Symbol {
-	if sym == nil || sym.Inner == nil {
-		return nil
+	if sym.Kind == a.SymFunction {
+		return sym, nil
 	}
-	params := make([]*a.Symbol, 0, len(sym.Inner.Symbols))
-	for _, inner := range sym.Inner.Symbols {
-		if inner != nil && inner.Kind == a.SymParameter {
-			params = append(params, inner)
+	if typ := a.SymbolType(sym); typ != nil && typ.Kind == a.TypeClass && typ.Symbol != nil {
+		if init, ok := a.LookupMemberOnType(typ, "__init__"); ok && init != nil && init.Kind == a.SymFunction {
+			return init, typ.Symbol
 		}
 	}
-	sort.Slice(params, func(i, j int) bool {
-		if params[i].Span.Start != params[j].Span.Start {
-			return params[i].Span.Start < params[j].Span.Start
+	return nil, nil
+}
+
+// calleeCallable returns the callable type of a call's callee when it is
+// not a function, such as a Callable-annotated variable or attribute, a
+// function reference or an instance with __call__.
+func calleeCallable(doc *Document, callID ast.NodeID) (string, *a.Type) {
+	callee := callCalleeNode(doc.Tree, callID)
+	var sym *a.Symbol
+	var recv *a.Type
+	nameNode := callee
+	switch doc.Tree.Node(callee).Kind {
+	case ast.NodeName:
+		sym = doc.Symbols[callee]
+	case ast.NodeAttribute:
+		sym = doc.AttrSymbols[callee]
+		if base := doc.Tree.ChildAt(callee, 0); doc.Tree.Node(base).Kind == ast.NodeName {
+			recv = a.SymbolType(doc.Symbols[base])
 		}
-		return params[i].Name < params[j].Name
-	})
-	return params
+		nameNode = doc.Tree.ChildAt(callee, 1)
+	}
+	call := a.CallableOf(a.ReferenceType(sym, recv))
+	if call == nil || call.Params == nil {
+		return "", nil
+	}
+	name, _ := doc.Tree.NameText(nameNode)
+	return name, call
 }
 
 func formatSignatureParam(sym *a.Symbol) string {
 	if sym == nil {
 		return ""
 	}
+	if sym.IsPosOnly && sym.Def == ast.NoNode && sym.Span == (ast.Range{}) {
+		// Parameters of a Callable[[...], R] annotation have no names.
+		if typeText := formatHoverType(a.SymbolType(sym)); typeText != "" {
+			return typeText
+		}
+	}
 	var b strings.Builder
 	if sym.IsKwArg {
 		b.WriteString("**")
@@ -122,21 +144,49 @@ func signatureLabel(sym *a.Symbol) (string, []lsp.ParameterInformation) {
 	if sym == nil {
 		return "", nil
 	}
-	params := orderedParams(sym)
-	parts := make([]string, 0, len(params))
+	name := sym.Name
+	if cls := classOwner(sym.Scope); cls != nil {
+		name = cls.Name + "." + name
+	}
+	return formatSignature(name, a.OrderedParams(sym), sym.Returns)
+}
+
+// constructorParams drops the bound self parameter of an __init__ signature.
+func constructorParams(init *a.Symbol) []*a.Symbol {
+	params := a.OrderedParams(init)
+	if len(params) > 0 && !params[0].IsVarArg && !params[0].IsKwArg {
+		return params[1:]
+	}
+	return params
+}
+
+func formatSignature(name string, params []*a.Symbol, returns *a.Type) (string, []lsp.ParameterInformation) {
+	parts := make([]string, 0, len(params)+1)
 	paramInfos := make([]lsp.ParameterInformation, 0, len(params))
+	starred := false
+	posOnly := false
 	for _, param := range params {
+		if posOnly && !param.IsPosOnly {
+			parts = append(parts, "/")
+		}
+		posOnly = param.IsPosOnly
+		if param.IsVarArg {
+			starred = true
+		}
+		if param.IsKwOnly && !starred {
+			parts = append(parts, "*")
+			starred = true
+		}
 		label := formatSignatureParam(param)
 		parts = append(parts, label)
 		paramInfos = append(paramInfos, lsp.ParameterInformation{Label: label})
 	}
-	name := sym.Name
-	if cls := classOwner(sym.Scope); cls != nil {
-		name = cls.Name + "." + name
+	if posOnly {
+		parts = append(parts, "/")
 	}
 	label := name + "(" + strings.Join(parts, ", ") + ")"
-	if returns := formatHoverType(sym.Returns); returns != "" {
-		label += " -> " + returns
+	if returnText := formatHoverType(returns); returnText != "" {
+		label += " -> " + returnText
 	}
 	return label, paramInfos
 }
@@ -179,6 +229,52 @@ func activeParameterForCall(tree *ast.AST, callID ast.NodeID, pos int, params []
 	return active
 }
 
+// typedCallArgs describes the arguments written before pos, for matching
+// overloads while the call is still being typed. A comma before the cursor
+// counts as the start of one more positional argument.
+func typedCallArgs(tree *ast.AST, text string, callID ast.NodeID, pos int) a.CallArgs {
+	args := a.CallArgs{Partial: true}
+	lastEnd := -1
+	for _, arg := range callArgNodes(tree, callID) {
+		r := tree.RangeOf(arg)
+		if int(r.Start) >= pos {
+			break
+		}
+		if tree.Node(arg).Kind != ast.NodeErrExp {
+			lastEnd = int(r.End)
+		}
+		switch tree.Node(arg).Kind {
+		case ast.NodeErrExp:
+		case ast.NodeKeywordArg:
+			if name, ok := tree.NameText(tree.ChildAt(arg, 0)); ok {
+				args.Keywords = append(args.Keywords, name)
+			}
+		case ast.NodeStarArg, ast.NodeKwStarArg:
+			args.Starred = true
+		default:
+			args.Positional = append(args.Positional, nil)
+		}
+	}
+	if lastEnd >= 0 && lastEnd < pos && pos <= len(text) && strings.Contains(text[lastEnd:pos], ",") {
+		args.Positional = append(args.Positional, nil)
+	}
+	return args
+}
+
+// isBoundCall reports whether the callee of a method call supplies self or
+// cls implicitly.
+func isBoundCall(doc *Document, callID ast.NodeID, fn *a.Symbol) bool {
+	callee := callCalleeNode(doc.Tree, callID)
+	if doc.Tree.Node(callee).Kind != ast.NodeAttribute {
+		return false
+	}
+	var recv *a.Type
+	if base := doc.Tree.ChildAt(callee, 0); doc.Tree.Node(base).Kind == ast.NodeName {
+		recv = a.SymbolType(doc.Symbols[base])
+	}
+	return a.IsBoundMethod(fn, recv)
+}
+
 func (s *Server) SignatureHelp(p *lsp.SignatureHelpParams) (*lsp.SignatureHelp, *jsonrpc.Error) {
 	doc := s.Get(p.TextDocument.URI)
 	if doc == nil {
@@ -196,22 +292,55 @@ func (s *Server) SignatureHelp(p *lsp.SignatureHelpParams) (*lsp.SignatureHelp,
 	if callID == ast.NoNode {
 		return nil, jsonrpc.InvalidParamsError(nil)
 	}
-	sym := callableSymbolAtCall(doc, callID)
+	sym, cls := callableSymbolAtCall(doc, callID)
 	if sym == nil {
-		return nil, jsonrpc.InvalidParamsError(nil)
+		name, call := calleeCallable(doc, callID)
+		if call == nil {
+			return nil, jsonrpc.InvalidParamsError(nil)
+		}
+		label, params := formatSignature(name, call.Params, call.Returns)
+		active := activeParameterForCall(doc.Tree, callID, offset, call.Params)
+		return &lsp.SignatureHelp{
+			Signatures: []lsp.SignatureInformation{{
+				Label:           label,
+				Parameters:      params,
+				ActiveParameter: active,
+			}},
+			ActiveParameter: active,
+		}, nil
 	}
-	label, params := signatureLabel(sym)
-	if label == "" {
+
+	bound := cls != nil || isBoundCall(doc, callID, sym)
+	typed := typedCallArgs(doc.Tree, doc.Text, callID, offset)
+	help := &lsp.SignatureHelp{}
+	activeSet := false
+	for _, sig := range a.Signatures(sym) {
+		ordered := a.OrderedParams(sig)
+		label, params := signatureLabel(sig)
+		if cls != nil {
+			ordered = constructorParams(sig)
+			label, params = formatSignature(cls.Name, ordered, nil)
+		}
+		if label == "" {
+			continue
+		}
+		active := activeParameterForCall(doc.Tree, callID, offset, ordered)
+		if !activeSet && a.AcceptsCall(sig, bound, typed) {
+			help.ActiveSignature = len(help.Signatures)
+			help.ActiveParameter = active
+			activeSet = true
+		}
+		help.Signatures = append(help.Signatures, lsp.SignatureInformation{
+			Label:           label,
+			Parameters:      params,
+			ActiveParameter: active,
+		})
+	}
+	if len(help.Signatures) == 0 {
 		return nil, jsonrpc.InvalidParamsError(nil)
 	}
-	ordered := orderedParams(sym)
-	active := activeParameterForCall(doc.Tree, callID, offset, ordered)
-	return &lsp.SignatureHelp{
-		Signatures: []lsp.SignatureInformation{{
-			Label:      label,
-			Parameters: params,
-		}},
-		ActiveSignature: 0,
-		ActiveParameter: active,
-	}, nil
+	if !activeSet {
+		help.ActiveParameter = help.Signatures[0].ActiveParameter
+	}
+	return help, nil
 }
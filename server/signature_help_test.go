@@ -141,6 +141,140 @@ func TestSignatureHelpShowsVarArgsAndKwArgs(t *testing.T) {
 	}
 }
 
+func TestSignatureHelpDataclassConstructor(t *testing.T) {
+	code := "from dataclasses import dataclass, field\n\n@dataclass(kw_only=False)\nclass Point:\n    x: int\n    y: int = field(default=0, kw_only=True)\n\nPoint(1, \n"
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	help, err := s.SignatureHelp(signatureHelpParams(uri, code, 7, 9))
+	if err != nil {
+		t.Fatalf("unexpected signatureHelp error: %v", err)
+	}
+	label := help.Signatures[0].Label
+	if label != "Point(x: int, *, y: int = 0)" {
+		t.Fatalf("unexpected constructor signature label: %q", label)
+	}
+	if len(help.Signatures[0].Parameters) != 2 || help.ActiveParameter != 1 {
+		t.Fatalf("unexpected constructor parameters: %+v", help)
+	}
+}
+
+func TestSignatureHelpNamedTupleFunctionalForm(t *testing.T) {
+	code := "from collections import namedtuple\n\nPair = namedtuple(\"Pair\", [\"left\", \"right\"])\nPair(\n"
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	help, err := s.SignatureHelp(signatureHelpParams(uri, code, 3, 5))
+	if err != nil {
+		t.Fatalf("unexpected signatureHelp error: %v", err)
+	}
+	if label := help.Signatures[0].Label; label != "Pair(left, right)" {
+		t.Fatalf("unexpected namedtuple signature label: %q", label)
+	}
+}
+
+func TestSignatureHelpListsOverloads(t *testing.T) {
+	code := "from typing import overload\n\n@overload\ndef pick(a: int) -> int: ...\n@overload\ndef pick(a: str, b: str) -> str: ...\n\npick(\"x\", \n"
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	help, err := s.SignatureHelp(signatureHelpParams(uri, code, 7, 10))
+	if err != nil {
+		t.Fatalf("unexpected signatureHelp error: %v", err)
+	}
+	if len(help.Signatures) != 2 {
+		t.Fatalf("expected both overloads, got %+v", help.Signatures)
+	}
+	if help.Signatures[0].Label != "pick(a: int) -> int" || help.Signatures[1].Label != "pick(a: str, b: str) -> str" {
+		t.Fatalf("unexpected overload labels: %+v", help.Signatures)
+	}
+	if help.ActiveSignature != 1 || help.ActiveParameter != 1 {
+		t.Fatalf("expected the two-argument overload to be active, got %+v", help)
+	}
+}
+
+func TestSignatureHelpParameterSeparators(t *testing.T) {
+	code := "def f(a, /, b, *, c):\n    pass\n\nf(\n"
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	help, err := s.SignatureHelp(signatureHelpParams(uri, code, 3, 2))
+	if err != nil {
+		t.Fatalf("unexpected signatureHelp error: %v", err)
+	}
+	if label := help.Signatures[0].Label; label != "f(a, /, b, *, c)" {
+		t.Fatalf("unexpected separator signature label: %q", label)
+	}
+}
+
+func TestSignatureHelpCallableValues(t *testing.T) {
+	code := `from typing import Callable
+
+class Request: ...
+class Response: ...
+
+registry: dict[str, Callable[[Request], Response]] = {}
+handler = registry["index"]
+handler(None)
+
+class Service:
+    def __init__(self, callback: Callable[[int, str], bool]):
+        self.callback = callback
+
+    def run(self):
+        self.callback(1, "a")
+`
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	cases := []struct {
+		line, char int
+		label      string
+		active     int
+	}{
+		{7, 8, "handler(Request, /) -> Response", 0},
+		{14, 26, "callback(int, str, /) -> bool", 1},
+	}
+	for _, tc := range cases {
+		help, err := s.SignatureHelp(signatureHelpParams(uri, code, tc.line, tc.char))
+		if err != nil {
+			t.Fatalf("unexpected signatureHelp error at %d:%d: %v", tc.line, tc.char, err)
+		}
+		if label := help.Signatures[0].Label; label != tc.label {
+			t.Fatalf("expected %q, got %q", tc.label, label)
+		}
+		if help.ActiveParameter != tc.active {
+			t.Fatalf("expected active parameter %d for %q, got %d", tc.active, tc.label, help.ActiveParameter)
+		}
+	}
+}
+
+func TestSignatureHelpPartialShowsRemainingParameters(t *testing.T) {
+	code := "from functools import partial\n\ndef add(a: int, b: int) -> int:\n    return a + b\n\np = partial(add, 1)\np(\n"
+	s := New(nil)
+	uri := lsp.DocumentURI("file:///test.py")
+	s.Open(lsp.TextDocumentItem{URI: uri, Text: code, Version: 1})
+	s.analyze(s.Get(uri))
+
+	help, err := s.SignatureHelp(signatureHelpParams(uri, code, 6, 2))
+	if err != nil {
+		t.Fatalf("unexpected signatureHelp error: %v", err)
+	}
+	if label := help.Signatures[0].Label; label != "p(b: int) -> int" {
+		t.Fatalf("expected the parameters partial leaves unbound, got %q", label)
+	}
+}
+
 func signatureHelpParams(uri lsp.DocumentURI, code string, line, char int) *lsp.SignatureHelpParams {
 	li := source.NewLineIndex(code)
 	offset := li.PositionToOffset(line, char)
@@ -8,6 +8,8 @@ import (
 	"log"
 	"path/filepath"
 	"rahu"
+	"rahu/analyser"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -448,6 +450,22 @@ func parsePythonVersion(s string) (PythonVersion, error) {
 	return PythonVersion{Major: major, Minor: minor}, nil
 }
 
+// pythonTarget returns the interpreter the analyser evaluates
+// sys.version_info and sys.platform conditions against, given the version an
+// interpreter reports, such as "3.11.7", and the configured platform, which
+// defaults to the host's. An unknown version leaves version checks
+// undecided.
+func pythonTarget(version, platform string) analyser.Target {
+	target := analyser.Target{Platform: platform}
+	if target.Platform == "" {
+		target.Platform = analyser.PlatformForGOOS(runtime.GOOS)
+	}
+	if v, err := parsePythonVersion(version); err == nil {
+		target.Major, target.Minor = v.Major, v.Minor
+	}
+	return target
+}
+
 // comparePythonVersions compares two Python versions.
 // Returns -1 if a < b, 0 if a == b, 1 if a > b.
 func comparePythonVersions(a, b PythonVersion) int {
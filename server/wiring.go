@@ -54,6 +54,11 @@ func Register(s *Server) {
 		jsonrpc.AdaptRequest(s.References),
 	)
 
+	jsonrpc.RegisterRequest(
+		"textDocument/implementation",
+		jsonrpc.AdaptRequest(s.Implementation),
+	)
+
 	jsonrpc.RegisterRequest(
 		"textDocument/completion",
 		jsonrpc.AdaptRequest(s.Completion),
@@ -84,8 +89,18 @@ func Register(s *Server) {
 		jsonrpc.AdaptRequest(s.DocumentSymbol),
 	)
 
+	jsonrpc.RegisterRequest(
+		"textDocument/codeAction",
+		jsonrpc.AdaptRequest(s.CodeAction),
+	)
+
 	jsonrpc.RegisterRequest(
 		"workspace/symbol",
 		jsonrpc.AdaptRequest(s.WorkspaceSymbol),
 	)
+
+	jsonrpc.RegisterRequest(
+		"rahu/importGraph",
+		jsonrpc.AdaptRequest(s.ImportGraph),
+	)
 }
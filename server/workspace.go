@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -152,13 +153,18 @@ func hasPythonProjectMarker(path string) bool {
 	return false
 }
 
-func hasImportablePythonModule(path string) bool {
+// hasImportablePythonModule reports whether path holds a module or package
+// that is not excluded from the workspace at rootPath.
+func hasImportablePythonModule(path, rootPath string, exclude []string) bool {
 	entries, err := os.ReadDir(path)
 	if err != nil {
 		return false
 	}
 	for _, entry := range entries {
 		name := entry.Name()
+		if isExcludedPath(rootPath, exclude, filepath.Join(path, name)) {
+			continue
+		}
 		if entry.IsDir() {
 			if strings.HasPrefix(name, ".") || shouldSkipWorkspaceDir(name) {
 				continue
@@ -178,18 +184,18 @@ func hasImportablePythonModule(path string) bool {
 	return false
 }
 
-func detectImportRoot(projectRoot string) string {
+func detectImportRoot(projectRoot, rootPath string, exclude []string) string {
 	if projectRoot == "" {
 		return ""
 	}
 	srcRoot := filepath.Join(projectRoot, "src")
-	if info, err := os.Stat(srcRoot); err == nil && info.IsDir() && hasImportablePythonModule(srcRoot) {
+	if info, err := os.Stat(srcRoot); err == nil && info.IsDir() && hasImportablePythonModule(srcRoot, rootPath, exclude) {
 		return srcRoot
 	}
 	return projectRoot
 }
 
-func appendPythonProjectRoot(roots []PythonProjectRoot, projectRoot string) []PythonProjectRoot {
+func appendPythonProjectRoot(roots []PythonProjectRoot, projectRoot, rootPath string, exclude []string) []PythonProjectRoot {
 	if projectRoot == "" {
 		return roots
 	}
@@ -202,10 +208,12 @@ func appendPythonProjectRoot(roots []PythonProjectRoot, projectRoot string) []Py
 			return roots
 		}
 	}
-	return append(roots, PythonProjectRoot{ProjectRoot: projectAbs, ImportRoot: detectImportRoot(projectAbs)})
+	return append(roots, PythonProjectRoot{ProjectRoot: projectAbs, ImportRoot: detectImportRoot(projectAbs, rootPath, exclude)})
 }
 
-func findPythonProjectRoots(rootPath string) []PythonProjectRoot {
+// findPythonProjectRoots finds the workspace root and the nested projects
+// under it, leaving out directories the exclude globs match.
+func findPythonProjectRoots(rootPath string, exclude []string) []PythonProjectRoot {
 	if rootPath == "" {
 		return nil
 	}
@@ -221,11 +229,11 @@ func findPythonProjectRoots(rootPath string) []PythonProjectRoot {
 		if !d.IsDir() {
 			return nil
 		}
-		if path != rootAbs && shouldSkipWorkspaceDir(d.Name()) {
+		if path != rootAbs && (shouldSkipWorkspaceDir(d.Name()) || isExcludedPath(rootAbs, exclude, path)) {
 			return filepath.SkipDir
 		}
 		if path != rootAbs && hasPythonProjectMarker(path) {
-			roots = appendPythonProjectRoot(roots, path)
+			roots = appendPythonProjectRoot(roots, path, rootAbs, exclude)
 		}
 		return nil
 	})
@@ -305,11 +313,12 @@ func (s *Server) buildModuleIndex() {
 func (s *Server) buildModuleIndexWithContext(ctx context.Context) error {
 	s.miscMu.Lock()
 	rootPath := s.rootPath
+	exclude := s.settings.Exclude
 	s.miscMu.Unlock()
 
 	modulesByName := make(map[string]ModuleFile)
 	modulesByURI := make(map[lsp.DocumentURI]ModuleFile)
-	projectRoots := findPythonProjectRoots(rootPath)
+	projectRoots := findPythonProjectRoots(rootPath, exclude)
 	if rootPath == "" {
 		s.indexMu.Lock()
 		s.modulesByName = modulesByName
@@ -332,13 +341,14 @@ func (s *Server) buildModuleIndexWithContext(ctx context.Context) error {
 		if err != nil || d == nil {
 			return nil
 		}
+		excluded := isExcludedPath(rootPath, exclude, path)
 		if d.IsDir() {
-			if shouldSkipWorkspaceDir(d.Name()) {
+			if shouldSkipWorkspaceDir(d.Name()) || excluded {
 				return filepath.SkipDir
 			}
 			return nil
 		}
-		if !isPythonModulePath(path) {
+		if !isPythonModulePath(path) || excluded {
 			return nil
 		}
 
@@ -377,6 +387,7 @@ func (s *Server) buildModuleIndexWithContext(ctx context.Context) error {
 	// Reset dependencies
 	s.depsMu.Lock()
 	s.moduleImportsByURI = make(map[lsp.DocumentURI][]string)
+	s.runtimeImportsByURI = make(map[lsp.DocumentURI][]string)
 	s.reverseDepsByModule = make(map[string]map[lsp.DocumentURI]struct{})
 	s.depsMu.Unlock()
 
@@ -386,6 +397,7 @@ func (s *Server) buildModuleIndexWithContext(ctx context.Context) error {
 	s.openModuleCounts = make(map[lsp.DocumentURI]int)
 	s.moduleSnapshotsByName = make(map[string]*ModuleSnapshot)
 	s.moduleSnapshotsByURI = make(map[lsp.DocumentURI]*ModuleSnapshot)
+	s.classSummariesByURI = make(map[lsp.DocumentURI][]classSummary)
 	s.snapshotLRU = newSnapshotLRU()
 	s.snapshotsMu.Unlock()
 
@@ -859,9 +871,10 @@ func (s *Server) applySnapshotToOpenDocument(snapshot *ModuleSnapshot) {
 		return
 	}
 
-	s.SetAnalysis(snapshot.URI, snapshot.Tree, snapshot.Global, snapshot.Defs, snapshot.Symbols, snapshot.AttrSymbols, snapshot.SemErrs)
+	semErrs := s.documentErrors(snapshot)
+	s.SetAnalysis(snapshot.URI, snapshot.Tree, snapshot.Global, snapshot.Defs, snapshot.Symbols, snapshot.AttrSymbols, semErrs)
 	s.markOpenDocumentSnapshotApplied(snapshot.URI)
-	s.publishDiagnostics(snapshot.URI, toDiagnostics(snapshot.LineIndex, snapshot.ParseErrs, snapshot.SemErrs))
+	s.publishDiagnostics(snapshot.URI, toDiagnostics(snapshot.URI, snapshot.LineIndex, snapshot.ParseErrs, semErrs))
 }
 
 func (s *Server) refreshModuleAndDependents(uri lsp.DocumentURI) {
@@ -870,12 +883,26 @@ func (s *Server) refreshModuleAndDependents(uri lsp.DocumentURI) {
 	if oldRoot != nil {
 		oldRootHash = oldRoot.ExportHash
 	}
+	s.depsMu.RLock()
+	oldRuntimeImports := s.runtimeImportsByURI[uri]
+	s.depsMu.RUnlock()
+	var oldCycle []string
+	if mod, ok := s.LookupModuleByURI(uri); ok {
+		oldCycle = s.importCycleMembers(mod.Name)
+	}
 
 	rootSnapshot, ok := s.rebuildModuleSnapshotOnly(uri)
 	if !ok || rootSnapshot == nil {
 		return
 	}
 	s.applySnapshotToOpenDocument(rootSnapshot)
+	s.depsMu.RLock()
+	importsChanged := !slices.Equal(oldRuntimeImports, s.runtimeImportsByURI[uri])
+	s.depsMu.RUnlock()
+	if importsChanged {
+		// Other open modules on a cycle the root joined or left report it.
+		defer s.refreshImportCycles(uri, rootSnapshot.Name, oldCycle)
+	}
 	if oldRootHash != 0 && oldRootHash == rootSnapshot.ExportHash {
 		// Exports unchanged: no dependents need rebuilding, but root's import
 		// list may have changed (e.g. user added "import os"), so update revdeps.
@@ -946,6 +973,21 @@ func (s *Server) LookupModule(name string) (ModuleFile, bool) {
 	return s.resolveExternalModule(name)
 }
 
+// indexedModuleNames lists the workspace and external modules indexed so far,
+// the candidates for correcting a misspelled import.
+func (s *Server) indexedModuleNames() []string {
+	s.indexMu.RLock()
+	defer s.indexMu.RUnlock()
+	names := make([]string, 0, len(s.modulesByName)+len(s.externalModulesByName))
+	for name := range s.modulesByName {
+		names = append(names, name)
+	}
+	for name := range s.externalModulesByName {
+		names = append(names, name)
+	}
+	return names
+}
+
 func (s *Server) LookupModuleByURI(uri lsp.DocumentURI) (ModuleFile, bool) {
 	s.indexMu.RLock()
 	module, ok := s.modulesByURI[uri]
@@ -127,7 +127,7 @@ func TestFindPythonProjectRootsPrefersSrcImportRoot(t *testing.T) {
 	writeWorkspaceSource(t, filepath.Join(requestsRoot, "pyproject.toml"), "[project]\nname='requests'\n")
 	writeWorkspaceSource(t, filepath.Join(requestsRoot, "src", "requests", "__init__.py"), "")
 
-	roots := findPythonProjectRoots(root)
+	roots := findPythonProjectRoots(root, nil)
 	for _, got := range roots {
 		if got.ProjectRoot == requestsRoot {
 			wantImportRoot := filepath.Join(requestsRoot, "src")
@@ -140,6 +140,32 @@ func TestFindPythonProjectRootsPrefersSrcImportRoot(t *testing.T) {
 	t.Fatalf("expected nested requests project root in %+v", roots)
 }
 
+func TestFindPythonProjectRootsSkipsExcludedPaths(t *testing.T) {
+	root := t.TempDir()
+	generatedRoot := filepath.Join(root, "generated", "client")
+	writeWorkspaceSource(t, filepath.Join(generatedRoot, "setup.py"), "")
+	requestsRoot := filepath.Join(root, "pythonLibs", "requests")
+	writeWorkspaceSource(t, filepath.Join(requestsRoot, "pyproject.toml"), "[project]\nname='requests'\n")
+	writeWorkspaceSource(t, filepath.Join(requestsRoot, "src", "requests", "__init__.py"), "")
+
+	roots := findPythonProjectRoots(root, []string{"generated", "pythonLibs/requests/src/requests"})
+	found := false
+	for _, got := range roots {
+		if got.ProjectRoot == generatedRoot {
+			t.Fatalf("expected the excluded project not to be a root, got %+v", roots)
+		}
+		if got.ProjectRoot == requestsRoot {
+			found = true
+			if got.ImportRoot != requestsRoot {
+				t.Fatalf("expected src with only excluded packages not to be the import root, got %q", got.ImportRoot)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected nested requests project root in %+v", roots)
+	}
+}
+
 func TestModuleNameForPathNestedSrcLayout(t *testing.T) {
 	root := t.TempDir()
 	requestsRoot := filepath.Join(root, "pythonLibs", "requests")
@@ -147,7 +173,7 @@ func TestModuleNameForPathNestedSrcLayout(t *testing.T) {
 	structuresPath := filepath.Join(requestsRoot, "src", "requests", "structures.py")
 	writeWorkspaceSource(t, structuresPath, "value = 1\n")
 
-	roots := findPythonProjectRoots(root)
+	roots := findPythonProjectRoots(root, nil)
 	got, ok := moduleNameForPath(structuresPath, roots)
 	if !ok {
 		t.Fatal("expected module name for nested src path")
@@ -285,6 +285,36 @@ func printNode(w io.Writer, tree *ast.AST, id ast.NodeID, indent int, opts Print
 			printNode(w, tree, body, indent+4, opts)
 		}
 
+	case ast.NodeMatch:
+		fmt.Fprintf(w, "%s%s\n", prefix, nodeLabel(opts, "Match:"))
+		subject, cases := tree.MatchParts(id)
+		fmt.Fprintf(w, "%s  %s\n", prefix, field(opts, "Subject:"))
+		printNode(w, tree, subject, indent+4, opts)
+		for _, clause := range cases {
+			printNode(w, tree, clause, indent+2, opts)
+		}
+
+	case ast.NodeMatchCase:
+		fmt.Fprintf(w, "%s%s\n", prefix, nodeLabel(opts, "Case:"))
+		pattern, guard, body := tree.MatchCaseParts(id)
+		fmt.Fprintf(w, "%s  %s\n", prefix, field(opts, "Pattern:"))
+		printNode(w, tree, pattern, indent+4, opts)
+		if guard != ast.NoNode {
+			fmt.Fprintf(w, "%s  %s\n", prefix, field(opts, "Guard:"))
+			printNode(w, tree, guard, indent+4, opts)
+		}
+		if body != ast.NoNode {
+			fmt.Fprintf(w, "%s  %s\n", prefix, field(opts, "Body:"))
+			printNode(w, tree, body, indent+4, opts)
+		}
+
+	case ast.NodeMatchAs:
+		fmt.Fprintf(w, "%s%s\n", prefix, nodeLabel(opts, "MatchAs:"))
+		fmt.Fprintf(w, "%s  %s\n", prefix, field(opts, "Pattern:"))
+		printNode(w, tree, tree.ChildAt(id, 0), indent+4, opts)
+		fmt.Fprintf(w, "%s  %s\n", prefix, field(opts, "Name:"))
+		printNode(w, tree, tree.ChildAt(id, 1), indent+4, opts)
+
 	case ast.NodeWithItem:
 		fmt.Fprintf(w, "%s%s\n", prefix, nodeLabel(opts, "WithItem:"))
 		contextExpr, asTarget := tree.WithItemParts(id)